@@ -23,7 +23,11 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/getkin/kin-openapi/openapi3"
+
 	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/ratelimit"
 )
 
 //nolint:gochecknoglobals
@@ -35,6 +39,58 @@ func report(v ...any) {
 	failed = true
 }
 
+// pathParameters returns the names of all path parameters for an operation.
+func pathParameters(path *openapi3.PathItem, operation *openapi3.Operation) []string {
+	var names []string
+
+	for _, parameters := range []openapi3.Parameters{path.Parameters, operation.Parameters} {
+		for _, parameter := range parameters {
+			if parameter.Value != nil && parameter.Value.In == openapi3.ParameterInPath {
+				names = append(names, parameter.Value.Name)
+			}
+		}
+	}
+
+	return names
+}
+
+// validatePermission checks secured operations declare a valid required permission.
+func validatePermission(method, pathName string, path *openapi3.PathItem, operation *openapi3.Operation) {
+	// Only secured operations can be authorized.
+	if operation.Security == nil || len(*operation.Security) == 0 || len((*operation.Security)[0]) == 0 {
+		return
+	}
+
+	// You have to explicitly opt out from following the rules.
+	if _, ok := operation.Extensions[authorization.NoPermissionExtension]; ok {
+		return
+	}
+
+	permission, err := authorization.PermissionFromOperation(operation)
+	if err != nil {
+		report("required permission for", method, pathName, "invalid:", err)
+
+		return
+	}
+
+	if permission == nil {
+		report("no required permission set for", method, pathName)
+
+		return
+	}
+
+	if err := permission.Validate(pathParameters(path, operation)); err != nil {
+		report("required permission for", method, pathName, "invalid:", err)
+	}
+}
+
+// validateRateLimit checks any rate limit overrides are well formed.
+func validateRateLimit(method, pathName string, operation *openapi3.Operation) {
+	if _, err := ratelimit.LimitFromOperation(operation); err != nil {
+		report("rate limit for", method, pathName, "invalid:", err)
+	}
+}
+
 //nolint:gocognit,cyclop
 func main() {
 	spec, err := openapi.GetSwagger()
@@ -64,6 +120,12 @@ func main() {
 				os.Exit(1)
 			}
 
+			// Secured operations need to declare what permission is required.
+			validatePermission(method, pathName, path, operation)
+
+			// Rate limit overrides must be parseable at runtime.
+			validateRateLimit(method, pathName, operation)
+
 			//nolint:nestif
 			if method == http.MethodGet {
 				// Where there are responses, they must have a schema.
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AuditRecordList defines a list of audit records.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type AuditRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AuditRecord `json:"items"`
+}
+
+// AuditRecord is an immutable record of a mutating API request.  Records are
+// labeled with the organization, project and resource they relate to so they
+// can be queried with a label selector.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Namespaced,categories=unikorn
+// +kubebuilder:printcolumn:name="resource",type="string",JSONPath=".spec.resourceID"
+// +kubebuilder:printcolumn:name="method",type="string",JSONPath=".spec.method"
+// +kubebuilder:printcolumn:name="path",type="string",JSONPath=".spec.path"
+// +kubebuilder:printcolumn:name="subject",type="string",JSONPath=".spec.subject"
+// +kubebuilder:printcolumn:name="outcome",type="string",JSONPath=".spec.outcome"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type AuditRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              AuditRecordSpec `json:"spec"`
+}
+
+// AuditOutcome defines the outcome of an audited request.
+// +kubebuilder:validation:Enum=success;denied;failure
+type AuditOutcome string
+
+const (
+	// AuditOutcomeSuccess means the request was successful.
+	AuditOutcomeSuccess AuditOutcome = "success"
+	// AuditOutcomeDenied means the request failed authentication or
+	// authorization.
+	AuditOutcomeDenied AuditOutcome = "denied"
+	// AuditOutcomeFailure means the request failed for any other reason.
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+type AuditRecordSpec struct {
+	// Time is when the request was handled.
+	Time metav1.Time `json:"time"`
+	// Issuer is the identity provider that authenticated the actor.
+	Issuer string `json:"issuer,omitempty"`
+	// Subject is the actor that made the request.
+	Subject string `json:"subject,omitempty"`
+	// OrganizationID is the organization the request was scoped to.
+	OrganizationID string `json:"organizationID,omitempty"`
+	// ProjectID is the project the request was scoped to.
+	ProjectID string `json:"projectID,omitempty"`
+	// OperationID is the API operation that was requested.
+	OperationID string `json:"operationID,omitempty"`
+	// Method is the HTTP method.
+	Method string `json:"method"`
+	// Path is the templated API path.
+	Path string `json:"path"`
+	// ResourceID is the resource that was acted upon.
+	ResourceID string `json:"resourceID,omitempty"`
+	// StatusCode is the HTTP status code returned to the client.
+	StatusCode int `json:"statusCode"`
+	// Outcome summarizes the status code.
+	Outcome AuditOutcome `json:"outcome"`
+	// RequestDigest is a SHA-256 digest of the redacted request body.
+	RequestDigest string `json:"requestDigest,omitempty"`
+	// TraceID allows the request to be found in telemetry.
+	TraceID string `json:"traceID,omitempty"`
+}
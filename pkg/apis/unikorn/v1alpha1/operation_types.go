@@ -0,0 +1,87 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OperationList defines a list of operations.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type OperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Operation `json:"items"`
+}
+
+// Operation tracks the progress of a long running API request that is
+// fulfilled asynchronously.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Namespaced,categories=unikorn
+// +kubebuilder:printcolumn:name="resource",type="string",JSONPath=".spec.resource"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="progress",type="integer",JSONPath=".status.progress"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type Operation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              OperationSpec   `json:"spec"`
+	Status            OperationStatus `json:"status,omitempty"`
+}
+
+type OperationSpec struct {
+	// Resource is the API path of the resource the operation acts upon,
+	// and is returned to clients as a link to the result.
+	Resource string `json:"resource,omitempty"`
+}
+
+// OperationPhase defines the lifecycle of an operation.
+// +kubebuilder:validation:Enum=pending;running;succeeded;failed
+type OperationPhase string
+
+const (
+	// OperationPhasePending means the operation has been accepted but not
+	// yet started.
+	OperationPhasePending OperationPhase = "pending"
+	// OperationPhaseRunning means the operation is in progress.
+	OperationPhaseRunning OperationPhase = "running"
+	// OperationPhaseSucceeded means the operation completed successfully.
+	OperationPhaseSucceeded OperationPhase = "succeeded"
+	// OperationPhaseFailed means the operation completed with an error.
+	OperationPhaseFailed OperationPhase = "failed"
+)
+
+type OperationError struct {
+	// Code is the API error code e.g. "conflict".
+	Code string `json:"code"`
+	// Description is a verbose, user facing, description of the error.
+	Description string `json:"description"`
+}
+
+type OperationStatus struct {
+	// Phase is the current phase of the operation.
+	Phase OperationPhase `json:"phase,omitempty"`
+	// Progress is an optional percentage estimate of completion.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Progress *int `json:"progress,omitempty"`
+	// Error is set when the operation has failed.
+	Error *OperationError `json:"error,omitempty"`
+	// CompletionTime is set when the operation has succeeded or failed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
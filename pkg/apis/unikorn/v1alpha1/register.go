@@ -35,6 +35,16 @@ const (
 	HelmApplicationKind = "HelmApplication"
 	// HelmApplicationResource is the API endpoint for helm application descriptors.
 	HelmApplicationResource = "helmapplications"
+
+	// OperationKind is the API kind for asynchronous operations.
+	OperationKind = "Operation"
+	// OperationResource is the API endpoint for asynchronous operations.
+	OperationResource = "operations"
+
+	// AuditRecordKind is the API kind for audit records.
+	AuditRecordKind = "AuditRecord"
+	// AuditRecordResource is the API endpoint for audit records.
+	AuditRecordResource = "auditrecords"
 )
 
 var (
@@ -53,7 +63,7 @@ var (
 
 //nolint:gochecknoinits
 func init() {
-	SchemeBuilder.Register(&HelmApplication{}, &HelmApplicationList{})
+	SchemeBuilder.Register(&HelmApplication{}, &HelmApplicationList{}, &Operation{}, &OperationList{}, &AuditRecord{}, &AuditRecordList{})
 }
 
 // Resource maps a resource type to a group resource.
@@ -283,7 +283,7 @@ const (
 
 // ConditionReason defines the possible reasons of a resource
 // condition.  These are generic and may be used by any condition.
-// +kubebuilder:validation:Enum=Provisioning;Provisioned;Cancelled;Errored;Deprovisioning;Deprovisioned;Unknown;Healthy;Degraded
+// +kubebuilder:validation:Enum=Provisioning;Provisioned;Cancelled;Errored;Deprovisioning;Deprovisioned;DeletionProtected;Unknown;Healthy;Degraded
 type ConditionReason string
 
 // Condition reasons for ConditionAvailable.
@@ -309,6 +309,10 @@ const (
 	// indicate we have finished deprovisioning and the Kubernetes
 	// garbage collector can remove the resource.
 	ConditionReasonDeprovisioned ConditionReason = "Deprovisioned"
+	// ConditionReasonDeletionProtected is used by a condition to
+	// indicate the resource has been deleted, but deprovisioning is blocked
+	// by the deletion protection annotation.
+	ConditionReasonDeletionProtected ConditionReason = "DeletionProtected"
 )
 
 // Condition reasons for ConditionHealthy.
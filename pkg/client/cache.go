@@ -0,0 +1,239 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrResourceType is raised when a resource type cannot be mapped to
+	// a type known by the scheme.
+	ErrResourceType = errors.New("unable to resolve resource type")
+)
+
+// CacheOptions restricts what resources are cached by informers.  By default
+// every object of every type that is read is cached cluster wide, which can
+// lead to large memory overheads.  Resource types are specified as "Kind.group"
+// e.g. "Application.argoproj.io", or just "Kind" for the core group e.g. "Secret".
+type CacheOptions struct {
+	// Namespaces restricts caching of a resource type to the listed namespaces.
+	Namespaces map[string][]string
+
+	// LabelSelectors restricts caching of a resource type to those resources
+	// that match the label selector.
+	LabelSelectors map[string]string
+
+	// Disabled resource types are never cached, and reads go directly to the
+	// API server.
+	Disabled []string
+}
+
+// resourceNamespacesFlag allows namespaces to be specified per resource type
+// in the form "Kind.group=namespace[,namespace]".
+type resourceNamespacesFlag struct {
+	values map[string][]string
+}
+
+var _ pflag.Value = &resourceNamespacesFlag{}
+
+// String implements the pflag.Value interface.
+func (s *resourceNamespacesFlag) String() string {
+	out := make([]string, 0, len(s.values))
+
+	for resource, namespaces := range s.values {
+		out = append(out, resource+"="+strings.Join(namespaces, ","))
+	}
+
+	slices.Sort(out)
+
+	return strings.Join(out, " ")
+}
+
+// Set implements the pflag.Value interface.
+func (s *resourceNamespacesFlag) Set(in string) error {
+	resource, namespaces, ok := strings.Cut(in, "=")
+	if !ok || resource == "" || namespaces == "" {
+		return fmt.Errorf("%w: expected Kind.group=namespace[,namespace]", coreerrors.ErrParseFlag)
+	}
+
+	s.values[resource] = strings.Split(namespaces, ",")
+
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *resourceNamespacesFlag) Type() string {
+	return "string"
+}
+
+// resourceSelectorFlag allows label selectors to be specified per resource type
+// in the form "Kind.group=selector".
+type resourceSelectorFlag struct {
+	values map[string]string
+}
+
+var _ pflag.Value = &resourceSelectorFlag{}
+
+// String implements the pflag.Value interface.
+func (s *resourceSelectorFlag) String() string {
+	out := make([]string, 0, len(s.values))
+
+	for resource, selector := range s.values {
+		out = append(out, resource+"="+selector)
+	}
+
+	slices.Sort(out)
+
+	return strings.Join(out, " ")
+}
+
+// Set implements the pflag.Value interface.
+func (s *resourceSelectorFlag) Set(in string) error {
+	resource, selector, ok := strings.Cut(in, "=")
+	if !ok || resource == "" || selector == "" {
+		return fmt.Errorf("%w: expected Kind.group=selector", coreerrors.ErrParseFlag)
+	}
+
+	if _, err := labels.Parse(selector); err != nil {
+		return fmt.Errorf("%w: %w", coreerrors.ErrParseFlag, err)
+	}
+
+	s.values[resource] = selector
+
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *resourceSelectorFlag) Type() string {
+	return "string"
+}
+
+// AddFlags adds the options to the CLI flags.  Any existing values are treated as
+// defaults, and flags will override them on a per resource type basis.
+func (o *CacheOptions) AddFlags(f *pflag.FlagSet) {
+	if o.Namespaces == nil {
+		o.Namespaces = map[string][]string{}
+	}
+
+	if o.LabelSelectors == nil {
+		o.LabelSelectors = map[string]string{}
+	}
+
+	f.Var(&resourceNamespacesFlag{values: o.Namespaces}, "cache-namespaces", "Restrict caching of a resource type to a set of namespaces, in the form Kind.group=namespace[,namespace] (may be specified multiple times)")
+	f.Var(&resourceSelectorFlag{values: o.LabelSelectors}, "cache-label-selector", "Restrict caching of a resource type to those matching a label selector, in the form Kind.group=selector (may be specified multiple times)")
+	f.StringSliceVar(&o.Disabled, "cache-disable", o.Disabled, "Resource types, in the form Kind.group, that are never cached")
+}
+
+// newObject resolves a resource type to a typed object known to the scheme.
+func newObject(scheme *runtime.Scheme, resource string) (client.Object, error) {
+	gk := schema.ParseGroupKind(resource)
+
+	for _, gv := range scheme.PrioritizedVersionsForGroup(gk.Group) {
+		for kind := range scheme.KnownTypes(gv) {
+			if !strings.EqualFold(kind, gk.Kind) {
+				continue
+			}
+
+			object, err := scheme.New(gv.WithKind(kind))
+			if err != nil {
+				return nil, err
+			}
+
+			if o, ok := object.(client.Object); ok {
+				return o, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrResourceType, resource)
+}
+
+// Apply resolves resource types against the scheme and updates the cache and
+// client options with any restrictions.
+func (o *CacheOptions) Apply(scheme *runtime.Scheme, cacheOptions *cache.Options, clientOptions *client.CacheOptions) error {
+	byObject := map[string]*cache.ByObject{}
+
+	getByObject := func(resource string) *cache.ByObject {
+		if b, ok := byObject[resource]; ok {
+			return b
+		}
+
+		b := &cache.ByObject{}
+
+		byObject[resource] = b
+
+		return b
+	}
+
+	for resource, namespaces := range o.Namespaces {
+		b := getByObject(resource)
+
+		b.Namespaces = map[string]cache.Config{}
+
+		for _, namespace := range namespaces {
+			b.Namespaces[namespace] = cache.Config{}
+		}
+	}
+
+	for resource, selector := range o.LabelSelectors {
+		s, err := labels.Parse(selector)
+		if err != nil {
+			return err
+		}
+
+		getByObject(resource).Label = s
+	}
+
+	for resource, b := range byObject {
+		object, err := newObject(scheme, resource)
+		if err != nil {
+			return err
+		}
+
+		if cacheOptions.ByObject == nil {
+			cacheOptions.ByObject = map[client.Object]cache.ByObject{}
+		}
+
+		cacheOptions.ByObject[object] = *b
+	}
+
+	for _, resource := range o.Disabled {
+		object, err := newObject(scheme, resource)
+		if err != nil {
+			return err
+		}
+
+		clientOptions.DisableFor = append(clientOptions.DisableFor, object)
+	}
+
+	return nil
+}
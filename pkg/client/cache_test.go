@@ -0,0 +1,84 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+
+	argoprojv1 "github.com/unikorn-cloud/core/pkg/apis/argoproj/v1alpha1"
+	coreclient "github.com/unikorn-cloud/core/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestCacheOptions checks flags are parsed and resolved into cache options.
+func TestCacheOptions(t *testing.T) {
+	t.Parallel()
+
+	scheme, err := coreclient.NewScheme()
+	require.NoError(t, err)
+
+	options := &coreclient.CacheOptions{
+		Disabled: []string{"ConfigMap"},
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	options.AddFlags(flags)
+
+	require.NoError(t, flags.Parse([]string{
+		"--cache-namespaces=Application.argoproj.io=argocd",
+		"--cache-label-selector=Application.argoproj.io=unikorn-cloud.org/application",
+		"--cache-disable=Secret",
+	}))
+
+	cacheOptions := &cache.Options{}
+	clientOptions := &client.CacheOptions{}
+
+	require.NoError(t, options.Apply(scheme, cacheOptions, clientOptions))
+
+	require.Len(t, cacheOptions.ByObject, 1)
+
+	for object, byObject := range cacheOptions.ByObject {
+		require.IsType(t, &argoprojv1.Application{}, object)
+		require.Contains(t, byObject.Namespaces, "argocd")
+		require.Equal(t, "unikorn-cloud.org/application", byObject.Label.String())
+	}
+
+	// Flags override the defaults.
+	require.Len(t, clientOptions.DisableFor, 1)
+	require.IsType(t, &corev1.Secret{}, clientOptions.DisableFor[0])
+}
+
+// TestCacheOptionsUnknownType checks unknown types are rejected.
+func TestCacheOptionsUnknownType(t *testing.T) {
+	t.Parallel()
+
+	scheme, err := coreclient.NewScheme()
+	require.NoError(t, err)
+
+	options := &coreclient.CacheOptions{
+		Disabled: []string{"Widget.example.com"},
+	}
+
+	require.ErrorIs(t, options.Apply(scheme, &cache.Options{}, &client.CacheOptions{}), coreclient.ErrResourceType)
+}
@@ -72,6 +72,13 @@ func NewScheme(schemes ...SchemeAdder) (*runtime.Scheme, error) {
 // New returns a new controller runtime caching client, initialized with core and
 // unikorn resources for typed operation.
 func New(ctx context.Context, schemes ...SchemeAdder) (client.Client, error) {
+	return NewWithCacheOptions(ctx, nil, schemes...)
+}
+
+// NewWithCacheOptions returns a new controller runtime caching client, initialized
+// with core and unikorn resources for typed operation, with optional restrictions on
+// what is cached.
+func NewWithCacheOptions(ctx context.Context, options *CacheOptions, schemes ...SchemeAdder) (client.Client, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
@@ -84,23 +91,34 @@ func New(ctx context.Context, schemes ...SchemeAdder) (client.Client, error) {
 		return nil, err
 	}
 
-	cache, err := cache.New(config, cache.Options{Scheme: scheme})
-	if err != nil {
-		return nil, err
+	cacheOptions := cache.Options{
+		Scheme: scheme,
 	}
 
-	go func() {
-		_ = cache.Start(ctx)
-	}()
-
 	clientOptions := client.Options{
 		Scheme: scheme,
 		Cache: &client.CacheOptions{
-			Reader:       cache,
 			Unstructured: true,
 		},
 	}
 
+	if options != nil {
+		if err := options.Apply(scheme, &cacheOptions, clientOptions.Cache); err != nil {
+			return nil, err
+		}
+	}
+
+	cache, err := cache.New(config, cacheOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		_ = cache.Start(ctx)
+	}()
+
+	clientOptions.Cache.Reader = cache
+
 	c, err := client.NewWithWatch(config, clientOptions)
 	if err != nil {
 		return nil, err
@@ -131,6 +131,16 @@ const (
 	// can be used as a label selector.
 	ReferencedResourceIDLabel = "unikorn-cloud.org/resource-id"
 
+	// DeletionProtectionAnnotation, when set to "true", prevents a managed
+	// resource from being deprovisioned.  The controller will keep the finalizer
+	// in place and report the blockage in the status until the annotation is
+	// removed.
+	DeletionProtectionAnnotation = "unikorn-cloud.org/deletion-protection"
+
+	// ShardGroupLabel is attached to leases used to coordinate sharded
+	// controller replicas, and identifies the group they belong to.
+	ShardGroupLabel = "unikorn-cloud.org/shard-group"
+
 	// UndefinedName is when the name label needs to be contractually present
 	// but it's irrelevant.
 	UndefinedName = "undefined"
@@ -20,6 +20,7 @@ package manager
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 
 	"github.com/spf13/pflag"
@@ -27,10 +28,12 @@ import (
 	coreclient "github.com/unikorn-cloud/core/pkg/client"
 	"github.com/unikorn-cloud/core/pkg/manager/options"
 	"github.com/unikorn-cloud/core/pkg/manager/otel"
+	"github.com/unikorn-cloud/core/pkg/manager/sharding"
 
 	klog "k8s.io/klog/v2"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	clientconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -38,6 +41,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 // ControllerOptions abstracts controller specific flags.
@@ -73,10 +77,40 @@ type ControllerFactory interface {
 	Schemes() []coreclient.SchemeAdder
 }
 
+// ShardedControllerFactory is optionally implemented by a ControllerFactory
+// to support sharding.  When sharding is enabled, this is called in place of
+// RegisterWatches.  The sharder's predicate should be applied to the watch of
+// the primary resource, and secondary watches' handlers wrapped with the sharder's
+// handler, so only events for resources owned by this replica are processed.
+type ShardedControllerFactory interface {
+	// RegisterShardedWatches adds any watches that would trigger a reconcile.
+	RegisterShardedWatches(manager manager.Manager, controller controller.Controller, sharder *sharding.Sharder) error
+}
+
+// CachedControllerFactory is optionally implemented by a ControllerFactory
+// to restrict what is cached by informers.  These are used as defaults and
+// may be overridden by CLI flags.
+type CachedControllerFactory interface {
+	// CacheOptions returns cache restrictions.
+	CacheOptions() *coreclient.CacheOptions
+}
+
+// ObjectTyper is implemented by reconcilers that can report the type of
+// resource they manage.
+type ObjectTyper interface {
+	// NewObject returns a new, empty, instance of the resource type.
+	NewObject() client.Object
+}
+
 // getManager returns a generic manager.
-func getManager(f ControllerFactory) (manager.Manager, error) {
+func getManager(f ControllerFactory, shardingOptions *sharding.Options, cacheOptions *coreclient.CacheOptions) (manager.Manager, error) {
 	// Create a manager with leadership election to prevent split brain
 	// problems, and set the scheme so it gets propagated to the client.
+	// When sharding, all replicas are active, and split brain is prevented
+	// by each resource being owned by only one replica.  Ownership changes
+	// are fenced by the sharder, but a reconcile that is already in flight
+	// when a resource moves may overlap with one on its new owner, so
+	// reconcilers must be idempotent.
 	config, err := clientconfig.GetConfig()
 	if err != nil {
 		return nil, err
@@ -91,8 +125,15 @@ func getManager(f ControllerFactory) (manager.Manager, error) {
 
 	options := manager.Options{
 		Scheme:           scheme,
-		LeaderElection:   true,
+		LeaderElection:   !shardingOptions.Enabled,
 		LeaderElectionID: application,
+		Client: client.Options{
+			Cache: &client.CacheOptions{},
+		},
+	}
+
+	if err := cacheOptions.Apply(scheme, &options.Cache, options.Client.Cache); err != nil {
+		return nil, err
 	}
 
 	manager, err := manager.New(config, options)
@@ -104,7 +145,7 @@ func getManager(f ControllerFactory) (manager.Manager, error) {
 }
 
 // getController returns a generic controller.
-func getController(o *options.Options, controllerOptions ControllerOptions, manager manager.Manager, f ControllerFactory) (controller.Controller, error) {
+func getController(o *options.Options, reconciler reconcile.Reconciler, manager manager.Manager, f ControllerFactory) (controller.Controller, error) {
 	// This prevents a single bad reconcile from affecting all the rest by
 	// boning the whole container.
 	recoverPanic := true
@@ -112,7 +153,7 @@ func getController(o *options.Options, controllerOptions ControllerOptions, mana
 	options := controller.Options{
 		MaxConcurrentReconciles: o.MaxConcurrentReconciles,
 		RecoverPanic:            &recoverPanic,
-		Reconciler:              f.Reconciler(o, controllerOptions, manager),
+		Reconciler:              reconciler,
 	}
 
 	application, _, _ := f.Metadata()
@@ -125,8 +166,58 @@ func getController(o *options.Options, controllerOptions ControllerOptions, mana
 	return c, nil
 }
 
-func doUpgrade(f ControllerFactory) error {
-	client, err := coreclient.New(context.TODO())
+// registerWebhooks adds any optional admission webhooks to the manager.
+func registerWebhooks(o *options.Options, manager manager.Manager) {
+	if o.DeletionProtectionWebhook {
+		manager.GetWebhookServer().Register(DeletionProtectionWebhookPath, &webhook.Admission{Handler: &DeletionProtectionValidator{}})
+	}
+}
+
+// registerWatches adds watches to the controller, filtering them when sharded
+// and supported by the controller.
+func registerWatches(f ControllerFactory, manager manager.Manager, controller controller.Controller, sharder *sharding.Sharder) error {
+	if sharder != nil {
+		if sf, ok := f.(ShardedControllerFactory); ok {
+			return sf.RegisterShardedWatches(manager, controller, sharder)
+		}
+	}
+
+	return f.RegisterWatches(manager, controller)
+}
+
+// getSharder creates a sharder and adds it to the manager, the controller will
+// be triggered when resources are rebalanced onto this replica.
+func getSharder(o *options.Options, shardingOptions *sharding.Options, manager manager.Manager, controller controller.Controller, reconciler reconcile.Reconciler, f ControllerFactory) (*sharding.Sharder, error) {
+	typer, ok := reconciler.(ObjectTyper)
+	if !ok {
+		return nil, fmt.Errorf("%w: reconciler does not implement ObjectTyper", ErrResourceError)
+	}
+
+	gvk, err := apiutil.GVKForObject(typer.NewObject(), manager.GetScheme())
+	if err != nil {
+		return nil, err
+	}
+
+	application, _, _ := f.Metadata()
+
+	sharder, err := sharding.New(shardingOptions, manager, o.Namespace, application, gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := manager.Add(sharder); err != nil {
+		return nil, err
+	}
+
+	if err := controller.Watch(sharder.Source()); err != nil {
+		return nil, err
+	}
+
+	return sharder, nil
+}
+
+func doUpgrade(f ControllerFactory, cacheOptions *coreclient.CacheOptions) error {
+	client, err := coreclient.NewWithCacheOptions(context.TODO(), cacheOptions, f.Schemes()...)
 	if err != nil {
 		return err
 	}
@@ -148,9 +239,27 @@ func Run(f ControllerFactory) {
 	o := &options.Options{}
 	o.AddFlags(pflag.CommandLine)
 
-	otelOptions := &otel.Options{}
+	application, version, revision := f.Metadata()
+
+	otelOptions := &otel.Options{
+		ServiceName:    application,
+		ServiceVersion: version,
+	}
 	otelOptions.AddFlags(pflag.CommandLine)
 
+	shardingOptions := &sharding.Options{}
+	shardingOptions.AddFlags(pflag.CommandLine)
+
+	cacheOptions := &coreclient.CacheOptions{}
+
+	if cf, ok := f.(CachedControllerFactory); ok {
+		if o := cf.CacheOptions(); o != nil {
+			cacheOptions = o
+		}
+	}
+
+	cacheOptions.AddFlags(pflag.CommandLine)
+
 	controllerOptions := f.Options()
 	if controllerOptions != nil {
 		controllerOptions.AddFlags(pflag.CommandLine)
@@ -158,13 +267,11 @@ func Run(f ControllerFactory) {
 
 	pflag.Parse()
 
-	logr := zap.New(zap.UseFlagOptions(zapOptions))
+	logr := otelOptions.Logger(zap.New(zap.UseFlagOptions(zapOptions)))
 
 	log.SetLogger(logr)
 	klog.SetLogger(logr)
 
-	application, version, revision := f.Metadata()
-
 	logger := log.Log.WithName("init")
 	logger.Info("service starting", "application", application, "version", version, "revision", revision)
 
@@ -175,29 +282,52 @@ func Run(f ControllerFactory) {
 		os.Exit(1)
 	}
 
-	if err := doUpgrade(f); err != nil {
+	if err := doUpgrade(f, cacheOptions); err != nil {
 		logger.Error(err, "resource upgrade failed")
 		os.Exit(1)
 	}
 
-	manager, err := getManager(f)
+	manager, err := getManager(f, shardingOptions, cacheOptions)
 	if err != nil {
 		logger.Error(err, "manager creation error")
 		os.Exit(1)
 	}
 
-	controller, err := getController(o, controllerOptions, manager, f)
+	registerWebhooks(o, manager)
+
+	reconciler := f.Reconciler(o, controllerOptions, manager)
+
+	controller, err := getController(o, reconciler, manager, f)
 	if err != nil {
 		logger.Error(err, "controller creation error")
 		os.Exit(1)
 	}
 
-	if err := f.RegisterWatches(manager, controller); err != nil {
+	var sharder *sharding.Sharder
+
+	if shardingOptions.Enabled {
+		if sharder, err = getSharder(o, shardingOptions, manager, controller, reconciler, f); err != nil {
+			logger.Error(err, "sharder creation error")
+			os.Exit(1)
+		}
+
+		// The reconciler uses this to ignore resources owned by other replicas.
+		ctx = sharding.NewContext(ctx, sharder)
+	}
+
+	if err := registerWatches(f, manager, controller, sharder); err != nil {
 		logger.Error(err, "watcher registration error")
 		os.Exit(1)
 	}
 
-	if err := manager.Start(ctx); err != nil {
+	err = manager.Start(ctx)
+
+	// Flush any buffered spans, the signal context is cancelled by now.
+	if err := otelOptions.Shutdown(context.Background()); err != nil {
+		logger.Error(err, "open telemetry shutdown failed")
+	}
+
+	if err != nil {
 		logger.Error(err, "manager terminated")
 		os.Exit(1)
 	}
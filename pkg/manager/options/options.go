@@ -36,6 +36,11 @@ type Options struct {
 	// CDDriver defines the continuous-delivery backend driver to use
 	// to manage applications.
 	CDDriver cd.DriverKindFlag
+
+	// DeletionProtectionWebhook enables an admission webhook that rejects
+	// deletion of resources with deletion protection enabled.  This requires
+	// a ValidatingWebhookConfiguration and serving certificate to be provisioned.
+	DeletionProtectionWebhook bool
 }
 
 func (o *Options) AddFlags(flags *pflag.FlagSet) {
@@ -44,4 +49,5 @@ func (o *Options) AddFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.Namespace, "namespace", "", "Namespace the process is running in")
 	flags.IntVar(&o.MaxConcurrentReconciles, "max-concurrency", 16, "Maximum number of requests to process at the same time")
 	flags.Var(&o.CDDriver, "cd-driver", "CD backend driver to use from [argocd]")
+	flags.BoolVar(&o.DeletionProtectionWebhook, "deletion-protection-webhook", false, "Serve an admission webhook that rejects deletion of protected resources")
 }
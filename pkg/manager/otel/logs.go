@@ -0,0 +1,342 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// loggerName is the instrumentation scope of bridged logs.
+	loggerName = "github.com/unikorn-cloud/core/pkg/manager/otel"
+
+	// traceIDKey and spanIDKey are the logging keys used to correlate logs with
+	// traces, these are added to contextual loggers by the OpenTelemetry HTTP
+	// middleware, and are translated into native trace context when bridged.
+	traceIDKey = "trace.id"
+	spanIDKey  = "span.id"
+)
+
+// httpLogExporter creates an OTLP over HTTP log exporter.
+func (o *Options) httpLogExporter(ctx context.Context) (sdklog.Exporter, error) {
+	options := []otlploghttp.Option{
+		otlploghttp.WithEndpoint(o.OTLPEndpoint),
+		otlploghttp.WithHeaders(o.OTLPHeaders),
+	}
+
+	if o.tlsEnabled() {
+		config, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, otlploghttp.WithTLSClientConfig(config))
+	} else {
+		options = append(options, otlploghttp.WithInsecure())
+	}
+
+	return otlploghttp.New(ctx, options...)
+}
+
+// grpcLogExporter creates an OTLP over gRPC log exporter.
+func (o *Options) grpcLogExporter(ctx context.Context) (sdklog.Exporter, error) {
+	options := []otlploggrpc.Option{
+		otlploggrpc.WithEndpoint(o.OTLPEndpoint),
+		otlploggrpc.WithHeaders(o.OTLPHeaders),
+	}
+
+	if o.tlsEnabled() {
+		config, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, otlploggrpc.WithTLSCredentials(credentials.NewTLS(config)))
+	} else {
+		options = append(options, otlploggrpc.WithInsecure())
+	}
+
+	return otlploggrpc.New(ctx, options...)
+}
+
+// setupLogs creates a logger provider and installs it globally, this is consumed
+// by loggers created with NewLogger.
+func (o *Options) setupLogs(ctx context.Context, res *resource.Resource) error {
+	if !o.Logs || o.OTLPEndpoint == "" {
+		return nil
+	}
+
+	var exporter sdklog.Exporter
+
+	var err error
+
+	switch o.OTLPProtocol.Protocol {
+	case ProtocolGRPC:
+		exporter, err = o.grpcLogExporter(ctx)
+	default:
+		exporter, err = o.httpLogExporter(ctx)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	o.loggerProvider = sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	)
+
+	global.SetLoggerProvider(o.loggerProvider)
+
+	return nil
+}
+
+// Logger wraps the provided logger so that all logs are also shipped as
+// OpenTelemetry logs when enabled, otherwise the logger is returned as is.
+func (o *Options) Logger(l logr.Logger) logr.Logger {
+	if !o.Logs {
+		return l
+	}
+
+	return NewLogger(l)
+}
+
+// NewLogger wraps the provided logger so that all logs are also emitted to the
+// global OpenTelemetry logger provider.  The provider may be installed after
+// the logger is created.
+func NewLogger(l logr.Logger) logr.Logger {
+	// Discarded logs are discarded.
+	if l.GetSink() == nil {
+		return l
+	}
+
+	return logr.New(&logSink{
+		delegate: l.GetSink(),
+		logger:   global.Logger(loggerName),
+	})
+}
+
+// logSink tees logs to a delegate logger, and OpenTelemetry.
+type logSink struct {
+	// delegate is the original log sink.
+	delegate logr.LogSink
+
+	// logger emits OpenTelemetry log records.
+	logger otellog.Logger
+
+	// name is the logger name.
+	name string
+
+	// attributes are accumulated from WithValues.
+	attributes []otellog.KeyValue
+
+	// spanContext is extracted from WithValues and allows correlation
+	// of logs with traces.
+	spanContext trace.SpanContext
+}
+
+// Ensure this implements the logr.LogSink interface.
+var _ logr.LogSink = &logSink{}
+var _ logr.CallDepthLogSink = &logSink{}
+
+// Init implements the logr.LogSink interface.
+func (s *logSink) Init(info logr.RuntimeInfo) {
+	// We add an extra stack frame between the caller and the delegate.
+	info.CallDepth++
+
+	s.delegate.Init(info)
+}
+
+// Enabled implements the logr.LogSink interface.
+func (s *logSink) Enabled(level int) bool {
+	return s.delegate.Enabled(level)
+}
+
+// Info implements the logr.LogSink interface.
+func (s *logSink) Info(level int, msg string, keysAndValues ...any) {
+	s.delegate.Info(level, msg, keysAndValues...)
+
+	severity := otellog.SeverityInfo
+
+	if level > 0 {
+		severity = otellog.SeverityDebug
+	}
+
+	s.emit(severity, msg, nil, keysAndValues)
+}
+
+// Error implements the logr.LogSink interface.
+func (s *logSink) Error(err error, msg string, keysAndValues ...any) {
+	s.delegate.Error(err, msg, keysAndValues...)
+
+	s.emit(otellog.SeverityError, msg, err, keysAndValues)
+}
+
+// WithValues implements the logr.LogSink interface.
+func (s *logSink) WithValues(keysAndValues ...any) logr.LogSink {
+	out := s.clone()
+	out.delegate = s.delegate.WithValues(keysAndValues...)
+	out.attributes = append(out.attributes, out.convert(keysAndValues)...)
+
+	return out
+}
+
+// WithName implements the logr.LogSink interface.
+func (s *logSink) WithName(name string) logr.LogSink {
+	out := s.clone()
+	out.delegate = s.delegate.WithName(name)
+
+	if out.name == "" {
+		out.name = name
+	} else {
+		out.name += "." + name
+	}
+
+	return out
+}
+
+// WithCallDepth implements the logr.CallDepthLogSink interface.
+func (s *logSink) WithCallDepth(depth int) logr.LogSink {
+	out := s.clone()
+
+	if delegate, ok := s.delegate.(logr.CallDepthLogSink); ok {
+		out.delegate = delegate.WithCallDepth(depth)
+	}
+
+	return out
+}
+
+// clone does a deep copy of the sink so it can be modified.
+func (s *logSink) clone() *logSink {
+	out := *s
+	out.attributes = append([]otellog.KeyValue(nil), s.attributes...)
+
+	return &out
+}
+
+// convert translates logr key/value pairs into OpenTelemetry attributes,
+// picking out any trace correlation information along the way.
+func (s *logSink) convert(keysAndValues []any) []otellog.KeyValue {
+	attributes := make([]otellog.KeyValue, 0, len(keysAndValues)/2)
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+
+		var value any
+
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+
+		switch key {
+		case traceIDKey:
+			if id, err := trace.TraceIDFromHex(fmt.Sprint(value)); err == nil {
+				s.spanContext = s.spanContext.WithTraceID(id)
+			}
+		case spanIDKey:
+			if id, err := trace.SpanIDFromHex(fmt.Sprint(value)); err == nil {
+				s.spanContext = s.spanContext.WithSpanID(id)
+			}
+		}
+
+		attributes = append(attributes, otellog.KeyValue{Key: key, Value: convertValue(value)})
+	}
+
+	return attributes
+}
+
+// convertValue translates a logr value into an OpenTelemetry one.
+//
+//nolint:cyclop
+func convertValue(value any) otellog.Value {
+	switch t := value.(type) {
+	case nil:
+		return otellog.Value{}
+	case string:
+		return otellog.StringValue(t)
+	case bool:
+		return otellog.BoolValue(t)
+	case int:
+		return otellog.IntValue(t)
+	case int32:
+		return otellog.Int64Value(int64(t))
+	case int64:
+		return otellog.Int64Value(t)
+	case float32:
+		return otellog.Float64Value(float64(t))
+	case float64:
+		return otellog.Float64Value(t)
+	case []byte:
+		return otellog.BytesValue(t)
+	case time.Duration:
+		return otellog.StringValue(t.String())
+	case time.Time:
+		return otellog.StringValue(t.Format(time.RFC3339Nano))
+	case error:
+		return otellog.StringValue(t.Error())
+	case fmt.Stringer:
+		return otellog.StringValue(t.String())
+	}
+
+	return otellog.StringValue(fmt.Sprintf("%+v", value))
+}
+
+// emit sends a log record to OpenTelemetry.
+func (s *logSink) emit(severity otellog.Severity, msg string, err error, keysAndValues []any) {
+	// Work on a copy so trace correlation from this call doesn't leak.
+	sink := s.clone()
+
+	var record otellog.Record
+
+	record.SetTimestamp(time.Now())
+	record.SetSeverity(severity)
+	record.SetSeverityText(severity.String())
+	record.SetBody(otellog.StringValue(msg))
+	record.AddAttributes(sink.attributes...)
+	record.AddAttributes(sink.convert(keysAndValues)...)
+
+	if sink.name != "" {
+		record.AddAttributes(otellog.String("logger", sink.name))
+	}
+
+	if err != nil {
+		record.AddAttributes(otellog.String("exception.message", err.Error()))
+	}
+
+	ctx := context.Background()
+
+	if sink.spanContext.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, sink.spanContext)
+	}
+
+	sink.logger.Emit(ctx, record)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/require"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+
+	"github.com/unikorn-cloud/core/pkg/manager/otel"
+)
+
+var errTest = errors.New("test")
+
+// recorder is a log processor that remembers what it's seen.
+type recorder struct {
+	lock    sync.Mutex
+	records []sdklog.Record
+}
+
+func (r *recorder) OnEmit(_ context.Context, record *sdklog.Record) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.records = append(r.records, record.Clone())
+
+	return nil
+}
+
+func (r *recorder) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func (r *recorder) ForceFlush(_ context.Context) error {
+	return nil
+}
+
+func attributes(record sdklog.Record) map[string]string {
+	out := map[string]string{}
+
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		out[kv.Key] = kv.Value.String()
+
+		return true
+	})
+
+	return out
+}
+
+// TestLogBridge tests logs are teed to OpenTelemetry with trace correlation.
+// This modifies the global logger provider so cannot be run in parallel.
+//
+//nolint:paralleltest
+func TestLogBridge(t *testing.T) {
+	r := &recorder{}
+
+	provider := global.GetLoggerProvider()
+
+	t.Cleanup(func() {
+		global.SetLoggerProvider(provider)
+	})
+
+	global.SetLoggerProvider(sdklog.NewLoggerProvider(sdklog.WithProcessor(r)))
+
+	var lines []string
+
+	logger := otel.NewLogger(funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{}))
+
+	logger = logger.WithName("test").WithValues("trace.id", "0102030405060708090a0b0c0d0e0f10", "span.id", "0102030405060708")
+
+	logger.Info("hello", "count", 1)
+	logger.Error(errTest, "failed")
+
+	require.Len(t, lines, 2)
+	require.Len(t, r.records, 2)
+
+	info := r.records[0]
+
+	require.Equal(t, "hello", info.Body().AsString())
+	require.Equal(t, otellog.SeverityInfo, info.Severity())
+	require.Equal(t, "0102030405060708090a0b0c0d0e0f10", info.TraceID().String())
+	require.Equal(t, "0102030405060708", info.SpanID().String())
+	require.Equal(t, "1", attributes(info)["count"])
+	require.Equal(t, "test", attributes(info)["logger"])
+
+	failure := r.records[1]
+
+	require.Equal(t, otellog.SeverityError, failure.Severity())
+	require.Equal(t, "test", attributes(failure)["exception.message"])
+}
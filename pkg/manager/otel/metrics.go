@@ -0,0 +1,178 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otel
+
+import (
+	"context"
+	"slices"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"google.golang.org/grpc/credentials"
+
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// MetricsExporter defines where metrics are exported to.
+type MetricsExporter string
+
+const (
+	// MetricsExporterNone disables metrics.
+	MetricsExporterNone MetricsExporter = "none"
+
+	// MetricsExporterOTLP ships metrics to the OTLP endpoint.
+	MetricsExporterOTLP MetricsExporter = "otlp"
+
+	// MetricsExporterPrometheus registers metrics with the controller-runtime
+	// registry, so they are served alongside all other metrics.
+	MetricsExporterPrometheus MetricsExporter = "prometheus"
+)
+
+// MetricsExporterFlag wraps up the metrics exporter in a flag that can be used
+// on the CLI.
+type MetricsExporterFlag struct {
+	Exporter MetricsExporter
+}
+
+var _ pflag.Value = &MetricsExporterFlag{}
+
+// String implements the pflag.Value interface.
+func (s *MetricsExporterFlag) String() string {
+	return string(s.Exporter)
+}
+
+// Set implements the pflag.Value interface.
+func (s *MetricsExporterFlag) Set(in string) error {
+	valid := []MetricsExporter{
+		MetricsExporterNone,
+		MetricsExporterOTLP,
+		MetricsExporterPrometheus,
+	}
+
+	value := MetricsExporter(in)
+
+	if !slices.Contains(valid, value) {
+		return coreerrors.ErrParseFlag
+	}
+
+	s.Exporter = value
+
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *MetricsExporterFlag) Type() string {
+	return "string"
+}
+
+// httpMetricExporter creates an OTLP over HTTP metric exporter.
+func (o *Options) httpMetricExporter(ctx context.Context) (metric.Exporter, error) {
+	options := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(o.OTLPEndpoint),
+		otlpmetrichttp.WithHeaders(o.OTLPHeaders),
+	}
+
+	if o.tlsEnabled() {
+		config, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, otlpmetrichttp.WithTLSClientConfig(config))
+	} else {
+		options = append(options, otlpmetrichttp.WithInsecure())
+	}
+
+	return otlpmetrichttp.New(ctx, options...)
+}
+
+// grpcMetricExporter creates an OTLP over gRPC metric exporter.
+func (o *Options) grpcMetricExporter(ctx context.Context) (metric.Exporter, error) {
+	options := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(o.OTLPEndpoint),
+		otlpmetricgrpc.WithHeaders(o.OTLPHeaders),
+	}
+
+	if o.tlsEnabled() {
+		config, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(config)))
+	} else {
+		options = append(options, otlpmetricgrpc.WithInsecure())
+	}
+
+	return otlpmetricgrpc.New(ctx, options...)
+}
+
+// metricReader returns a reader for the configured exporter, or nil if metrics
+// are disabled.
+func (o *Options) metricReader(ctx context.Context) (metric.Reader, error) {
+	switch o.Metrics.Exporter {
+	case MetricsExporterOTLP:
+		var exporter metric.Exporter
+
+		var err error
+
+		switch o.OTLPProtocol.Protocol {
+		case ProtocolGRPC:
+			exporter, err = o.grpcMetricExporter(ctx)
+		default:
+			exporter, err = o.httpMetricExporter(ctx)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return metric.NewPeriodicReader(exporter, metric.WithInterval(o.MetricsInterval)), nil
+	case MetricsExporterPrometheus:
+		return prometheus.New(prometheus.WithRegisterer(metrics.Registry))
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+// setupMetrics creates a meter provider and installs it globally.
+func (o *Options) setupMetrics(ctx context.Context, res *resource.Resource) error {
+	reader, err := o.metricReader(ctx)
+	if err != nil {
+		return err
+	}
+
+	if reader == nil {
+		return nil
+	}
+
+	o.meterProvider = metric.NewMeterProvider(
+		metric.WithResource(res),
+		metric.WithReader(reader),
+	)
+
+	otel.SetMeterProvider(o.meterProvider)
+
+	return nil
+}
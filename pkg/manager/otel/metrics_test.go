@@ -0,0 +1,145 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	otelapi "go.opentelemetry.io/otel"
+
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/manager/otel"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// TestMetricsExporterFlag tests the metrics exporter is parsed from the CLI.
+func TestMetricsExporterFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in       string
+		exporter otel.MetricsExporter
+		err      bool
+	}{
+		{in: "none", exporter: otel.MetricsExporterNone},
+		{in: "otlp", exporter: otel.MetricsExporterOTLP},
+		{in: "prometheus", exporter: otel.MetricsExporterPrometheus},
+		{in: "", err: true},
+		{in: "statsd", err: true},
+	}
+
+	for _, test := range tests {
+		flag := &otel.MetricsExporterFlag{}
+
+		err := flag.Set(test.in)
+
+		if test.err {
+			require.ErrorIs(t, err, coreerrors.ErrParseFlag, test.in)
+
+			continue
+		}
+
+		require.NoError(t, err, test.in)
+		require.Equal(t, test.exporter, flag.Exporter, test.in)
+		require.Equal(t, test.in, flag.String(), test.in)
+	}
+}
+
+// TestMetricsNone tests no meter provider is installed when metrics are disabled.
+// This modifies the global meter provider so cannot be run in parallel.
+//
+//nolint:paralleltest
+func TestMetricsNone(t *testing.T) {
+	restoreGlobals(t)
+
+	meterProvider := otelapi.GetMeterProvider()
+
+	setup(t, &otel.Options{Metrics: otel.MetricsExporterFlag{Exporter: otel.MetricsExporterNone}})
+
+	require.Equal(t, meterProvider, otelapi.GetMeterProvider())
+}
+
+// TestMetricsPrometheus tests metrics are served from the controller-runtime
+// registry.  This modifies the global meter provider and registry so cannot be
+// run in parallel.
+//
+//nolint:paralleltest
+func TestMetricsPrometheus(t *testing.T) {
+	setup(t, &otel.Options{Metrics: otel.MetricsExporterFlag{Exporter: otel.MetricsExporterPrometheus}})
+
+	counter, err := otelapi.Meter("test").Int64Counter("otel_test_widgets")
+	require.NoError(t, err)
+
+	counter.Add(t.Context(), 3)
+
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+
+	var value float64
+
+	for _, family := range families {
+		if family.GetName() == "otel_test_widgets_total" {
+			value = family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+
+	require.InDelta(t, 3, value, 0)
+}
+
+// TestMetricsOTLP tests metrics are shipped to the OTLP endpoint, and are flushed
+// on shutdown.  This modifies the global meter provider so cannot be run in
+// parallel.
+//
+//nolint:paralleltest
+func TestMetricsOTLP(t *testing.T) {
+	var received atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/metrics" {
+			received.Add(1)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	options := &otel.Options{
+		OTLPEndpoint:    server.Listener.Addr().String(),
+		OTLPProtocol:    otel.ProtocolFlag{Protocol: otel.ProtocolHTTP},
+		Metrics:         otel.MetricsExporterFlag{Exporter: otel.MetricsExporterOTLP},
+		MetricsInterval: time.Hour,
+	}
+
+	restoreGlobals(t)
+
+	require.NoError(t, options.Setup(t.Context()))
+
+	counter, err := otelapi.Meter("test").Int64Counter("widgets")
+	require.NoError(t, err)
+
+	counter.Add(t.Context(), 1)
+
+	require.NoError(t, options.Shutdown(context.Background()))
+	require.Positive(t, received.Load())
+}
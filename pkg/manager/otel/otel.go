@@ -18,39 +18,316 @@ package otel
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
 
 	"github.com/spf13/pflag"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.22.0"
+	"google.golang.org/grpc/credentials"
+
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/opentelemetry"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+var (
+	// ErrCertificate is raised when the CA certificate cannot be parsed.
+	ErrCertificate = errors.New("unable to parse certificate")
+
+	// ErrOptions is raised when options are invalid.
+	ErrOptions = errors.New("open telemetry options invalid")
+)
+
+// Protocol defines how spans are shipped to the OTLP endpoint.
+type Protocol string
+
+const (
+	// ProtocolHTTP ships spans using OTLP over HTTP.
+	ProtocolHTTP Protocol = "http"
+
+	// ProtocolGRPC ships spans using OTLP over gRPC.
+	ProtocolGRPC Protocol = "grpc"
+)
+
+// ProtocolFlag wraps up the protocol in a flag that can be used on the CLI.
+type ProtocolFlag struct {
+	Protocol Protocol
+}
+
+var _ pflag.Value = &ProtocolFlag{}
+
+// String implements the pflag.Value interface.
+func (s *ProtocolFlag) String() string {
+	return string(s.Protocol)
+}
+
+// Set implements the pflag.Value interface.
+func (s *ProtocolFlag) Set(in string) error {
+	valid := []Protocol{
+		ProtocolHTTP,
+		ProtocolGRPC,
+	}
+
+	value := Protocol(in)
+
+	if !slices.Contains(valid, value) {
+		return coreerrors.ErrParseFlag
+	}
+
+	s.Protocol = value
+
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *ProtocolFlag) Type() string {
+	return "string"
+}
+
 // Options defines common controller options.
 type Options struct {
 	// OTLPEndpoint defines whether to ship spans to an OTLP consumer or
 	// not, and where to send them to.
 	OTLPEndpoint string
+
+	// OTLPProtocol defines how to ship spans.
+	OTLPProtocol ProtocolFlag
+
+	// OTLPTLS enables TLS when talking to the endpoint.  This is implied
+	// if any of the certificate options are set.
+	OTLPTLS bool
+
+	// OTLPCAFile is a PEM encoded CA certificate used to verify the endpoint,
+	// when not set the system trust store is used.
+	OTLPCAFile string
+
+	// OTLPCertFile and OTLPKeyFile are a PEM encoded client certificate
+	// and key used for mutual TLS.
+	OTLPCertFile string
+	OTLPKeyFile  string
+
+	// OTLPHeaders are sent with every request, typically for authentication.
+	OTLPHeaders map[string]string
+
+	// SampleRatio is the ratio of root spans that are sampled, child spans
+	// follow the sampling decision of their parent.  When nil the SDK default
+	// is used, which samples everything.
+	SampleRatio *float64
+
+	// ServiceName, ServiceVersion and ServiceNamespace are added to all spans
+	// as resource attributes to identify the emitter.
+	ServiceName      string
+	ServiceVersion   string
+	ServiceNamespace string
+
+	// LogSpans installs a span processor that logs spans.
+	LogSpans bool
+
+	// Metrics defines where metrics are exported to, if at all.
+	Metrics MetricsExporterFlag
+
+	// MetricsInterval is how often metrics are shipped to the OTLP endpoint.
+	MetricsInterval time.Duration
+
+	// Logs enables shipping of logs to the OTLP endpoint.
+	Logs bool
+
+	// ShutdownTimeout is how long to wait for buffered telemetry to be shipped
+	// on shutdown.
+	ShutdownTimeout time.Duration
+
+	// tracerProvider is the tracer provider created by Setup.
+	tracerProvider *trace.TracerProvider
+
+	// meterProvider is the meter provider created by Setup.
+	meterProvider *metric.MeterProvider
+
+	// loggerProvider is the logger provider created by Setup.
+	loggerProvider *sdklog.LoggerProvider
 }
 
 func (o *Options) AddFlags(f *pflag.FlagSet) {
+	o.OTLPProtocol.Protocol = ProtocolHTTP
+	o.Metrics.Exporter = MetricsExporterNone
+	o.SampleRatio = new(float64)
+
 	f.StringVar(&o.OTLPEndpoint, "otlp-endpoint", "", "An optional OTLP endpoint to ship spans to.")
+	f.Var(&o.OTLPProtocol, "otlp-protocol", "OTLP protocol to use from [http grpc].")
+	f.BoolVar(&o.OTLPTLS, "otlp-tls", false, "Use TLS when shipping spans, implied by any other TLS option.")
+	f.StringVar(&o.OTLPCAFile, "otlp-ca-file", "", "PEM encoded CA certificate used to verify the OTLP endpoint.")
+	f.StringVar(&o.OTLPCertFile, "otlp-cert-file", "", "PEM encoded client certificate used to authenticate with the OTLP endpoint.")
+	f.StringVar(&o.OTLPKeyFile, "otlp-key-file", "", "PEM encoded client private key used to authenticate with the OTLP endpoint.")
+	f.StringToStringVar(&o.OTLPHeaders, "otlp-headers", nil, "Headers to send to the OTLP endpoint, typically for authentication.")
+	f.Float64Var(o.SampleRatio, "otel-sample-ratio", 1, "Ratio of root spans to sample, between 0 and 1.")
+	f.StringVar(&o.ServiceName, "otel-service-name", o.ServiceName, "Service name resource attribute.")
+	f.StringVar(&o.ServiceVersion, "otel-service-version", o.ServiceVersion, "Service version resource attribute.")
+	f.StringVar(&o.ServiceNamespace, "otel-service-namespace", "", "Service namespace resource attribute.")
+	f.BoolVar(&o.LogSpans, "otel-log-spans", false, "Log spans as they start and end.")
+	f.Var(&o.Metrics, "otel-metrics", "Where to export metrics to from [none otlp prometheus], prometheus metrics are served by the metrics endpoint.")
+	f.DurationVar(&o.MetricsInterval, "otel-metrics-interval", time.Minute, "How often to ship metrics to the OTLP endpoint.")
+	f.BoolVar(&o.Logs, "otel-logs", false, "Ship logs to the OTLP endpoint.")
+	f.DurationVar(&o.ShutdownTimeout, "otel-shutdown-timeout", 5*time.Second, "How long to wait for telemetry to be shipped on shutdown.")
 }
 
-// Setup creates enough infrastructure to enable span creation, shipping and
-// trace contect propagation.
-func (o *Options) Setup(ctx context.Context, opts ...trace.TracerProviderOption) error {
-	otel.SetLogger(log.Log)
+// Validate checks the options are consistent.
+func (o *Options) Validate() error {
+	if o.SampleRatio != nil && (*o.SampleRatio < 0 || *o.SampleRatio > 1) {
+		return fmt.Errorf("%w: sample ratio must be between 0 and 1", ErrOptions)
+	}
 
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if (o.OTLPCertFile == "") != (o.OTLPKeyFile == "") {
+		return fmt.Errorf("%w: client certificate and key must be specified together", ErrOptions)
+	}
+
+	if o.Metrics.Exporter == MetricsExporterOTLP && o.OTLPEndpoint == "" {
+		return fmt.Errorf("%w: OTLP metrics require an OTLP endpoint", ErrOptions)
+	}
+
+	return nil
+}
+
+// tlsEnabled returns whether the exporter should use TLS.
+func (o *Options) tlsEnabled() bool {
+	return o.OTLPTLS || o.OTLPCAFile != "" || o.OTLPCertFile != "" || o.OTLPKeyFile != ""
+}
+
+// tlsConfig returns the TLS configuration for the exporter.
+func (o *Options) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if o.OTLPCAFile != "" {
+		pem, err := os.ReadFile(o.OTLPCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %s", ErrCertificate, o.OTLPCAFile)
+		}
+
+		config.RootCAs = pool
+	}
+
+	if o.OTLPCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(o.OTLPCertFile, o.OTLPKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+// httpSpanExporter creates an OTLP over HTTP span exporter.
+func (o *Options) httpSpanExporter(ctx context.Context) (trace.SpanExporter, error) {
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(o.OTLPEndpoint),
+		otlptracehttp.WithHeaders(o.OTLPHeaders),
+	}
+
+	if o.tlsEnabled() {
+		config, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, otlptracehttp.WithTLSClientConfig(config))
+	} else {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	return otlptracehttp.New(ctx, options...)
+}
+
+// grpcSpanExporter creates an OTLP over gRPC span exporter.
+func (o *Options) grpcSpanExporter(ctx context.Context) (trace.SpanExporter, error) {
+	options := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(o.OTLPEndpoint),
+		otlptracegrpc.WithHeaders(o.OTLPHeaders),
+	}
+
+	if o.tlsEnabled() {
+		config, err := o.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(config)))
+	} else {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	return otlptracegrpc.New(ctx, options...)
+}
+
+// resource returns the resource that identifies the emitter of telemetry.
+func (o *Options) resource() (*resource.Resource, error) {
+	var attributes []resource.Option
+
+	if o.ServiceName != "" {
+		attributes = append(attributes, resource.WithAttributes(semconv.ServiceName(o.ServiceName)))
+	}
+
+	if o.ServiceVersion != "" {
+		attributes = append(attributes, resource.WithAttributes(semconv.ServiceVersion(o.ServiceVersion)))
+	}
+
+	if o.ServiceNamespace != "" {
+		attributes = append(attributes, resource.WithAttributes(semconv.ServiceNamespace(o.ServiceNamespace)))
+	}
+
+	custom, err := resource.New(context.Background(), attributes...)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource.Merge(resource.Default(), custom)
+}
+
+// setupTracing creates a tracer provider and installs it globally.
+func (o *Options) setupTracing(ctx context.Context, res *resource.Resource, opts ...trace.TracerProviderOption) error {
+	// Add defaults first so they can be overridden by the caller.
+	defaults := []trace.TracerProviderOption{
+		trace.WithResource(res),
+	}
+
+	if o.SampleRatio != nil {
+		defaults = append(defaults, trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(*o.SampleRatio))))
+	}
+
+	opts = append(defaults, opts...)
 
 	if o.OTLPEndpoint != "" {
-		exporter, err := otlptracehttp.New(ctx,
-			otlptracehttp.WithEndpoint(o.OTLPEndpoint),
-			otlptracehttp.WithInsecure(),
-		)
+		var exporter trace.SpanExporter
+
+		var err error
+
+		switch o.OTLPProtocol.Protocol {
+		case ProtocolGRPC:
+			exporter, err = o.grpcSpanExporter(ctx)
+		default:
+			exporter, err = o.httpSpanExporter(ctx)
+		}
 
 		if err != nil {
 			return err
@@ -59,7 +336,71 @@ func (o *Options) Setup(ctx context.Context, opts ...trace.TracerProviderOption)
 		opts = append(opts, trace.WithBatcher(exporter))
 	}
 
-	otel.SetTracerProvider(trace.NewTracerProvider(opts...))
+	if o.LogSpans {
+		opts = append(opts, trace.WithSpanProcessor(&opentelemetry.LoggingSpanProcessor{}))
+	}
+
+	o.tracerProvider = trace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(o.tracerProvider)
 
 	return nil
 }
+
+// Setup creates enough infrastructure to enable span creation, shipping and
+// trace contect propagation, and optionally metrics and logs shipping.
+func (o *Options) Setup(ctx context.Context, opts ...trace.TracerProviderOption) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	otel.SetLogger(log.Log)
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	res, err := o.resource()
+	if err != nil {
+		return err
+	}
+
+	if err := o.setupTracing(ctx, res, opts...); err != nil {
+		return err
+	}
+
+	if err := o.setupMetrics(ctx, res); err != nil {
+		return err
+	}
+
+	if err := o.setupLogs(ctx, res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown flushes any buffered telemetry and stops the providers.  This
+// should be called on termination so nothing is lost.
+func (o *Options) Shutdown(ctx context.Context) error {
+	if o.ShutdownTimeout != 0 {
+		c, cancel := context.WithTimeout(ctx, o.ShutdownTimeout)
+		defer cancel()
+
+		ctx = c
+	}
+
+	var errs []error
+
+	if o.tracerProvider != nil {
+		errs = append(errs, o.tracerProvider.Shutdown(ctx))
+	}
+
+	if o.meterProvider != nil {
+		errs = append(errs, o.meterProvider.Shutdown(ctx))
+	}
+
+	if o.loggerProvider != nil {
+		errs = append(errs, o.loggerProvider.Shutdown(ctx))
+	}
+
+	return errors.Join(errs...)
+}
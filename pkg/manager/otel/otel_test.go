@@ -0,0 +1,306 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otel_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	otelapi "go.opentelemetry.io/otel"
+
+	"github.com/unikorn-cloud/core/pkg/manager/otel"
+
+	"k8s.io/utils/ptr"
+)
+
+// TestOptionsValidate tests invalid option combinations are rejected.
+func TestOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options otel.Options
+		valid   bool
+	}{
+		{name: "unset", options: otel.Options{}, valid: true},
+		{name: "always sample", options: otel.Options{SampleRatio: ptr.To(1.0)}, valid: true},
+		{name: "never sample", options: otel.Options{SampleRatio: ptr.To(0.0)}, valid: true},
+		{name: "half sample", options: otel.Options{SampleRatio: ptr.To(0.5)}, valid: true},
+		{name: "negative sample", options: otel.Options{SampleRatio: ptr.To(-0.1)}},
+		{name: "oversample", options: otel.Options{SampleRatio: ptr.To(1.1)}},
+		{name: "client certificate", options: otel.Options{OTLPCertFile: "cert.pem", OTLPKeyFile: "key.pem"}, valid: true},
+		{name: "certificate without key", options: otel.Options{OTLPCertFile: "cert.pem"}},
+		{name: "key without certificate", options: otel.Options{OTLPKeyFile: "key.pem"}},
+		{name: "otlp metrics", options: otel.Options{OTLPEndpoint: "localhost:4318", Metrics: otel.MetricsExporterFlag{Exporter: otel.MetricsExporterOTLP}}, valid: true},
+		{name: "otlp metrics without endpoint", options: otel.Options{Metrics: otel.MetricsExporterFlag{Exporter: otel.MetricsExporterOTLP}}},
+		{name: "prometheus metrics without endpoint", options: otel.Options{Metrics: otel.MetricsExporterFlag{Exporter: otel.MetricsExporterPrometheus}}, valid: true},
+	}
+
+	for _, test := range tests {
+		err := test.options.Validate()
+
+		if test.valid {
+			require.NoError(t, err, test.name)
+
+			continue
+		}
+
+		require.ErrorIs(t, err, otel.ErrOptions, test.name)
+	}
+}
+
+// restoreGlobals puts back the global providers modified by Setup.
+func restoreGlobals(t *testing.T) {
+	t.Helper()
+
+	tracerProvider := otelapi.GetTracerProvider()
+	meterProvider := otelapi.GetMeterProvider()
+	propagator := otelapi.GetTextMapPropagator()
+
+	t.Cleanup(func() {
+		otelapi.SetTracerProvider(tracerProvider)
+		otelapi.SetMeterProvider(meterProvider)
+		otelapi.SetTextMapPropagator(propagator)
+	})
+}
+
+// setup runs Setup and arranges for Shutdown to be called.
+func setup(t *testing.T, options *otel.Options) {
+	t.Helper()
+
+	restoreGlobals(t)
+
+	require.NoError(t, options.Setup(t.Context()))
+
+	t.Cleanup(func() {
+		require.NoError(t, options.Shutdown(context.Background()))
+	})
+}
+
+// TestSampling tests root spans are sampled according to the sample ratio, and
+// that when unset the SDK default of sampling everything is used.  This modifies
+// the global tracer provider so cannot be run in parallel.
+//
+//nolint:paralleltest
+func TestSampling(t *testing.T) {
+	flags := &otel.Options{}
+	flags.AddFlags(pflag.NewFlagSet("test", pflag.ContinueOnError))
+
+	tests := []struct {
+		name    string
+		options *otel.Options
+		sampled bool
+	}{
+		{
+			name:    "ZeroValue",
+			options: &otel.Options{},
+			sampled: true,
+		},
+		{
+			name:    "FlagDefault",
+			options: flags,
+			sampled: true,
+		},
+		{
+			name:    "Always",
+			options: &otel.Options{SampleRatio: ptr.To(1.0)},
+			sampled: true,
+		},
+		{
+			name:    "Never",
+			options: &otel.Options{SampleRatio: ptr.To(0.0)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setup(t, test.options)
+
+			_, span := otelapi.Tracer("test").Start(t.Context(), "root")
+			defer span.End()
+
+			require.Equal(t, test.sampled, span.SpanContext().IsSampled())
+		})
+	}
+}
+
+// writePEM writes a PEM block to a file in a temporary directory.
+func writePEM(t *testing.T, name, blockType string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0o600))
+
+	return path
+}
+
+// clientCertificate generates a self signed client certificate, returning the
+// certificate and paths to the certificate and key.
+func clientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return certificate, writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+// TestExporterClientCertificate tests spans are shipped over mutual TLS.  This
+// modifies the global tracer provider so cannot be run in parallel.
+//
+//nolint:paralleltest
+func TestExporterClientCertificate(t *testing.T) {
+	certificate, certFile, keyFile := clientCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate)
+
+	var authenticated atomic.Int32
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" && len(r.TLS.PeerCertificates) != 0 && r.TLS.PeerCertificates[0].Subject.CommonName == "client" {
+			authenticated.Add(1)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	server.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+
+	server.StartTLS()
+	defer server.Close()
+
+	options := &otel.Options{
+		OTLPEndpoint: server.Listener.Addr().String(),
+		OTLPProtocol: otel.ProtocolFlag{Protocol: otel.ProtocolHTTP},
+		OTLPCAFile:   writePEM(t, "ca.crt", "CERTIFICATE", server.Certificate().Raw),
+		OTLPCertFile: certFile,
+		OTLPKeyFile:  keyFile,
+	}
+
+	restoreGlobals(t)
+
+	require.NoError(t, options.Setup(t.Context()))
+
+	_, span := otelapi.Tracer("test").Start(t.Context(), "root")
+	span.End()
+
+	require.NoError(t, options.Shutdown(context.Background()))
+	require.Positive(t, authenticated.Load())
+}
+
+// TestExporterTLSErrors tests invalid TLS configuration is reported by Setup for
+// all protocols.  This modifies global providers so cannot be run in parallel.
+//
+//nolint:paralleltest
+func TestExporterTLSErrors(t *testing.T) {
+	_, certFile, keyFile := clientCertificate(t)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalid, []byte("not a certificate"), 0o600))
+
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+
+	tests := []struct {
+		name    string
+		options otel.Options
+		err     error
+	}{
+		{
+			name:    "CAMissing",
+			options: otel.Options{OTLPCAFile: missing},
+		},
+		{
+			name:    "CAInvalid",
+			options: otel.Options{OTLPCAFile: invalid},
+			err:     otel.ErrCertificate,
+		},
+		{
+			name:    "CertificateMissing",
+			options: otel.Options{OTLPCertFile: missing, OTLPKeyFile: keyFile},
+		},
+		{
+			name:    "KeyInvalid",
+			options: otel.Options{OTLPCertFile: certFile, OTLPKeyFile: invalid},
+		},
+		{
+			name:    "KeyWithoutCertificate",
+			options: otel.Options{OTLPKeyFile: keyFile},
+			err:     otel.ErrOptions,
+		},
+	}
+
+	for _, protocol := range []otel.Protocol{otel.ProtocolHTTP, otel.ProtocolGRPC} {
+		for _, test := range tests {
+			t.Run(string(protocol)+test.name, func(t *testing.T) {
+				restoreGlobals(t)
+
+				options := test.options
+				options.OTLPEndpoint = "localhost:4318"
+				options.OTLPProtocol = otel.ProtocolFlag{Protocol: protocol}
+
+				err := options.Setup(t.Context())
+				require.Error(t, err)
+
+				if test.err != nil {
+					require.ErrorIs(t, err, test.err)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/unikorn-cloud/core/pkg/constants"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// DeletionProtectionWebhookPath is where the deletion protection admission
+	// webhook is served.  Use this in your ValidatingWebhookConfiguration.
+	DeletionProtectionWebhookPath = "/validate-deletion-protection"
+)
+
+// DeletionProtected returns true if the resource has deletion protection enabled.
+func DeletionProtected(object metav1.Object) bool {
+	return object.GetAnnotations()[constants.DeletionProtectionAnnotation] == "true"
+}
+
+// DeletionProtectionValidator is an admission handler that rejects deletion of
+// any resource with deletion protection enabled.  This is optional, and prevents
+// the resource from entering a deleting state at all, the reconciler will
+// prevent deprovisioning in the absence of this.
+type DeletionProtectionValidator struct{}
+
+// Ensure this implements the admission.Handler interface.
+var _ admission.Handler = &DeletionProtectionValidator{}
+
+// Handle implements the admission.Handler interface.
+func (*DeletionProtectionValidator) Handle(_ context.Context, request admission.Request) admission.Response {
+	if request.Operation != admissionv1.Delete {
+		return admission.Allowed("")
+	}
+
+	// We only care about metadata, and this allows the handler to be used for
+	// any resource type, not just those known to the scheme.
+	object := &metav1.PartialObjectMetadata{}
+
+	if err := json.Unmarshal(request.OldObject.Raw, object); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if DeletionProtected(object) {
+		return admission.Denied(fmt.Sprintf("resource has deletion protection enabled, remove the %s annotation to continue", constants.DeletionProtectionAnnotation))
+	}
+
+	return admission.Allowed("")
+}
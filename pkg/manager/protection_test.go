@@ -0,0 +1,81 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	unikornv1fake "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1/fake"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/manager"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func mustNewAdmissionRequest(t *testing.T, operation admissionv1.Operation, annotations map[string]string) admission.Request {
+	t.Helper()
+
+	object := &unikornv1fake.ManagedResource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   testNamespace,
+			Name:        testName,
+			Annotations: annotations,
+		},
+	}
+
+	raw, err := json.Marshal(object)
+	require.NoError(t, err)
+
+	request := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			OldObject: runtime.RawExtension{
+				Raw: raw,
+			},
+		},
+	}
+
+	return request
+}
+
+// TestDeletionProtectionValidator checks the admission webhook only denies
+// deletion of protected resources.
+func TestDeletionProtectionValidator(t *testing.T) {
+	t.Parallel()
+
+	protected := map[string]string{
+		constants.DeletionProtectionAnnotation: "true",
+	}
+
+	validator := &manager.DeletionProtectionValidator{}
+
+	response := validator.Handle(t.Context(), mustNewAdmissionRequest(t, admissionv1.Delete, protected))
+	require.False(t, response.Allowed)
+
+	response = validator.Handle(t.Context(), mustNewAdmissionRequest(t, admissionv1.Delete, nil))
+	require.True(t, response.Allowed)
+
+	response = validator.Handle(t.Context(), mustNewAdmissionRequest(t, admissionv1.Update, protected))
+	require.True(t, response.Allowed)
+}
@@ -25,16 +25,18 @@ import (
 	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
 	"github.com/unikorn-cloud/core/pkg/cd"
 	"github.com/unikorn-cloud/core/pkg/cd/argocd"
-	"github.com/unikorn-cloud/core/pkg/client"
+	coreclient "github.com/unikorn-cloud/core/pkg/client"
 	"github.com/unikorn-cloud/core/pkg/constants"
 	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
 	"github.com/unikorn-cloud/core/pkg/manager/options"
+	"github.com/unikorn-cloud/core/pkg/manager/sharding"
 	"github.com/unikorn-cloud/core/pkg/provisioners"
 	"github.com/unikorn-cloud/core/pkg/provisioners/application"
 
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -78,6 +80,14 @@ func NewReconciler(options *options.Options, controllerOptions ControllerOptions
 // Ensure this implements the reconcile.Reconciler interface.
 var _ reconcile.Reconciler = &Reconciler{}
 
+// Ensure this implements the ObjectTyper interface.
+var _ ObjectTyper = &Reconciler{}
+
+// NewObject implements the ObjectTyper interface.
+func (r *Reconciler) NewObject() client.Object {
+	return r.createProvisioner(r.controllerOptions).Object()
+}
+
 func (r *Reconciler) getDriver() (cd.Driver, error) {
 	if r.options.CDDriver.Kind != cd.DriverKindArgoCD {
 		return nil, coreerrors.ErrCDDriver
@@ -106,19 +116,19 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	// The namespace allows access to the current namespace to lookup any
 	// namespace scoped resources.
-	ctx = client.NewContextWithNamespace(ctx, r.options.Namespace)
+	ctx = coreclient.NewContextWithNamespace(ctx, r.options.Namespace)
 
 	// The static client is used by the application provisioner to get access to
 	// application bundles and definitions regardless of remote cluster scoping etc.
-	ctx = client.NewContextWithProvisionerClient(ctx, r.manager.GetClient())
+	ctx = coreclient.NewContextWithProvisionerClient(ctx, r.manager.GetClient())
 
 	// The cluster context is updated as remote clusters are descended into.
-	clusterContext := &client.ClusterContext{
+	clusterContext := &coreclient.ClusterContext{
 		// TODO: cluster information.
 		Client: r.manager.GetClient(),
 	}
 
-	ctx = client.NewContextWithCluster(ctx, clusterContext)
+	ctx = coreclient.NewContextWithCluster(ctx, clusterContext)
 
 	// The driver context is updated as remote provisioners are descended into.
 	ctx = cd.NewContext(ctx, driver)
@@ -138,6 +148,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		return reconcile.Result{}, err
 	}
 
+	// When sharded, another replica may be responsible for this object.
+	if sharder, err := sharding.FromContext(ctx); err == nil && !sharder.Owns(object) {
+		log.V(1).Info("object owned by another shard")
+
+		return reconcile.Result{}, nil
+	}
+
 	if object.Paused() {
 		log.Info("reconcilication paused")
 
@@ -151,6 +168,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 			return reconcile.Result{}, nil
 		}
 
+		// Deletion protection is a safety net against fat fingers, don't touch
+		// anything until the annotation has been removed.
+		if DeletionProtected(object) {
+			log.Info("deletion blocked by protection annotation")
+
+			return r.reconcileDeleteProtected(ctx, object)
+		}
+
 		log.Info("deleting object")
 
 		return r.reconcileDelete(ctx, provisioner, object)
@@ -162,6 +187,22 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	return r.reconcileNormal(ctx, provisioner, object)
 }
 
+// reconcileDeleteProtected handles deletion of a protected object, the finalizer
+// is retained and the status updated to tell the user what's going on.
+func (r *Reconciler) reconcileDeleteProtected(ctx context.Context, object unikornv1.ManagableResourceInterface) (reconcile.Result, error) {
+	message := fmt.Sprintf("Deletion blocked, remove the %s annotation to continue", constants.DeletionProtectionAnnotation)
+
+	object.StatusConditionWrite(unikornv1.ConditionAvailable, corev1.ConditionTrue, unikornv1.ConditionReasonDeletionProtected, message)
+
+	if err := r.manager.GetClient().Status().Update(ctx, object); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Metadata updates may be filtered out by watch predicates, so periodically
+	// poll to see if the protection has been lifted.
+	return reconcile.Result{RequeueAfter: constants.DefaultYieldTimeout}, nil
+}
+
 // reconcileDelete handles object deletion.
 func (r *Reconciler) reconcileDelete(ctx context.Context, provisioner provisioners.Provisioner, object unikornv1.ManagableResourceInterface) (reconcile.Result, error) {
 	log := log.FromContext(ctx)
@@ -470,3 +470,48 @@ func TestReconcileDeleteError(t *testing.T) {
 	assert.Contains(t, result.Finalizers, constants.Finalizer)
 	mustAssertStatus(t, &result, corev1.ConditionFalse, unikornv1.ConditionReasonErrored)
 }
+
+// TestReconcileDeleteProtected checks that a resource marked as being deleted with
+// deletion protection enabled is not deprovisioned, and the finalizer is retained.
+func TestReconcileDeleteProtected(t *testing.T) {
+	t.Parallel()
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	request := &unikornv1fake.ManagedResource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testName,
+			Annotations: map[string]string{
+				constants.DeletionProtectionAnnotation: "true",
+			},
+			Finalizers: []string{
+				constants.Finalizer,
+			},
+			DeletionTimestamp: &metav1.Time{
+				Time: time.Now(),
+			},
+		},
+	}
+
+	tc := mustNewTestContext(t, request)
+	ctx := t.Context()
+
+	// NOTE: Deprovision is not expected to be called.
+	p := mockprovisioners.NewMockManagerProvisioner(c)
+	p.EXPECT().Object().Return(&unikornv1fake.ManagedResource{})
+
+	reconciler := manager.NewReconciler(managerOptions(), nil, tc.newManager(c), func(_ manager.ControllerOptions) provisioners.ManagerProvisioner { return p })
+
+	result, err := reconciler.Reconcile(ctx, newRequest(testNamespace, testName))
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultYieldTimeout, result.RequeueAfter)
+
+	// Does the resource still exist in Kubernetes?
+	var resource unikornv1fake.ManagedResource
+
+	assert.NoError(t, tc.client.Get(ctx, newNamespacedName(testNamespace, testName), &resource))
+	assert.Contains(t, resource.Finalizers, constants.Finalizer)
+	mustAssertStatus(t, &resource, corev1.ConditionTrue, unikornv1.ConditionReasonDeletionProtected)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+
+	"github.com/unikorn-cloud/core/pkg/errors"
+)
+
+type contextkeyType int
+
+//nolint:gochecknoglobals
+var contextkey contextkeyType
+
+func NewContext(ctx context.Context, sharder *Sharder) context.Context {
+	return context.WithValue(ctx, contextkey, sharder)
+}
+
+func FromContext(ctx context.Context) (*Sharder, error) {
+	if value := ctx.Value(contextkey); value != nil {
+		if sharder, ok := value.(*Sharder); ok {
+			return sharder, nil
+		}
+	}
+
+	return nil, errors.ErrInvalidContext
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	goerrors "errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/unikorn-cloud/core/pkg/errors"
+)
+
+var (
+	// ErrOptions is raised when sharding is misconfigured.
+	ErrOptions = goerrors.New("sharding options invalid")
+)
+
+// Key defines how a resource is mapped to a shard.
+type Key string
+
+const (
+	// KeyName shards resources by their namespace and name, this gives the
+	// most even distribution, and is known for any reconcile request, so
+	// events from secondary watches can be filtered too.
+	KeyName Key = "name"
+
+	// KeyOrganization shards resources by their organization label, so all
+	// resources belonging to an organization are handled by the same replica.
+	// Resources without an organization label fall back to their name.
+	KeyOrganization Key = "organization"
+)
+
+// KeyFlag wraps up the shard key in a flag that can be used on the CLI.
+type KeyFlag struct {
+	Key Key
+}
+
+var _ pflag.Value = &KeyFlag{}
+
+// String implements the pflag.Value interface.
+func (s *KeyFlag) String() string {
+	return string(s.Key)
+}
+
+// Set implements the pflag.Value interface.
+func (s *KeyFlag) Set(in string) error {
+	valid := []Key{
+		KeyName,
+		KeyOrganization,
+	}
+
+	value := Key(in)
+
+	if !slices.Contains(valid, value) {
+		return errors.ErrParseFlag
+	}
+
+	s.Key = value
+
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *KeyFlag) Type() string {
+	return "string"
+}
+
+// Options defines sharding options.
+type Options struct {
+	// Enabled turns on sharding.  When enabled, leader election is disabled
+	// and all replicas process a subset of resources.
+	Enabled bool
+
+	// Key defines how resources are mapped to shards.
+	Key KeyFlag
+
+	// Identity uniquely identifies this replica, defaulting to the host name
+	// which is the pod name in Kubernetes.
+	Identity string
+
+	// LeaseDuration is how long a replica's lease is valid for without being
+	// renewed before it's considered dead and its resources are redistributed.
+	LeaseDuration time.Duration
+
+	// RenewInterval is how often the lease is renewed and membership is
+	// checked for changes.
+	RenewInterval time.Duration
+}
+
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	o.Key.Key = KeyName
+
+	f.BoolVar(&o.Enabled, "sharding", false, "Distribute resources across all replicas rather than using leader election")
+	f.Var(&o.Key, "shard-key", "How to map resources to shards from [name organization]")
+	f.StringVar(&o.Identity, "shard-identity", "", "Unique replica identity, defaults to the host name")
+	f.DurationVar(&o.LeaseDuration, "shard-lease-duration", 30*time.Second, "How long before an unrenewed shard lease is considered expired")
+	f.DurationVar(&o.RenewInterval, "shard-renew-interval", 10*time.Second, "How often to renew the shard lease and check for membership changes")
+}
+
+// Validate checks the options are consistent.
+func (o *Options) Validate() error {
+	if o.RenewInterval <= 0 {
+		return fmt.Errorf("%w: renew interval must be positive", ErrOptions)
+	}
+
+	if o.LeaseDuration <= o.RenewInterval {
+		return fmt.Errorf("%w: lease duration must be longer than the renew interval", ErrOptions)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strconv"
+)
+
+const (
+	// virtualNodes is the number of points each member has on the ring.
+	// More points give a more even distribution of keys at the expense of
+	// memory and lookup time.
+	virtualNodes = 128
+)
+
+// point is a position on the hash ring owned by a member.
+type point struct {
+	hash   uint64
+	member string
+}
+
+// Ring is a consistent hash ring.  When a member is added or removed only
+// the keys owned by that member are redistributed.
+type Ring struct {
+	points []point
+}
+
+// hash maps a string to a position on the ring.  Keys and virtual nodes are
+// typically very similar strings, so we need something with good avalanche
+// properties to get an even distribution.
+func hash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// NewRing creates a hash ring from a set of members.
+func NewRing(members []string) *Ring {
+	points := make([]point, 0, len(members)*virtualNodes)
+
+	for _, member := range members {
+		for i := range virtualNodes {
+			points = append(points, point{
+				hash:   hash(member + "#" + strconv.Itoa(i)),
+				member: member,
+			})
+		}
+	}
+
+	slices.SortFunc(points, func(a, b point) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+
+	return &Ring{
+		points: points,
+	}
+}
+
+// Lookup returns the member that owns the key, or false if there are no
+// members.
+func (r *Ring) Lookup(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	h := hash(key)
+
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p point, h uint64) int {
+		return cmp.Compare(p.hash, h)
+	})
+
+	// Wrap around to the start of the ring.
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.points[i].member, true
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/manager/sharding"
+)
+
+const (
+	testKeys = 10000
+)
+
+// TestRingEmpty checks an empty ring owns nothing.
+func TestRingEmpty(t *testing.T) {
+	t.Parallel()
+
+	_, ok := sharding.NewRing(nil).Lookup("foo")
+	require.False(t, ok)
+}
+
+// TestRingDistribution checks keys are roughly evenly distributed.
+func TestRingDistribution(t *testing.T) {
+	t.Parallel()
+
+	members := []string{"a", "b", "c", "d"}
+
+	ring := sharding.NewRing(members)
+
+	counts := map[string]int{}
+
+	for i := range testKeys {
+		member, ok := ring.Lookup(strconv.Itoa(i))
+		require.True(t, ok)
+
+		counts[member]++
+	}
+
+	require.Len(t, counts, len(members))
+
+	// Allow for +/- 50% of a fair share.
+	fair := testKeys / len(members)
+
+	for _, count := range counts {
+		require.InDelta(t, fair, count, float64(fair)/2)
+	}
+}
+
+// TestRingRebalance checks that removing a member only moves the keys owned
+// by that member.
+func TestRingRebalance(t *testing.T) {
+	t.Parallel()
+
+	before := sharding.NewRing([]string{"a", "b", "c"})
+	after := sharding.NewRing([]string{"a", "b"})
+
+	for i := range testKeys {
+		key := strconv.Itoa(i)
+
+		b, _ := before.Lookup(key)
+		a, _ := after.Lookup(key)
+
+		if b != "c" {
+			require.Equal(t, b, a)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/unikorn-cloud/core/pkg/constants"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Sharder coordinates replicas via leases and allows resources to be distributed
+// across them with a consistent hash.  Each replica holds a lease labeled with the
+// shard group, and the set of unexpired leases defines the shard membership.
+// Replicas observe membership changes at different times, so resources gained in a
+// rebalance are fenced off for a renew interval, by which time the previous owner
+// has observed the change and stopped processing them.
+// NOTE: the service account requires permission to create, update, list and delete
+// leases in the process namespace.
+type Sharder struct {
+	// options define the sharding behaviour.
+	options *Options
+
+	// client is used to manage our lease.
+	client client.Client
+
+	// reader is an uncached client used to read leases and resources, we don't
+	// want to be caching leases cluster wide.
+	reader client.Reader
+
+	// namespace is where leases live.
+	namespace string
+
+	// group is the name of the shard group, typically the application name.
+	group string
+
+	// identity is the unique replica identity.
+	identity string
+
+	// gvk is the type of resource that is sharded, this is used to find newly
+	// owned resources after a rebalance.
+	gvk schema.GroupVersionKind
+
+	// events are used to trigger reconciles of newly owned resources.
+	events chan event.GenericEvent
+
+	// lock protects the members and rings.
+	lock sync.RWMutex
+
+	// members is the current sorted list of shard members.
+	members []string
+
+	// ring maps resources to members.
+	ring *Ring
+
+	// previous is the ring before the last membership change.
+	previous *Ring
+
+	// settled is when all replicas are guaranteed to have observed the last
+	// membership change, until then resources gained from another replica are
+	// not owned.
+	settled time.Time
+}
+
+// Ensure the manager runs us on all replicas.
+var _ manager.Runnable = &Sharder{}
+var _ manager.LeaderElectionRunnable = &Sharder{}
+
+// New creates a new sharder.
+func New(options *Options, manager manager.Manager, namespace, group string, gvk schema.GroupVersionKind) (*Sharder, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Without a namespace leases would be listed cluster wide, and creation
+	// would fail.
+	if namespace == "" {
+		return nil, fmt.Errorf("%w: lease namespace must be specified", ErrOptions)
+	}
+
+	identity := options.Identity
+
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+
+		identity = hostname
+	}
+
+	s := &Sharder{
+		options:   options,
+		client:    manager.GetClient(),
+		reader:    manager.GetAPIReader(),
+		namespace: namespace,
+		group:     group,
+		identity:  identity,
+		gvk:       gvk,
+		events:    make(chan event.GenericEvent),
+		ring:      NewRing(nil),
+		previous:  NewRing(nil),
+	}
+
+	return s, nil
+}
+
+// key returns the sharding key for a resource.  This must only depend on what
+// is available in a reconcile request, or organization labels.
+func (s *Sharder) key(key client.ObjectKey, labels map[string]string) string {
+	if s.options.Key.Key == KeyOrganization {
+		if organization, ok := labels[constants.OrganizationLabel]; ok {
+			return organization
+		}
+	}
+
+	return key.String()
+}
+
+// ownsKey returns true if this replica is responsible for the sharding key.
+func (s *Sharder) ownsKey(key string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if !s.ownsKeyInRing(s.ring, key) {
+		return false
+	}
+
+	// Resources gained in the last rebalance may still be being processed
+	// by their previous owner until it has observed the change.
+	return !time.Now().Before(s.settled) || s.ownsKeyInRing(s.previous, key)
+}
+
+func (s *Sharder) ownsKeyInRing(ring *Ring, key string) bool {
+	member, ok := ring.Lookup(key)
+	if !ok {
+		return false
+	}
+
+	return member == s.identity
+}
+
+// Owns returns true if this replica is responsible for the resource.  This is
+// the definitive check, and must be made on the primary resource by the reconciler.
+func (s *Sharder) Owns(object metav1.Object) bool {
+	return s.ownsKey(s.key(client.ObjectKey{Namespace: object.GetNamespace(), Name: object.GetName()}, object.GetLabels()))
+}
+
+// OwnsRequest returns true if this replica may be responsible for the reconcile
+// request.  When sharding by organization the request alone isn't enough to tell,
+// so this always returns true and it's left to the reconciler to decide.
+func (s *Sharder) OwnsRequest(request reconcile.Request) bool {
+	if s.options.Key.Key == KeyOrganization {
+		return true
+	}
+
+	return s.ownsKey(s.key(request.NamespacedName, nil))
+}
+
+// Predicate filters watch events to only those resources owned by this replica.
+// This must only be applied to watches of the primary resource, use Handler for
+// secondary watches.
+func (s *Sharder) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return s.Owns(object)
+	})
+}
+
+// queue filters reconcile requests to those owned by this replica.
+type queue struct {
+	workqueue.TypedRateLimitingInterface[reconcile.Request]
+
+	sharder *Sharder
+}
+
+func (q *queue) Add(request reconcile.Request) {
+	if q.sharder.OwnsRequest(request) {
+		q.TypedRateLimitingInterface.Add(request)
+	}
+}
+
+func (q *queue) AddAfter(request reconcile.Request, duration time.Duration) {
+	if q.sharder.OwnsRequest(request) {
+		q.TypedRateLimitingInterface.AddAfter(request, duration)
+	}
+}
+
+func (q *queue) AddRateLimited(request reconcile.Request) {
+	if q.sharder.OwnsRequest(request) {
+		q.TypedRateLimitingInterface.AddRateLimited(request)
+	}
+}
+
+// handlerFilter wraps an event handler so only reconcile requests owned by this
+// replica are queued.
+type handlerFilter struct {
+	handler handler.EventHandler
+
+	sharder *Sharder
+}
+
+func (h *handlerFilter) queue(q workqueue.TypedRateLimitingInterface[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return &queue{TypedRateLimitingInterface: q, sharder: h.sharder}
+}
+
+func (h *handlerFilter) Create(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.handler.Create(ctx, e, h.queue(q))
+}
+
+func (h *handlerFilter) Update(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.handler.Update(ctx, e, h.queue(q))
+}
+
+func (h *handlerFilter) Delete(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.handler.Delete(ctx, e, h.queue(q))
+}
+
+func (h *handlerFilter) Generic(ctx context.Context, e event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.handler.Generic(ctx, e, h.queue(q))
+}
+
+// Handler filters the reconcile requests generated by an event handler to those
+// owned by this replica.  Use this for secondary watches, where events are for
+// other resources that are mapped to the primary resource.
+func (s *Sharder) Handler(h handler.EventHandler) handler.EventHandler {
+	return &handlerFilter{
+		handler: h,
+		sharder: s,
+	}
+}
+
+// Source returns a watch source that triggers reconciles for resources that
+// become owned by this replica on a rebalance.
+func (s *Sharder) Source() source.Source {
+	return source.Channel(s.events, &handler.EnqueueRequestForObject{})
+}
+
+// NeedLeaderElection implements the manager.LeaderElectionRunnable interface.
+func (s *Sharder) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements the manager.Runnable interface.
+func (s *Sharder) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithValues("shard", s.identity)
+
+	// Let everyone else know we are going away so they can rebalance immediately
+	// rather than waiting for the lease to expire.
+	defer s.release()
+
+	ticker := time.NewTicker(s.options.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.renew(ctx); err != nil {
+			log.Error(err, "failed to renew shard lease")
+		}
+
+		if err := s.sync(ctx); err != nil {
+			log.Error(err, "failed to synchronize shard membership")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaseName returns our lease's name.
+func (s *Sharder) leaseName() string {
+	return s.group + "-shard-" + s.identity
+}
+
+// renew creates or updates our lease.
+func (s *Sharder) renew(ctx context.Context) error {
+	now := metav1.NowMicro()
+
+	lease := &coordinationv1.Lease{}
+
+	if err := s.reader.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.leaseName()}, lease); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      s.leaseName(),
+				Labels: map[string]string{
+					constants.ShardGroupLabel: s.group,
+				},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(s.identity),
+				LeaseDurationSeconds: ptr.To(int32(s.options.LeaseDuration.Seconds())),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+
+		return s.client.Create(ctx, lease)
+	}
+
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(s.options.LeaseDuration.Seconds()))
+	lease.Spec.RenewTime = &now
+
+	return s.client.Update(ctx, lease)
+}
+
+// release deletes our lease.
+func (s *Sharder) release() {
+	// The main context is cancelled by now, so give ourselves a little
+	// time to tidy up.
+	ctx, cancel := context.WithTimeout(context.Background(), s.options.RenewInterval)
+	defer cancel()
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.namespace,
+			Name:      s.leaseName(),
+		},
+	}
+
+	if err := s.client.Delete(ctx, lease); err != nil && !kerrors.IsNotFound(err) {
+		log.Log.Error(err, "failed to release shard lease", "shard", s.identity)
+	}
+}
+
+// sync reads all live leases and rebalances if the membership has changed.
+func (s *Sharder) sync(ctx context.Context) error {
+	leases := &coordinationv1.LeaseList{}
+
+	if err := s.reader.List(ctx, leases, client.InNamespace(s.namespace), client.MatchingLabels{constants.ShardGroupLabel: s.group}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	members := make([]string, 0, len(leases.Items))
+
+	for i := range leases.Items {
+		spec := &leases.Items[i].Spec
+
+		if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+			continue
+		}
+
+		if spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second).Before(now) {
+			continue
+		}
+
+		members = append(members, *spec.HolderIdentity)
+	}
+
+	slices.Sort(members)
+
+	s.lock.RLock()
+	changed := !slices.Equal(members, s.members)
+	s.lock.RUnlock()
+
+	if !changed {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("shard membership changed", "shard", s.identity, "members", members)
+
+	return s.rebalance(ctx, members)
+}
+
+// rebalance updates the hash ring and triggers reconciliation of any resources
+// that this replica has gained ownership of, once the change has settled.
+func (s *Sharder) rebalance(ctx context.Context, members []string) error {
+	ring := NewRing(members)
+
+	s.lock.Lock()
+	previous := s.ring
+	s.members = members
+	s.ring = ring
+	s.previous = previous
+	s.settled = time.Now().Add(s.options.RenewInterval)
+	s.lock.Unlock()
+
+	resources := &metav1.PartialObjectMetadataList{}
+	resources.SetGroupVersionKind(s.gvk.GroupVersion().WithKind(s.gvk.Kind + "List"))
+
+	if err := s.reader.List(ctx, resources); err != nil {
+		return err
+	}
+
+	var owned []*metav1.PartialObjectMetadata
+
+	for i := range resources.Items {
+		resource := &resources.Items[i]
+
+		key := s.key(client.ObjectKeyFromObject(resource), resource.GetLabels())
+
+		if s.ownsKeyInRing(previous, key) || !s.ownsKeyInRing(ring, key) {
+			continue
+		}
+
+		owned = append(owned, resource)
+	}
+
+	// Do this asynchronously, the controller may not be consuming events yet
+	// and we don't want to block lease renewal.
+	go s.enqueue(ctx, owned)
+
+	return nil
+}
+
+// enqueue triggers reconciliation of the provided resources once the previous
+// owners have stopped processing them.
+func (s *Sharder) enqueue(ctx context.Context, resources []*metav1.PartialObjectMetadata) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(s.options.RenewInterval):
+	}
+
+	for _, resource := range resources {
+		select {
+		case <-ctx.Done():
+			return
+		case s.events <- event.GenericEvent{Object: resource}:
+		}
+	}
+}
@@ -0,0 +1,431 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/manager/sharding"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	testNamespace = "unikorn"
+	testGroup     = "test"
+	testResources = 100
+
+	testRenewInterval = 50 * time.Millisecond
+	testLeaseDuration = time.Second
+	testTimeout       = 5 * time.Second
+)
+
+// fakeManager provides just enough of a manager for a sharder.
+type fakeManager struct {
+	manager.Manager
+
+	client client.Client
+}
+
+func (m *fakeManager) GetClient() client.Client {
+	return m.client
+}
+
+func (m *fakeManager) GetAPIReader() client.Reader {
+	return m.client
+}
+
+func testOptions(identity string, key sharding.Key) *sharding.Options {
+	return &sharding.Options{
+		Enabled:       true,
+		Key:           sharding.KeyFlag{Key: key},
+		Identity:      identity,
+		LeaseDuration: testLeaseDuration,
+		RenewInterval: testRenewInterval,
+	}
+}
+
+func testClient(t *testing.T) client.Client {
+	t.Helper()
+
+	objects := make([]client.Object, testResources)
+
+	for i := range objects {
+		objects[i] = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "resource-" + strconv.Itoa(i),
+				Labels: map[string]string{
+					constants.OrganizationLabel: "organization-" + strconv.Itoa(i%10),
+				},
+			},
+		}
+	}
+
+	return fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objects...).Build()
+}
+
+func newSharder(t *testing.T, c client.Client, identity string, key sharding.Key) *sharding.Sharder {
+	t.Helper()
+
+	sharder, err := sharding.New(testOptions(identity, key), &fakeManager{client: c}, testNamespace, testGroup, corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	require.NoError(t, err)
+
+	return sharder
+}
+
+// start runs the sharder until the test completes.
+func start(t *testing.T, sharder *sharding.Sharder) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, sharder.Start(ctx))
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func resources(t *testing.T, c client.Client) []corev1.ConfigMap {
+	t.Helper()
+
+	list := &corev1.ConfigMapList{}
+	require.NoError(t, c.List(t.Context(), list))
+	require.Len(t, list.Items, testResources)
+
+	return list.Items
+}
+
+// settled returns true when every resource is owned by exactly one sharder.
+func settled(items []corev1.ConfigMap, sharders ...*sharding.Sharder) bool {
+	for i := range items {
+		owners := 0
+
+		for _, sharder := range sharders {
+			if sharder.Owns(&items[i]) {
+				owners++
+			}
+		}
+
+		if owners != 1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// balanced returns true when every resource is owned by exactly one sharder,
+// and every sharder owns something i.e. all members have been observed.
+func balanced(items []corev1.ConfigMap, sharders ...*sharding.Sharder) bool {
+	for _, sharder := range sharders {
+		owned := false
+
+		for i := range items {
+			if sharder.Owns(&items[i]) {
+				owned = true
+
+				break
+			}
+		}
+
+		if !owned {
+			return false
+		}
+	}
+
+	return settled(items, sharders...)
+}
+
+// TestNewInvalid checks misconfiguration is rejected up front.
+func TestNewInvalid(t *testing.T) {
+	t.Parallel()
+
+	m := &fakeManager{client: testClient(t)}
+	gvk := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+
+	_, err := sharding.New(testOptions("a", sharding.KeyName), m, "", testGroup, gvk)
+	require.ErrorIs(t, err, sharding.ErrOptions)
+
+	options := testOptions("a", sharding.KeyName)
+	options.LeaseDuration = options.RenewInterval
+
+	_, err = sharding.New(options, m, testNamespace, testGroup, gvk)
+	require.ErrorIs(t, err, sharding.ErrOptions)
+
+	options.RenewInterval = 0
+
+	_, err = sharding.New(options, m, testNamespace, testGroup, gvk)
+	require.ErrorIs(t, err, sharding.ErrOptions)
+}
+
+// TestSharderLease checks a sharder maintains a labeled lease, and releases it
+// when stopped.
+func TestSharderLease(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+	sharder := newSharder(t, c, "a", sharding.KeyName)
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, sharder.Start(ctx))
+	}()
+
+	lease := &coordinationv1.Lease{}
+
+	require.Eventually(t, func() bool {
+		return c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: testGroup + "-shard-a"}, lease) == nil
+	}, testTimeout, testRenewInterval)
+
+	require.Equal(t, testGroup, lease.Labels[constants.ShardGroupLabel])
+	require.Equal(t, "a", *lease.Spec.HolderIdentity)
+
+	cancel()
+	<-done
+
+	leases := &coordinationv1.LeaseList{}
+	require.NoError(t, c.List(t.Context(), leases))
+	require.Empty(t, leases.Items)
+}
+
+// TestSharderFence checks resources aren't owned until the membership change has
+// had time to be observed by all replicas.
+func TestSharderFence(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+	items := resources(t, c)
+	sharder := newSharder(t, c, "a", sharding.KeyName)
+
+	require.False(t, sharder.Owns(&items[0]))
+
+	start(t, sharder)
+
+	// Membership is synchronized immediately, but ownership is fenced.
+	time.Sleep(testRenewInterval / 5)
+	require.False(t, sharder.Owns(&items[0]))
+
+	require.Eventually(t, func() bool {
+		return settled(items, sharder)
+	}, testTimeout, testRenewInterval/5)
+}
+
+// TestSharderDistribution checks resources are owned by exactly one of many
+// replicas, and that reconcile requests are sharded in the same way as the
+// resources they refer to.
+func TestSharderDistribution(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+	items := resources(t, c)
+
+	a := newSharder(t, c, "a", sharding.KeyName)
+	b := newSharder(t, c, "b", sharding.KeyName)
+
+	start(t, a)
+	start(t, b)
+
+	require.Eventually(t, func() bool {
+		return balanced(items, a, b)
+	}, testTimeout, testRenewInterval)
+
+	owned := 0
+
+	for i := range items {
+		request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&items[i])}
+
+		require.Equal(t, a.Owns(&items[i]), a.OwnsRequest(request))
+		require.Equal(t, b.Owns(&items[i]), b.OwnsRequest(request))
+
+		if a.Owns(&items[i]) {
+			owned++
+		}
+	}
+
+	require.NotZero(t, owned)
+	require.NotEqual(t, testResources, owned)
+}
+
+// TestSharderOrganization checks all resources in an organization are owned by the
+// same replica, and requests are left to the reconciler to decide.
+func TestSharderOrganization(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+	items := resources(t, c)
+
+	a := newSharder(t, c, "a", sharding.KeyOrganization)
+	b := newSharder(t, c, "b", sharding.KeyOrganization)
+
+	start(t, a)
+	start(t, b)
+
+	require.Eventually(t, func() bool {
+		return balanced(items, a, b)
+	}, testTimeout, testRenewInterval)
+
+	owners := map[string]bool{}
+
+	for i := range items {
+		organization := items[i].Labels[constants.OrganizationLabel]
+
+		if owner, ok := owners[organization]; ok {
+			require.Equal(t, owner, a.Owns(&items[i]))
+		}
+
+		owners[organization] = a.Owns(&items[i])
+
+		require.True(t, a.OwnsRequest(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&items[i])}))
+	}
+}
+
+// TestSharderHandler checks secondary watch handlers only queue requests for
+// owned resources, regardless of the object that triggered the event.
+func TestSharderHandler(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+	items := resources(t, c)
+
+	a := newSharder(t, c, "a", sharding.KeyName)
+	b := newSharder(t, c, "b", sharding.KeyName)
+
+	start(t, a)
+	start(t, b)
+
+	require.Eventually(t, func() bool {
+		return balanced(items, a, b)
+	}, testTimeout, testRenewInterval)
+
+	// Map a secondary resource to the primary resource with the same name.
+	mapper := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, object client.Object) []reconcile.Request {
+		return []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: object.GetName()}},
+		}
+	})
+
+	h := a.Handler(mapper)
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	expected := 0
+
+	for i := range items {
+		secondary := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      items[i].Name,
+				UID:       types.UID("secondary-" + strconv.Itoa(i)),
+			},
+		}
+
+		h.Create(t.Context(), event.CreateEvent{Object: secondary}, queue)
+
+		if a.Owns(&items[i]) {
+			expected++
+		}
+	}
+
+	require.NotZero(t, expected)
+	require.Equal(t, expected, queue.Len())
+
+	for range expected {
+		request, _ := queue.Get()
+
+		primary := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(t.Context(), request.NamespacedName, primary))
+		require.True(t, a.Owns(primary))
+
+		queue.Done(request)
+	}
+}
+
+// TestSharderRebalance checks a replica joining the shard group is triggered to
+// reconcile the resources it has gained, and the previous owner stops owning them.
+func TestSharderRebalance(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+	items := resources(t, c)
+
+	a := newSharder(t, c, "a", sharding.KeyName)
+
+	start(t, a)
+
+	require.Eventually(t, func() bool {
+		return settled(items, a)
+	}, testTimeout, testRenewInterval)
+
+	b := newSharder(t, c, "b", sharding.KeyName)
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+
+	require.NoError(t, b.Source().Start(t.Context(), queue))
+
+	start(t, b)
+
+	require.Eventually(t, func() bool {
+		return balanced(items, a, b)
+	}, testTimeout, testRenewInterval)
+
+	gained := 0
+
+	for i := range items {
+		if b.Owns(&items[i]) {
+			gained++
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return queue.Len() == gained
+	}, testTimeout, testRenewInterval)
+}
@@ -70,3 +70,27 @@ func (s *Schema) FindRoute(r *http.Request) (*routers.Route, map[string]string,
 
 	return route, params, nil
 }
+
+// EventStreamMediaType is the media type of server-sent event streams.
+const EventStreamMediaType = "text/event-stream"
+
+// IsEventStream returns whether any of the route's responses are server-sent
+// event streams.  These are unbounded, so must not be buffered or subject to
+// request timeouts.
+func IsEventStream(route *routers.Route) bool {
+	if route.Operation == nil || route.Operation.Responses == nil {
+		return false
+	}
+
+	for _, response := range route.Operation.Responses.Map() {
+		if response.Value == nil {
+			continue
+		}
+
+		if _, ok := response.Value.Content[EventStreamMediaType]; ok {
+			return true
+		}
+	}
+
+	return false
+}
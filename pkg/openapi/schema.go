@@ -18,41 +18,101 @@ import (
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xZ4W7jyA1+FUK9w7Wo481tgANq4LBIu21v0Ssu2E2vaFdpQGtoa3YljnZm5MS38LsX",
-	"5Ei27MhJbpP+648glmaGQ34kvyFHn7PC1Y1j4hiy2eesQY81RfL6FHH5jioqovMX/YC8NxQKb5toHWez",
-	"7BwCRXALiLgMEB3UGIsScImWQwRPwbW+oACWIZYEC+dryDPGmr5fYdVSnk1yjmUb4KYkBuLCGTKwdi0s",
-	"KUKevYq4/H7h3NdnrwuMeXt6+vK7Ofqvz14bt8yzaTbJrGjyqSW/ziYqOpuJ+tkkC0VJNYraNlKd7Fo3",
-	"Mh6it7zMNpP+BXqP62yz2UwyT6FxHEjnY1FQE8m87V7exeCyJPD0qaUQocQAcyKGfhkgG7ixVQVzgkVb",
-	"LWxVyduw5qL0jl0bqvU053+5FmpcQ+OqSpHqoVMBtWMbnQcbAzTerWywji0vdbAkrGIJIWJsQ87RAd6g",
-	"jSDerUiUVAeVBK4hj/JiKobP0bxNag9tKxxH4qimN01lC13w4kMQWz9ndIsiVX9673w2yyyvsLLmusMg",
-	"m6SR632UeoTmzqyhWyJa7Fz0ladFNst+82IXli/SaHiR9lLv7It9OxS7QCvgpkWgW6j2E3C+AzXNNo4C",
-	"sBOMOKLlnHEL+6fWejKwsFSZoEAVjheVLZ4IUy/lCD648/iNjaUqE7AmkIAGrDyhWQPd2hDDs+DWbdar",
-	"FdK2yC6W5CfQhharag2xtAFqQg6i0hpKXNG+corRwvm5NYb4aSBtxRxBqQ3kofBkiKPFKoBx6setVlv/",
-	"Nd6ubEVLCs8YZTcYwBBbMjBfA7axdN7+0sVYQgrXkukFtiFNEqX2JkqGfiTu1ZYs3lM8FK5RogRkOL94",
-	"sw1etV0il7/ZGZwzU0EhoF8PTAaX6Fa5wpCHpsIo3Ku+shzJM1bvyK/I/1mMfprXggq6To/jjutSMzpI",
-	"1hcV2voZPHPO0DLdNlQI2ToPLZfIRvbSNeCKovWezBQuB/5BiB45WOLYzUM2OctoaIuCRBaD5GT06ynA",
-	"m0Vyr1XwBdoCA02gqQiDOK9xPoKNgEHcZkNoU16wi39xLZunAcwuXi9EzBF0B9xGZkckW5pT2ngGtP/B",
-	"OK9IvLiwbGDHWWpry32g0xPtldMzhOuUascIs42lsECS1nH/c0TUmNw+B5NiXQzLYU+3jWTtNNtsdw4D",
-	"Sw5rhb8Sk7dFF3O1JO6SJnpUY7SCrbKwE+NeSm3TeDm0o6X7pJ6DVG3USU2ljWiGbORXRwY/XF5edFOk",
-	"yJqCZn4A9ARzDCnkZeJPAgG8nJ6+hNBQYRcdFhOYt1GnJ9lkkraio7cUhYNSFaIbBCWx84s3AfRMgVii",
-	"bOAC9XITRe72E4uJ2zqbvR8pK4bxdV1UkrzZ5E6stBzaRtKRZG2Kwmst8iZbmcqxUh/uE1ekunEeva3W",
-	"1y3jCm0l8T5YuN21f7H0yPFgV33XbzlM3UEJUFMsnbmWUawqd3NH9ZqMxV7I7li8mtytYEey4zBCfiY/",
-	"F9y7iIM0Ou8PH5Ug4B/I1mI4nUvikuMEv1PLzT9QoVzzsZ2TZ4oUfsQ5VT9LuT8Wuwok/G07GyqZDtoe",
-	"TCCuG1toJaInqoTUlt+k+pCyBCMUyDCnnC0buiXT9xsGI0psayphlHMvm2X/eX968ofzk3/jyS9Xv301",
-	"2z2dXE+vPp9Ovvt2M5jxu1dfZSOoO79E7s70dxJPpq+p3hKav1NE2VxJr6p+WmSz9/cTkh9bvZl8PqCA",
-	"4bZvzHg3MpwDVmulhSW/31fMqXKsTdvDjj/Y9K63rw5ZtLdgV9fM1/t6aQ4ODispcFO93XgnUp8D1Ec6",
-	"6S7MnQ7HEO6GnwXc3VZfimuvzXFI+1c/aK/4Tkl63LJBN0nSNw7P+B05t/yR3Y1U6Wm+dN6Glh7N7rwe",
-	"5ape2NCVD1motXBVyVFyYFvqrr2NFO4elvcS4uXQW4Ohrox3+qC0g+2ylphSB2szpode7bw2T5Fu43SM",
-	"H9JFxP3hOUqRm0kWcRkeWhtx+aNWdQfhpPteDaC+GFwY3Of5vYuFR/t/uGr4qHFg6GD44cD4sjwXdW3x",
-	"9jC47ua1oXQlcmnrI1c50da0n8yp66soSp2nh3GNMZtlBiOdyPQx95cHmfYY6t/Lzs0+tr9O0ojPR2jn",
-	"cMqB1l9KR5Kr93DQPyVf/5////P8D1Svjt3Z1igdDqzIB60RBrezq2+nL6dn05wvPJ140gY3Ab1Cb1GQ",
-	"kBZAb8+ks+ZYSdPc1a0HhdYqz83v83w6+DdaTB3J319dPN2T+YUnjGT+uB4PBr1duSkddPP2KGDUwTrx",
-	"C6ik2+DxVGKPFCEt20/tQPib16N61s5oq/Wg5W1jHmd5L/EBy3Hf7k78Y+0+CGurDdQQ8kfQU7ry6QnF",
-	"hr32oescPrShuyCZaJQbx9/EnnxyRl4/cOWe2uI5MS1shIV3NaAMsUFvpFfNeatCMnyaczbSMEVcjvb2",
-	"uIQam0Y393MbvTTaXe/jUp8UpgCXJQVKF4PsUoeNlV7dWl7mnG4U17DNHk1j+bMcSbt5mdIGEg4nNhoY",
-	"ugUaI382cWLOHe3p0BbOiS7vLnNkqMBIS+3Wwca79Nzz46G5XVSL1el2eSQAV+O9pESeDvUfPCIuH66/",
-	"VZFe5tW4X5RpR5StbNh+/tJPUf1npgeYe/zDk+WF029UNlYy9CdX104vgaUNTmdcR9nZLPt2ejY91U60",
-	"IcbGZrPsbHo6PUsMXIoam81/AwAA//9yWLSD5RsAAA==",
+	"H4sIAAAAAAAC/+w8a3Mct5F/BZlzSnZudrmkSEbeKpVPZ9oxz3KkkpjkLh4dCzvo3YU1A4wBDMm1zP9+",
+	"1Q1gHruzD4rMt6tKLHIG0+hGvx/gpyTXZaUVKGeT6adkCVyAoR/B8cUP9Cv+JsDmRlZOapVMk1eK6Yr/",
+	"WgMD5aRbMccXzC25Y1Lgk7kEy9wS2A0YK7Viek6/GrC6NjmMGbtaSstKvmIzyFTFrQXBnGZ1JbgDy7gS",
+	"TEAB+LNU9PHlfPQTd/mSeSRxdWXgBpRjuVZ5bQwol6lSCzmXOUdU7ThJE5svoeRIhFtVkEwT64xUi+T+",
+	"Pk2kgLLSDpR7B1XBVyC2kfweHO6YJc7UkCXsdgkq0lRpZYFJyzgzBCbSq41cSMWLdpHTjGfKwK81WMdu",
+	"pVvSQstLYJcRmXw1+hFW+3AvtCdyK5NYIdVH3JFQqcDQcs8nZ3j+EY+ZcbtS+dJopWvLAmK4Nf4oDYhk",
+	"ihTvRsVwB69lKR39ZxtCV0tgJb+TZV0yVZczMHhOYUvr8cq5YjNcJgD5ztmsNh6fjf2lcrAA00fgHZRc",
+	"KqkWu5A4ZPMZzLXBf6RaMATPCoQP4iG4WHCH4WEh10pYVisniyBWXkR+rbXjKFvzuihWJF+gpF0eggg4",
+	"s3o1d2AehoTT7JZLF4+AwOApdNDauzfKF1yKXRvTEnZ50RoHAp2yqgBugSgH5tBOkLIZqLRxiEhl9KyA",
+	"0jKrM9U9rMDAua6VILMBBZSI/m5duk+Tihteggu2T7aa+COs3sZ3QzpWK4l28COsUuZWlcw5somzv/3t",
+	"8iJlC1BguAPBZisiMi8kKMfm2jDg+ZIVeoGfNCZhzNg7cEaCXTMOtAGMF2PGkaOMMwXuVpuPbM5lURtI",
+	"kVG1UZkatjyGuyUYFHTFKjBzbco1pjK+4FIRBrXFdzxTH2HlEeFMyPkcDGLfcEobdruUBawbOw9OWmad",
+	"LAomVaYqoxcGrEU0bV0469U712peyBzp/hFWlsFdJQ0EGk9O2VLXxuLhCZjzuiDBk3jy3gkkaaJ4icxc",
+	"M549fpf87jWohVsm05OzszQppYq/H6dDXmFOjmYH398opJeV2nRdoE0Zt4ENIKLf+u6KL6LPmq3IR3Ax",
+	"ztRV5+Rv8Zi0KtAdRu4ghL7bfGZZcHOdTTNVIrJgmUak8AttAXXkRgoQaXSSyNDWT7Kem2Rzo8tMeWNn",
+	"ZQHKFSumb8DcGukcKHLYwGwFueQFu+FFDSxL/pQlLO7O1Sq6++1MCi58n2fj1n2HOF+KHTy4WnatB37D",
+	"iFBmIAd5A4KoYpxZZ4CXqTclKJS4iNdOl9x5fc3UbMVmRt9aMI21ybVSkOO5pRSOoNyWIa7xIP0G5Doq",
+	"LdV24XzNrRsRRaPLi33Eo+vYQ/amF5UO7aHTQfq8chWSDMo/lmBIRQ0wbsBLLX2QKX7DZcFnQYUbY0Hy",
+	"mGvlOAFy+iOoAN1IuPGrFdw5VvEFNHT/WoNZtWQTKeuaiIgn0+PJZEKKGH5Nh5wIgvleFg7MTlUsVpHq",
+	"qCgd47mQN6AYQtqGJf3TRfILA/NkmvzbURsbH/m39uhjPQOjwIF9zWdQ/B0VIfEOZAFXeEy7vEUTNvsD",
+	"bSwFmQXUU4lBGLItZXWIiLcceaZeockgm44fsNaDsbK26L1b58FtsAoR6AIcvSU8th1MQ9IeiSVTg3ov",
+	"1eK94662n8k0qVobFjnXBc6s4w4sanJtM0V6CirXAgRb6ZqoypJvNvF5CcZok9WTycn5wNtafVT6VmXJ",
+	"1pPY+KZ3JKRL+yQnkvl2E9Z9I/3cGL6iQ7Xa7LMCcwkFcRPXBnVnsxVZ/Lm8A+G1IEtGWUIRB4IAJfAk",
+	"tRFgxpl6La2zZBXoiZfFy4shj7t2Jrhp7xQq7hwYXPm/o2++xFW/5wbIwVzJEn7fPMSvvkiG3K/ji/dQ",
+	"QO602Rl6WXAkK3yBYYMBa8mXOe19kg9nrGsFLGUcdWaOQUu+9GpCS1MMmmrLtkmU44uXc63/+Pwi586L",
+	"ET6acfPHk+M/Pr8QepElY8a+w5CuxSVTlOMKYHUVsw1poATl7DRTmfoTy+g4X5JDzRKmTXzSPIr+tUk4",
+	"kd4lD/k1Lhq3gP6w+zOhwTKlHYM7lBVtCFA3tFsHuA0SAbBh4R92rezv2QGN6v4lT2df7SKyYw8INdsF",
+	"oLTbB2OYYjUENlPvOvxhFirehO5ZkmaJFxgUIS9fFI1nCaibl5XRInXAy5ecBOEvRteVZXruA/ttQH/P",
+	"EtI9juZScSdvSEjVKtK9Lqi0Z6Y2Nv1d3yowtPVfeRnqJ56y6MbJflY8B4unQHvQAWQqX3LDc/Icz17+",
+	"4cuv0t+z5FnjRISuZ0XIxkTqTcozXIBbPMuyZwxszqtobTg922Y0HF8MW841K7BhECmd9cEJred5DpUD",
+	"8S48HLaQMbxGns8AFIufEfIU5GC6WBdzWRQgerWQYjXO1P/omopUlS6KXihOAEqtpNOGSWf7fgpfLoEX",
+	"bkkuC52V04xTVo2uoQDXqYo1pZkxEs5rgaWDXBthu9QhG0E5/JFXVRFC96NfLFL7KYE7jnCT6c+YvibT",
+	"RExmJ+I0Px59Lc5hdAoTGL0QL/jofH4yO86fwyk/myGXrK3BYMzqXGWnR0e+gudW41rJj9qoUV7oWoy1",
+	"Qc6V4JYaob998/4qSZMG9Ut8WGnrXlXy78dvzIIr+Ru9sd1fLi/eGv0L5M6Gfy8vvi1qS3l3mujuSgQ4",
+	"1xof1y7X3ufUeQ4Wl1Ycs7fkiFfy6Ob4qPulPfrUA3Rxf1SFTY8+VXHb+6O83Tg+xT1n3ITqF1h3IRdg",
+	"XTJNvp6/OBeTF8cvXpzmfxbnZ1/zkzlwPsnPzriYHJ/x57P56fx4djKbzF6cnOTi+Eyc58dns8l8MuGT",
+	"FwTSiw7tkv+Zn57Ci/mIn/OT0elZfjZ6MXt+PprzU36aPxdns4lATSHp+VYLSKYnk+M0sfUMMU2myS9c",
+	"wVho+A+elzDOdYm+VNIxnUxOzkeT49Hk+GoymdL//pncfzg0xu1KoFe9Tc2iNWwprdOGLAlvi7soxTMu",
+	"3vkj/BwZ/pRQwEbpwA0vpLgO/EhS/+a6j1F4y2ZarFj4BLE4jF6/1wCh77pgscwCgvmPGG1B2FMVpFtF",
+	"aVxOMLyZ4kVb05MGhI/cLB1ULIA87pgilC3n0zJnrapEbpQXBrhYBZ/+JOcWNotohXyMK8pXMLepqUxG",
+	"GXkJXFE0s2JLfgN95KhO4tN2Xljdpkzk5blaL5rjQgOU5qyXrZoa2wPqViyWrYhXc21mUghQj2NWA2YL",
+	"t2oLhuUGyBDzwjKhSZ6a02nkqDLyRhawAPuE0n7LLROgZMhLa7fUJtjSUEPxbRuW89q2lc3ewkz5DDeg",
+	"3S00EuI21xVQTsIVe/X2slEioh01SD1rCc6Ughys5WbVIZlpH+aFSpdhVcEdFs6IV1JRSFW8B3MD5jsk",
+	"+nFcswTo2v86zLhgIpwO+X1ecFk+AWdeKVYruKsgdyDwpGq15ErgXvQN0zmV9cS421ZjnDnDlaWCs1/H",
+	"lcBy4YqRI0VYigw3VsgZu5zHEhkePlWruYWmHu/r70w6xqlxRJEDBnqdpuF/j64Mz2F0KbYRGxYf9RsE",
+	"RLTS7nus3D+OT0q7a2oAbGFSx1SDaO1iP1F4Aqb9TfmymmZzqcS6f2wCp1cDgezhRHcT7G2OP03kgyMO",
+	"DOZ8peDwk2hI2hYzPDoafxuj8GYrZoCMe97aodehLdppE68H3mtCG7/YJ7JrDVeissHkM/nX4DTMwbPA",
+	"wafnc3RsVIql4LQu3NaIeq51Ey8f+Qi5EZRgS0A8oajELgVuQonweqO6nzNVVK0XEh98T0b4cTakC+/a",
+	"W/Wd1iTYkEawfXMFniIGvdq5AbNS5cCk99oGuPC9CqVZodUCTFMVITfamdaI/aGoNmvTFdQF5GIt511Q",
+	"OwCdiAE8yhUWyQzLl1wtwMdIQcm/o52utH7NzQIex40A8tpjf+20vi4Q6iHJANyhbBL5TdfEyt9Ipo4n",
+	"py/O/nzOZisH9slYdcjmjeELh+9ji3B+/rCpW/Se2kxbTs/BnTuijtfIt6N6x5fQm2kjKZmSYsqOT56f",
+	"Zkpwx6fsU5ZIkSXTrDEZVOXytbxphsk3Pdis29Lr5jEIWubrHZ0F/sGKXnbtF70csmBZcr+vTb9RAibC",
+	"/fACHuGIentEumV+8Ywan15CfT/FH7DFiEdJJ30DsCnr4fJmDVXnCCa3LEu4EEht2CBlc10U+jZW88Lb",
+	"FOtzzbEnpI9Z4oeZ2o99pzhu4/ELXVZkD5JEAwK0mgrJa51gVHZWguO0nFv2X+/f/DVACMcifUQTagZh",
+	"EqidPnKyBF27lM1qF2NGUGJdLBl3VC7E5b7mSX7XOm5cmik/0WCZXeq6EG3ftM00e93Pjlv2DVXfRL1d",
+	"ggEaXVixAuZY2J9TN73tymJ8IHS/cUsq47T+iatVyF/s44wNWpeSq1WsONhtVqYZCQpq/mTW3h8o2Xrq",
+	"HTitGWLUDitReTG6gt5skp8eWfX57Icp3OCsD3X057LlOX0/oqmh6Ar60dK7ON40ov/uC5oGR8Pu0w6Y",
+	"ZmTrYFDrQ15r4Cy4B4BqZ7QQTEv9XhDr01XEzlrFPPixMQinUue1z8S31XVqtwTlArRQonqKhHMIbkzR",
+	"PWIhxV3yOLMjSBdrZeuq0saB+AmE5Fer6pHevwPxukSQ1+QXdvv+sA1OZEHTydjY8F/g8oPJpYJog3cP",
+	"ne6YWFv/8lNb3/p1Izy1aChxhbTWN20JeLZxdNi5vm+o8Q0SLNC+iZXzoeZIKKvH6BrXg4jUjBm78DWg",
+	"xuoEKejLXKa06Zd+0FyAwqGOnzv1+kaMw7Ra8iHd7Pl0Cs9D/V5Db/zZlrXjNNL06u1lO4/mltCOPjFO",
+	"M5PdIvXtUjMhBY4OcPKH3ayccqIKjJMQhgCHzy1M/BGyEadm7Jms5QZhsckyBC72W9o6Fg0UdY4ZRNA7",
+	"p4fBx67MEPgfrq7eMr9g8NteB2cIAB7w2vCwzzVCCWUY6lonZ1D+Omt6BXQETwVCwZweBt/K9d5GRtSB",
+	"+9g0GsLFQYnVQxBELq7zkc5h/aVBHDs9paEdw+sHEr7WltrUkfc/vBqdnJ0zQSvWRmvJRIUWrgVlJTac",
+	"QzOCGRA8dyDSTFnt+dyofpirzbUx4I8JYeg6yn+o70oTLd0W5NsG2KddaW4jZIQQqyutBgF2u2NbZd8v",
+	"YrkW0HYPwlC8D7U6sJvps06nbQgy6aLH08do/dHoDUx9Y25Q8mTZ+5zoDtVdBIUFbe6SaSK4gxHBGYLv",
+	"q6kPHLQeGFZtC7id6G9g4L+5HPAz2slAYGOJgqr1ONRqbWv6tT/ita738FUGL81dq2tTpguBZzaX4ZLA",
+	"QZNYnb02Jw3SGHis4/AXUGBkHirpJVjLF5BSVZE7iaVe0iuNhvtk05sIcFwWA7S9oR94wcIK76dWPZnw",
+	"rjf1Y+M+RAqjIV55M+VXdNqSB58G0XNBez/gNF4xB8ZCOA0vGRgI8jBe5qWJFNAvQf0bM+rD+Kx6xi2I",
+	"uPANRpzsZDw5iRlJHhpOmJvicg87tvXwbI0Ehx2hjoJbOqFXby8t0+20ux997GU67X7dcGWz2dwN56+9",
+	"tUjSjdC8G6LGKZUYpUaYZNdRI/ptJHQ82nAji9V13U7jdj5sdo0PFoYrt7YrPYtbdjsgncaw181rfMt9",
+	"2SJJt0fX3SblUE48XCLdWqobCvcGAvh1Mfs7mBkyL6hbt6SDYkMQ9luo7T27IVPUVYgNhPzzqKMVNy4O",
+	"P7TWOzBq0wYEKoa06cYTmrJlXXJFxR2Ug/ShlKcJTaIPD0xihYiF9+zLd99/y86/nhx/Fb2hnydF/ZJq",
+	"I2wgZ5ep0PNtUIhDcEexEnXk64fxhp+0TJfSOd+xN9B+mamm5eaDihiPd/YkjLbEEtxqtdUupazkWMqD",
+	"zkn6T8hCbKAfpSVU78IoaxzIbO6PZMkAMmvCFjBLG24PydjgGPmQWKAgsR+b1azA5Ywm+7qJJDEFKWti",
+	"KMVL6F1ry5RUAu5aV4/cQiM8TtJIMM7u/jwZff1q9E8++u3Dl99M299G1+MPnybp+fF9Z8VX33wxxB30",
+	"1j8FgdgkK77pz78XBQ62S0XhZUHTpRsKhOPvb5up9L3z9U6zilvLwrBsM8/ezsoPDtjjfdQFDEiwVu1V",
+	"k3jvYVMYNpjddruGAhuN9eZa0dxgszJlVDFv5ly6sylN8wD53W2K0SWrHKhWMGZss1saIr59HdM4F9ps",
+	"FH1aGu4nSl+dbBp/qCXeBWyybL3DuTMGbjDdBvuwaLjfLT1wR7Tc4dAP36mJjg6oHqX7KgotLnvKCW3v",
+	"dlADQjwJ1skytE+X+jb2v9fplv7WGmcVmByUC3LdvanTuagzGUqVYvN49w3kzfQuyjjdpBO9MnSnv7s1",
+	"8Tu4yxyvWQwlLjZe5+gJzYddavy+2X2TlR7cno51G3HGIYs0CSYAMWr66WmYKBqMnro1iPdUNIhjd9i4",
+	"7VpfXhRv5jQYfMj9lN7X9+mnNX1+cGWnleW+FMwATZ8drnSscWpt003ufEi3+xgPBgWrh1cotDT4GOBh",
+	"JLNxQ3g5ZkiqFyEV7PsrBuoGCl2h56AUJHTE4M6BEuEiM80kIj8y5f/EwVwqr5H+Bp8fraLsyweWuVa5",
+	"Ia1dVUNFypjireOI/qm5GNhNBgdyvLIjLLtEpOfWN7SJ4HeADelQqHo9hbweKP+bEnxgYe4p5Lbd6nNF",
+	"NmKzXVrjox86/fdhyjo3EmB9ZLs1SeEyXJLGBj5V7xeGi7YNNWiQIrCDAr84CK0NRX6+it+lzc+EGenA",
+	"DhVTduSMvVJi51UYXo3+EW/p1wt/LYciP16GEg7dj6VC5t1wNc9fZvmci6J0uW2v43J8QaZnXZxo3w+d",
+	"ox64S7hNptcuUR7C/+5X3V9JDgSsvd4vGJ+n54iuzN+tC9emXtOExWFRXzsNT7PONJlxeNC3XNO0Q7xq",
+	"TzsH78w+5v7optnZvK7aw/pzzRHq6g4b9A/U1//X/3+5/lsob7bdiS25cjJv/vJSSPZRstnN8fhk/BwH",
+	"aQ2MDNBYd7wqaCRX4SYw3V3xA5jFqu1ir9UJbrJM/HuWjTv/fLEtVh/Q3wfHpTs0P+QR/7nakl5ZMNT6",
+	"Dev6f4rqkQlkz5Q8OH/ckxE2wC8vhhu/IWvaS7n/s1oHUB4h7qGc9+kO4A+leygP25N+fdicvKDRiWBQ",
+	"pO1Vv0Lh65fahnl+PxUrNF5sCFtjAL7ac23Ttx9moGAuXfvnRLgS3AjsCWSqQcETPs5UMhD3Or4YrFXy",
+	"BSt5VdHmZiadwYZGKN3peDWZZvos+OswSjd/haUEjmhnah4u9DbaQ2qM/5fK+Yk+XFJbQBsOSuCPhrbg",
+	"QuD/pbeJmQpmrz8b0W88Oc1y7mBBXREm3UCZjpew428lIdXxD3JsCODNcCn0Kt7Ujk1Mxxf74+/wpz08",
+	"zA/DfNmW5MWOIxrsg5tpyOfBy8tSzTV+7KSj6dxvdVlquvpEiR3tEEx2Mk2Ox8/Hk1BzULySyTR5Pp6M",
+	"n4fGKqVx9/83AM2ehBi8TwAA",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
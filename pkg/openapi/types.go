@@ -7,6 +7,13 @@ import (
 	"time"
 )
 
+// Defines values for AuditOutcome.
+const (
+	Denied  AuditOutcome = "denied"
+	Failure AuditOutcome = "failure"
+	Success AuditOutcome = "success"
+)
+
 // Defines values for ErrorError.
 const (
 	AccessDenied            ErrorError = "access_denied"
@@ -18,14 +25,25 @@ const (
 	InvalidScope            ErrorError = "invalid_scope"
 	MethodNotAllowed        ErrorError = "method_not_allowed"
 	NotFound                ErrorError = "not_found"
+	PreconditionFailed      ErrorError = "precondition_failed"
+	RequestEntityTooLarge   ErrorError = "request_entity_too_large"
 	ServerError             ErrorError = "server_error"
 	TemporarilyUnavailable  ErrorError = "temporarily_unavailable"
+	TooManyRequests         ErrorError = "too_many_requests"
 	UnauthorizedClient      ErrorError = "unauthorized_client"
 	UnsupportedGrantType    ErrorError = "unsupported_grant_type"
 	UnsupportedMediaType    ErrorError = "unsupported_media_type"
 	UnsupportedResponseType ErrorError = "unsupported_response_type"
 )
 
+// Defines values for OperationStatus.
+const (
+	Failed    OperationStatus = "failed"
+	Pending   OperationStatus = "pending"
+	Running   OperationStatus = "running"
+	Succeeded OperationStatus = "succeeded"
+)
+
 // Defines values for ResourceHealthStatus.
 const (
 	ResourceHealthStatusDegraded ResourceHealthStatus = "degraded"
@@ -43,8 +61,67 @@ const (
 	ResourceProvisioningStatusUnknown        ResourceProvisioningStatus = "unknown"
 )
 
+// AuditOutcome The outcome of an audited request.  Denied requests failed authentication
+// or authorization.
+type AuditOutcome string
+
+// AuditRecord A record of a mutating API request, these provide a full history of who did
+// what to a resource.
+type AuditRecord struct {
+	// Id The unique audit record identifier.
+	Id string `json:"id"`
+
+	// Issuer The identity provider that authenticated the actor.
+	Issuer *string `json:"issuer,omitempty"`
+
+	// Method The HTTP method.
+	Method string `json:"method"`
+
+	// OperationId The API operation that was requested.
+	OperationId *string `json:"operationId,omitempty"`
+
+	// OrganizationId The organization the request was scoped to.
+	OrganizationId *string `json:"organizationId,omitempty"`
+
+	// Outcome The outcome of an audited request.  Denied requests failed authentication
+	// or authorization.
+	Outcome AuditOutcome `json:"outcome"`
+
+	// Path The templated API path e.g. /api/v1/organizations/{organizationID}.
+	Path string `json:"path"`
+
+	// ProjectId The project the request was scoped to.
+	ProjectId *string `json:"projectId,omitempty"`
+
+	// RequestDigest A SHA-256 digest of the request body, with sensitive fields redacted,
+	// so that requests can be correlated without recording their content.
+	RequestDigest *string `json:"requestDigest,omitempty"`
+
+	// ResourceId The resource that was acted upon.
+	ResourceId *string `json:"resourceId,omitempty"`
+
+	// StatusCode The HTTP status code returned to the client.
+	StatusCode int `json:"statusCode"`
+
+	// Subject The actor that made the request.
+	Subject *string `json:"subject,omitempty"`
+
+	// Time The time the request was handled.
+	Time time.Time `json:"time"`
+
+	// TraceId The trace ID of the request, as returned in the X-Trace-Id header.
+	TraceId *string `json:"traceId,omitempty"`
+}
+
+// AuditRecords A list of audit records, oldest first.
+type AuditRecords = []AuditRecord
+
 // Error Generic error message, compatible with oauth2.
 type Error struct {
+	// Details Optional details of why the request failed, for example which fields
+	// failed validation.
+	Details *[]ErrorDetail `json:"details,omitempty"`
+
 	// Error A terse error string expanding on the HTTP error code. Errors are based on the OAuth 2.02 specification, but are expanded with proprietary status codes for APIs other than those specified by OAuth 2.02.
 	Error ErrorError `json:"error"`
 
@@ -55,10 +132,61 @@ type Error struct {
 // ErrorError A terse error string expanding on the HTTP error code. Errors are based on the OAuth 2.02 specification, but are expanded with proprietary status codes for APIs other than those specified by OAuth 2.02.
 type ErrorError string
 
+// ErrorDetail Detail of why part of a request was invalid.
+type ErrorDetail struct {
+	// Message A verbose, human readable, message describing the error.
+	Message string `json:"message"`
+
+	// Pointer A JSON pointer (RFC 6901) to the field within the request body that
+	// caused the error e.g. "/metadata/name".  This is omitted where the error
+	// does not relate to a request body field.
+	Pointer *string `json:"pointer,omitempty"`
+
+	// Reason A terse, machine readable, reason for the error e.g. "required",
+	// "pattern" or "maxLength".
+	Reason string `json:"reason"`
+}
+
 // KubernetesLabelValue A valid Kubernetes label value, typically used for resource names that can be
 // indexed in the database.
 type KubernetesLabelValue = string
 
+// ListMetadata Metadata returned by all paginated lists.
+type ListMetadata struct {
+	// NextPageToken An opaque token to pass as the pageToken parameter to retrieve the next
+	// page.  This is omitted on the last page.
+	NextPageToken *string `json:"nextPageToken,omitempty"`
+}
+
+// Operation A long running operation, created when a request is accepted for asynchronous
+// processing.  Poll the operation, as referenced by the Location header of the
+// accepted response, until it has succeeded or failed.
+type Operation struct {
+	// CompletionTime The time the operation succeeded or failed.
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+
+	// CreationTime The time the operation was created.
+	CreationTime time.Time `json:"creationTime"`
+
+	// Error Generic error message, compatible with oauth2.
+	Error *Error `json:"error,omitempty"`
+
+	// Id The unique operation identifier.
+	Id string `json:"id"`
+
+	// Progress An optional estimate of how complete the operation is, as a percentage.
+	Progress *int `json:"progress,omitempty"`
+
+	// Result A link to the resource that was created or modified by the operation.
+	Result *string `json:"result,omitempty"`
+
+	// Status The status of an asynchronous operation.
+	Status OperationStatus `json:"status"`
+}
+
+// OperationStatus The status of an asynchronous operation.
+type OperationStatus string
+
 // OrganizationScopedResourceReadMetadata defines model for organizationScopedResourceReadMetadata.
 type OrganizationScopedResourceReadMetadata struct {
 	// CreatedBy The user who created the resource.
@@ -99,6 +227,16 @@ type OrganizationScopedResourceReadMetadata struct {
 	Tags *TagList `json:"tags,omitempty"`
 }
 
+// PaginatedList A generic paginated list envelope.  APIs should extend this with allOf
+// and define the items as an array of a concrete type.
+type PaginatedList struct {
+	// Items A page of items.
+	Items []interface{} `json:"items"`
+
+	// Metadata Metadata returned by all paginated lists.
+	Metadata ListMetadata `json:"metadata"`
+}
+
 // ProjectScopedResourceReadMetadata defines model for projectScopedResourceReadMetadata.
 type ProjectScopedResourceReadMetadata struct {
 	// CreatedBy The user who created the resource.
@@ -247,9 +385,37 @@ type Tag struct {
 // TagList A list of tags.
 type TagList = []Tag
 
+// IdempotencyKeyParameter defines model for idempotencyKeyParameter.
+type IdempotencyKeyParameter = string
+
+// IfMatchParameter defines model for ifMatchParameter.
+type IfMatchParameter = string
+
+// LastEventIdParameter defines model for lastEventIdParameter.
+type LastEventIdParameter = string
+
+// LimitParameter defines model for limitParameter.
+type LimitParameter = int
+
+// NameFilterParameter A valid Kubernetes label value, typically used for resource names that can be
+// indexed in the database.
+type NameFilterParameter = KubernetesLabelValue
+
+// PageTokenParameter defines model for pageTokenParameter.
+type PageTokenParameter = string
+
+// ProvisioningStatusFilterParameter defines model for provisioningStatusFilterParameter.
+type ProvisioningStatusFilterParameter = []ResourceProvisioningStatus
+
+// SortParameter defines model for sortParameter.
+type SortParameter = string
+
 // TagSelectorParameter defines model for tagSelectorParameter.
 type TagSelectorParameter = []string
 
+// AuditRecordsResponse A list of audit records, oldest first.
+type AuditRecordsResponse = AuditRecords
+
 // BadRequestResponse Generic error message, compatible with oauth2.
 type BadRequestResponse = Error
 
@@ -265,5 +431,27 @@ type InternalServerErrorResponse = Error
 // NotFoundResponse Generic error message, compatible with oauth2.
 type NotFoundResponse = Error
 
+// OperationAcceptedResponse A long running operation, created when a request is accepted for asynchronous
+// processing.  Poll the operation, as referenced by the Location header of the
+// accepted response, until it has succeeded or failed.
+type OperationAcceptedResponse = Operation
+
+// OperationResponse A long running operation, created when a request is accepted for asynchronous
+// processing.  Poll the operation, as referenced by the Location header of the
+// accepted response, until it has succeeded or failed.
+type OperationResponse = Operation
+
+// PreconditionFailedResponse Generic error message, compatible with oauth2.
+type PreconditionFailedResponse = Error
+
+// RequestEntityTooLargeResponse Generic error message, compatible with oauth2.
+type RequestEntityTooLargeResponse = Error
+
+// TooManyRequestsResponse Generic error message, compatible with oauth2.
+type TooManyRequestsResponse = Error
+
 // UnauthorizedResponse Generic error message, compatible with oauth2.
 type UnauthorizedResponse = Error
+
+// UnsupportedMediaTypeResponse Generic error message, compatible with oauth2.
+type UnsupportedMediaTypeResponse = Error
@@ -52,7 +52,7 @@ func convertStatusCondition(in any) openapi.ResourceProvisioningStatus {
 	switch condition.Reason {
 	case unikornv1.ConditionReasonProvisioning:
 		return openapi.ResourceProvisioningStatusProvisioning
-	case unikornv1.ConditionReasonProvisioned:
+	case unikornv1.ConditionReasonProvisioned, unikornv1.ConditionReasonDeletionProtected:
 		return openapi.ResourceProvisioningStatusProvisioned
 	case unikornv1.ConditionReasonErrored:
 		return openapi.ResourceProvisioningStatusError
@@ -133,6 +133,13 @@ func ResourceReadMetadata(in metav1.Object, tags unikornv1.TagList) openapi.Reso
 	return out
 }
 
+// ETag returns a strong entity tag for the resource, derived from its resource
+// version, that can be returned to clients on reads.  The resource version is
+// opaque, and changes whenever the resource is modified.
+func ETag(in metav1.Object) string {
+	return `"` + in.GetResourceVersion() + `"`
+}
+
 // OrganizationScopedResourceReadMetadata extracts organization scoped metdata from a resource
 // for GET APIS.
 func OrganizationScopedResourceReadMetadata(in metav1.Object, tags unikornv1.TagList) openapi.OrganizationScopedResourceReadMetadata {
@@ -0,0 +1,402 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crud
+
+import (
+	"context"
+	"net/http"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/conversion"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Scope defines where resources are stored, and who they belong to.
+type Scope struct {
+	// Namespace is where resources are stored.
+	Namespace string
+
+	// OrganizationID, if set, scopes resources to an organization.
+	OrganizationID string
+
+	// ProjectID, if set, scopes resources to a project.
+	ProjectID string
+}
+
+// labels returns the labels that identify resources in the scope.
+func (s *Scope) labels() map[string]string {
+	labels := map[string]string{}
+
+	if s.OrganizationID != "" {
+		labels[constants.OrganizationLabel] = s.OrganizationID
+	}
+
+	if s.ProjectID != "" {
+		labels[constants.ProjectLabel] = s.ProjectID
+	}
+
+	return labels
+}
+
+// contains returns true if the resource is within the scope.
+func (s *Scope) contains(in metav1.Object) bool {
+	if in.GetNamespace() != s.Namespace {
+		return false
+	}
+
+	labels := in.GetLabels()
+
+	for key, value := range s.labels() {
+		if labels[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// List is the response returned by list operations, APIs should define their
+// list responses by extending the common paginatedList schema.
+type List[R any] struct {
+	// Items is a page of resources.
+	Items []R `json:"items"`
+
+	// Metadata contains pagination information.
+	Metadata openapi.ListMetadata `json:"metadata"`
+}
+
+// Handler implements generic create, read, update and delete handlers for a
+// custom resource type T, with list type L, that is read via the API as type R
+// and written as type W.  All operations are scoped by namespace, organization
+// and project.  Creator and modifier annotations are managed automatically.
+type Handler[T client.Object, L client.ObjectList, R, W any] struct {
+	// Client is a Kubernetes client.
+	Client client.Client
+
+	// New returns an empty resource e.g. &v1alpha1.Foo{}.
+	New func() T
+
+	// NewList returns an empty resource list e.g. &v1alpha1.FooList{}.
+	NewList func() L
+
+	// Metadata returns the generic metadata from an API write request.
+	Metadata func(in *W) *openapi.ResourceWriteMetadata
+
+	// Generate creates a resource from an API write request, with the provided
+	// object metadata.  The resource specification should be fully populated,
+	// including any tags.
+	Generate func(ctx context.Context, in *W, metadata metav1.ObjectMeta) (T, error)
+
+	// Merge copies the specification from the required resource, as returned by
+	// Generate, to the current one.  Metadata is handled by the framework.
+	Merge func(current, required T)
+
+	// Convert converts a resource to its API read type.
+	Convert func(in T) R
+
+	// Tags optionally returns the tags of a resource, for list filtering.
+	Tags func(in T) unikornv1.TagList
+
+	// RequiredAnnotations are preserved on update and must exist.
+	RequiredAnnotations []string
+
+	// OptionalAnnotations are preserved on update if they exist.
+	OptionalAnnotations []string
+}
+
+// actor returns the identity recorded in creator and modifier annotations.
+func actor(ctx context.Context) string {
+	if p, err := principal.FromContext(ctx); err == nil {
+		return p.Subject
+	}
+
+	return ""
+}
+
+// get reads a resource, ensuring it's within the scope.
+func (h *Handler[T, L, R, W]) get(ctx context.Context, scope *Scope, id string) (T, error) {
+	resource := h.New()
+
+	if err := h.Client.Get(ctx, client.ObjectKey{Namespace: scope.Namespace, Name: id}, resource); err != nil {
+		if kerrors.IsNotFound(err) {
+			return resource, errors.HTTPNotFound().WithError(err)
+		}
+
+		return resource, errors.OAuth2ServerError("unable to read resource").WithError(err)
+	}
+
+	if !scope.contains(resource) {
+		return resource, errors.HTTPNotFound()
+	}
+
+	return resource, nil
+}
+
+// list reads all resources within the scope.
+func (h *Handler[T, L, R, W]) list(ctx context.Context, scope *Scope, options ...client.ListOption) (L, []T, error) {
+	list := h.NewList()
+
+	options = append(options, client.InNamespace(scope.Namespace), client.MatchingLabels(scope.labels()))
+
+	if err := h.Client.List(ctx, list, options...); err != nil {
+		return list, nil, errors.OAuth2ServerError("unable to list resources").WithError(err)
+	}
+
+	objects, err := meta.ExtractList(list)
+	if err != nil {
+		return list, nil, errors.OAuth2ServerError("unable to extract resources").WithError(err)
+	}
+
+	items := make([]T, 0, len(objects))
+
+	for _, object := range objects {
+		if item, ok := object.(T); ok {
+			items = append(items, item)
+		}
+	}
+
+	return list, items, nil
+}
+
+// checkNameUnique returns a conflict error if another resource in the scope
+// already has the name.
+func (h *Handler[T, L, R, W]) checkNameUnique(ctx context.Context, scope *Scope, name, id string) error {
+	_, items, err := h.list(ctx, scope, client.MatchingLabels{constants.NameLabel: name})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.GetName() != id {
+			return errors.HTTPConflict()
+		}
+	}
+
+	return nil
+}
+
+// generate creates a resource from the request body.
+func (h *Handler[T, L, R, W]) generate(r *http.Request, scope *Scope, request *W) (T, error) {
+	metadata := conversion.NewObjectMetadata(h.Metadata(request), scope.Namespace, actor(r.Context()))
+
+	if scope.OrganizationID != "" {
+		metadata = metadata.WithOrganization(scope.OrganizationID)
+	}
+
+	if scope.ProjectID != "" {
+		metadata = metadata.WithProject(scope.ProjectID)
+	}
+
+	return h.Generate(r.Context(), request, metadata.Get())
+}
+
+// List lists resources within the scope, applying pagination, sorting and
+// filtering, including by tags, as defined by the options.  The whole scope is
+// read and paginated in memory, so this is safe to use with a cached client.
+func (h *Handler[T, L, R, W]) List(w http.ResponseWriter, r *http.Request, scope *Scope, options *util.ListOptions) {
+	list, items, err := h.list(r.Context(), scope, options.KubernetesListOptions()...)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	items, metadata, err := util.List(options, list, items, h.Tags)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	response := &List[R]{
+		Items:    make([]R, len(items)),
+		Metadata: *metadata,
+	}
+
+	for i := range items {
+		response.Items[i] = h.Convert(items[i])
+	}
+
+	util.WriteJSONResponse(w, r, http.StatusOK, response)
+}
+
+// Get reads a resource within the scope.
+func (h *Handler[T, L, R, W]) Get(w http.ResponseWriter, r *http.Request, scope *Scope, id string) {
+	resource, err := h.get(r.Context(), scope, id)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	util.WriteETag(w, resource)
+	util.WriteJSONResponse(w, r, http.StatusOK, h.Convert(resource))
+}
+
+// Create creates a resource within the scope from the request body.  Names must
+// be unique within the scope.
+func (h *Handler[T, L, R, W]) Create(w http.ResponseWriter, r *http.Request, scope *Scope) {
+	request := new(W)
+
+	if err := util.ReadJSONBody(r, request); err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	if err := h.checkNameUnique(r.Context(), scope, h.Metadata(request).Name, ""); err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	resource, err := h.generate(r, scope, request)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	if err := h.Client.Create(r.Context(), resource); err != nil {
+		if kerrors.IsAlreadyExists(err) {
+			errors.HandleError(w, r, errors.HTTPConflict().WithError(err))
+			return
+		}
+
+		errors.HandleError(w, r, errors.OAuth2ServerError("unable to create resource").WithError(err))
+
+		return
+	}
+
+	util.WriteETag(w, resource)
+	util.WriteJSONResponse(w, r, http.StatusCreated, h.Convert(resource))
+}
+
+// Update updates a resource within the scope from the request body.  Names must
+// be unique within the scope.  Any If-Match precondition is enforced.
+//
+//nolint:cyclop
+func (h *Handler[T, L, R, W]) Update(w http.ResponseWriter, r *http.Request, scope *Scope, id string) {
+	ctx := r.Context()
+
+	request := new(W)
+
+	if err := util.ReadJSONBody(r, request); err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	current, err := h.get(ctx, scope, id)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	if err := util.CheckIfMatch(r, current); err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	if name := h.Metadata(request).Name; name != current.GetLabels()[constants.NameLabel] {
+		if err := h.checkNameUnique(ctx, scope, name, id); err != nil {
+			errors.HandleError(w, r, err)
+			return
+		}
+	}
+
+	required, err := h.generate(r, scope, request)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	if err := conversion.UpdateObjectMetadata(required, current, h.RequiredAnnotations, h.OptionalAnnotations); err != nil {
+		errors.HandleError(w, r, errors.OAuth2ServerError("unable to update resource metadata").WithError(err))
+		return
+	}
+
+	updated, ok := current.DeepCopyObject().(T)
+	if !ok {
+		errors.HandleError(w, r, errors.OAuth2ServerError("unable to copy resource"))
+		return
+	}
+
+	updated.SetLabels(required.GetLabels())
+	updated.SetAnnotations(required.GetAnnotations())
+
+	h.Merge(updated, required)
+
+	// With a precondition, have the API server reject the update if the resource
+	// has been modified since it was checked, otherwise the last writer wins.
+	patch := client.MergeFrom(current)
+
+	if len(r.Header.Values("If-Match")) != 0 {
+		patch = client.MergeFromWithOptions(current, client.MergeFromWithOptimisticLock{})
+	}
+
+	if err := h.Client.Patch(ctx, updated, patch); err != nil {
+		if kerrors.IsConflict(err) {
+			errors.HandleError(w, r, errors.HTTPPreconditionFailed().WithError(err))
+			return
+		}
+
+		errors.HandleError(w, r, errors.OAuth2ServerError("unable to update resource").WithError(err))
+
+		return
+	}
+
+	util.WriteETag(w, updated)
+	util.WriteJSONResponse(w, r, http.StatusOK, h.Convert(updated))
+}
+
+// Delete deletes a resource within the scope.  Deletion is asynchronous, as
+// resources may have finalizers, so this returns accepted.  Any If-Match
+// precondition is enforced.
+func (h *Handler[T, L, R, W]) Delete(w http.ResponseWriter, r *http.Request, scope *Scope, id string) {
+	ctx := r.Context()
+
+	current, err := h.get(ctx, scope, id)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	options, err := util.IfMatchDelete(r, current)
+	if err != nil {
+		errors.HandleError(w, r, err)
+		return
+	}
+
+	if err := h.Client.Delete(ctx, current, options...); err != nil {
+		switch {
+		case kerrors.IsNotFound(err):
+			errors.HandleError(w, r, errors.HTTPNotFound().WithError(err))
+		case kerrors.IsConflict(err):
+			errors.HandleError(w, r, errors.HTTPPreconditionFailed().WithError(err))
+		default:
+			errors.HandleError(w, r, errors.OAuth2ServerError("unable to delete resource").WithError(err))
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
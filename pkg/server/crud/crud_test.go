@@ -0,0 +1,550 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crud_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/conversion"
+	"github.com/unikorn-cloud/core/pkg/server/crud"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const testNamespace = "test"
+
+// widgetWrite is the API write type.
+type widgetWrite struct {
+	Metadata openapi.ResourceWriteMetadata `json:"metadata"`
+	Spec     map[string]string             `json:"spec"`
+}
+
+// widgetRead is the API read type.
+type widgetRead struct {
+	Metadata openapi.ResourceReadMetadata `json:"metadata"`
+	Spec     map[string]string            `json:"spec"`
+}
+
+type handler = crud.Handler[*corev1.ConfigMap, *corev1.ConfigMapList, widgetRead, widgetWrite]
+
+func newHandler(c client.Client) *handler {
+	return &handler{
+		Client: c,
+		New: func() *corev1.ConfigMap {
+			return &corev1.ConfigMap{}
+		},
+		NewList: func() *corev1.ConfigMapList {
+			return &corev1.ConfigMapList{}
+		},
+		Metadata: func(in *widgetWrite) *openapi.ResourceWriteMetadata {
+			return &in.Metadata
+		},
+		Generate: func(ctx context.Context, in *widgetWrite, metadata metav1.ObjectMeta) (*corev1.ConfigMap, error) {
+			return &corev1.ConfigMap{
+				ObjectMeta: metadata,
+				Data:       in.Spec,
+			}, nil
+		},
+		Merge: func(current, required *corev1.ConfigMap) {
+			current.Data = required.Data
+		},
+		Convert: func(in *corev1.ConfigMap) widgetRead {
+			return widgetRead{
+				Metadata: conversion.ResourceReadMetadata(in, nil),
+				Spec:     in.Data,
+			}
+		},
+	}
+}
+
+func testScope(organizationID string) *crud.Scope {
+	return &crud.Scope{
+		Namespace:      testNamespace,
+		OrganizationID: organizationID,
+	}
+}
+
+// testWidget returns a resource as if created by the handler.
+func testWidget(id, name, organizationID string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      id,
+			Labels: map[string]string{
+				constants.NameLabel:         name,
+				constants.OrganizationLabel: organizationID,
+			},
+			Annotations: map[string]string{
+				constants.CreatorAnnotation: "alice",
+			},
+		},
+		Data: map[string]string{
+			"colour": "red",
+		},
+	}
+}
+
+func testClient(objects ...client.Object) client.WithWatch {
+	return fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objects...).Build()
+}
+
+// request creates a request as a principal, with an optional JSON body and If-Match header.
+func request(t *testing.T, method string, body any, ifMatch string) *http.Request {
+	t.Helper()
+
+	var r *http.Request
+
+	if body == nil {
+		r = httptest.NewRequest(method, "/", nil)
+	} else {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		r = httptest.NewRequest(method, "/", strings.NewReader(string(data)))
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+
+	return r.WithContext(principal.NewContext(r.Context(), &principal.Principal{Subject: "bob"}))
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) *T {
+	t.Helper()
+
+	out := new(T)
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+
+	return out
+}
+
+func writeRequest(name, colour string) *widgetWrite {
+	return &widgetWrite{
+		Metadata: openapi.ResourceWriteMetadata{
+			Name: name,
+		},
+		Spec: map[string]string{
+			"colour": colour,
+		},
+	}
+}
+
+// TestList checks lists are scoped, filtered and paginated.
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(
+		testWidget("id-0", "foo", "a"),
+		testWidget("id-1", "bar", "a"),
+		testWidget("id-2", "baz", "a"),
+		testWidget("id-3", "foo", "b"),
+	)
+
+	h := newHandler(c)
+
+	ids := func(list *crud.List[widgetRead]) []string {
+		out := make([]string, len(list.Items))
+
+		for i := range list.Items {
+			out[i] = list.Items[i].Metadata.Id
+		}
+
+		return out
+	}
+
+	list := func(options *util.ListOptions) *crud.List[widgetRead] {
+		w := httptest.NewRecorder()
+
+		h.List(w, request(t, http.MethodGet, nil, ""), testScope("a"), options)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		return decode[crud.List[widgetRead]](t, w)
+	}
+
+	options, err := util.DecodeListParams(nil, nil, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"id-0", "id-1", "id-2"}, ids(list(options)))
+	require.Equal(t, []string{"id-0"}, ids(list(options.WithNameFilter(ptr.To("foo")))))
+
+	// Pages are cut from the full list, so every item is seen.
+	var pageToken *string
+
+	var seen []string
+
+	for range 3 {
+		options, err := util.DecodeListParams(ptr.To(2), pageToken, nil)
+		require.NoError(t, err)
+
+		page := list(options)
+
+		seen = append(seen, ids(page)...)
+
+		if pageToken = page.Metadata.NextPageToken; pageToken == nil {
+			break
+		}
+	}
+
+	require.Equal(t, []string{"id-0", "id-1", "id-2"}, seen)
+}
+
+// TestGet checks resources are only visible within their scope.
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	h := newHandler(testClient(testWidget("id-0", "foo", "a")))
+
+	tests := []struct {
+		name   string
+		scope  *crud.Scope
+		id     string
+		status int
+	}{
+		{
+			name:   "Found",
+			scope:  testScope("a"),
+			id:     "id-0",
+			status: http.StatusOK,
+		},
+		{
+			name:   "WrongOrganization",
+			scope:  testScope("b"),
+			id:     "id-0",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Missing",
+			scope:  testScope("a"),
+			id:     "id-1",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+
+			h.Get(w, request(t, http.MethodGet, nil, ""), test.scope, test.id)
+
+			require.Equal(t, test.status, w.Code)
+
+			if test.status != http.StatusOK {
+				return
+			}
+
+			require.NotEmpty(t, w.Header().Get("ETag"))
+			require.Equal(t, test.id, decode[widgetRead](t, w).Metadata.Id)
+		})
+	}
+}
+
+// TestCreate checks resources are created in scope, and names are unique within
+// the scope.
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		body   any
+		scope  *crud.Scope
+		status int
+	}{
+		{
+			name:   "Created",
+			body:   writeRequest("bar", "blue"),
+			scope:  testScope("a"),
+			status: http.StatusCreated,
+		},
+		{
+			name:   "NameConflict",
+			body:   writeRequest("foo", "blue"),
+			scope:  testScope("a"),
+			status: http.StatusConflict,
+		},
+		{
+			name:   "NameInOtherScope",
+			body:   writeRequest("foo", "blue"),
+			scope:  testScope("b"),
+			status: http.StatusCreated,
+		},
+		{
+			name:   "InvalidBody",
+			body:   map[string]any{"colour": "blue"},
+			scope:  testScope("a"),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := testClient(testWidget("id-0", "foo", "a"))
+
+			w := httptest.NewRecorder()
+
+			newHandler(c).Create(w, request(t, http.MethodPost, test.body, ""), test.scope)
+
+			require.Equal(t, test.status, w.Code)
+
+			if test.status != http.StatusCreated {
+				return
+			}
+
+			response := decode[widgetRead](t, w)
+
+			created := &corev1.ConfigMap{}
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: response.Metadata.Id}, created))
+			require.Equal(t, test.scope.OrganizationID, created.Labels[constants.OrganizationLabel])
+			require.Equal(t, "bob", created.Annotations[constants.CreatorAnnotation])
+			require.Equal(t, `"`+created.ResourceVersion+`"`, w.Header().Get("ETag"))
+		})
+	}
+}
+
+// TestCreateAlreadyExists checks races with another creator are reported as conflicts.
+func TestCreateAlreadyExists(t *testing.T) {
+	t.Parallel()
+
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			return kerrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, obj.GetName())
+		},
+	}).Build()
+
+	w := httptest.NewRecorder()
+
+	newHandler(c).Create(w, request(t, http.MethodPost, writeRequest("foo", "blue"), ""), testScope("a"))
+
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+// TestUpdate checks updates preserve metadata, enforce name uniqueness and If-Match
+// preconditions.
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		id      string
+		body    any
+		ifMatch func(resourceVersion string) string
+		status  int
+	}{
+		{
+			name:   "Updated",
+			id:     "id-0",
+			body:   writeRequest("foo", "blue"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "Renamed",
+			id:     "id-0",
+			body:   writeRequest("baz", "blue"),
+			status: http.StatusOK,
+		},
+		{
+			name:   "NameConflict",
+			id:     "id-0",
+			body:   writeRequest("bar", "blue"),
+			status: http.StatusConflict,
+		},
+		{
+			name: "IfMatch",
+			id:   "id-0",
+			body: writeRequest("foo", "blue"),
+			ifMatch: func(resourceVersion string) string {
+				return `"` + resourceVersion + `"`
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "IfMatchStale",
+			id:   "id-0",
+			body: writeRequest("foo", "blue"),
+			ifMatch: func(resourceVersion string) string {
+				return `"stale"`
+			},
+			status: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "Missing",
+			id:     "id-2",
+			body:   writeRequest("foo", "blue"),
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := testClient(testWidget("id-0", "foo", "a"), testWidget("id-1", "bar", "a"))
+
+			current := &corev1.ConfigMap{}
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: "id-0"}, current))
+
+			var ifMatch string
+
+			if test.ifMatch != nil {
+				ifMatch = test.ifMatch(current.ResourceVersion)
+			}
+
+			w := httptest.NewRecorder()
+
+			newHandler(c).Update(w, request(t, http.MethodPut, test.body, ifMatch), testScope("a"), test.id)
+
+			require.Equal(t, test.status, w.Code)
+
+			updated := &corev1.ConfigMap{}
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: "id-0"}, updated))
+
+			if test.status != http.StatusOK {
+				require.Equal(t, current.ResourceVersion, updated.ResourceVersion)
+				return
+			}
+
+			body, ok := test.body.(*widgetWrite)
+			require.True(t, ok)
+
+			require.Equal(t, "blue", updated.Data["colour"])
+			require.Equal(t, body.Metadata.Name, updated.Labels[constants.NameLabel])
+			require.Equal(t, "a", updated.Labels[constants.OrganizationLabel])
+			require.Equal(t, "alice", updated.Annotations[constants.CreatorAnnotation])
+			require.Equal(t, "bob", updated.Annotations[constants.ModifierAnnotation])
+			require.Equal(t, `"`+updated.ResourceVersion+`"`, w.Header().Get("ETag"))
+		})
+	}
+}
+
+// TestUpdateRace checks modifications between the precondition check and the update
+// are reported as precondition failures.
+func TestUpdateRace(t *testing.T) {
+	t.Parallel()
+
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(testWidget("id-0", "foo", "a")).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, client client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			return kerrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, obj.GetName(), nil)
+		},
+	}).Build()
+
+	current := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: "id-0"}, current))
+
+	w := httptest.NewRecorder()
+
+	newHandler(c).Update(w, request(t, http.MethodPut, writeRequest("foo", "blue"), `"`+current.ResourceVersion+`"`), testScope("a"), "id-0")
+
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+// TestDelete checks deletes are scoped and enforce If-Match preconditions.
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		scope   *crud.Scope
+		ifMatch func(resourceVersion string) string
+		status  int
+	}{
+		{
+			name:   "Deleted",
+			scope:  testScope("a"),
+			status: http.StatusAccepted,
+		},
+		{
+			name:   "WrongOrganization",
+			scope:  testScope("b"),
+			status: http.StatusNotFound,
+		},
+		{
+			name:  "IfMatch",
+			scope: testScope("a"),
+			ifMatch: func(resourceVersion string) string {
+				return `"` + resourceVersion + `"`
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			name:  "IfMatchStale",
+			scope: testScope("a"),
+			ifMatch: func(resourceVersion string) string {
+				return `"stale"`
+			},
+			status: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := testClient(testWidget("id-0", "foo", "a"))
+
+			current := &corev1.ConfigMap{}
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: "id-0"}, current))
+
+			var ifMatch string
+
+			if test.ifMatch != nil {
+				ifMatch = test.ifMatch(current.ResourceVersion)
+			}
+
+			w := httptest.NewRecorder()
+
+			newHandler(c).Delete(w, request(t, http.MethodDelete, nil, ifMatch), test.scope, "id-0")
+
+			require.Equal(t, test.status, w.Code)
+
+			err := c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: "id-0"}, &corev1.ConfigMap{})
+
+			if test.status == http.StatusAccepted {
+				require.True(t, kerrors.IsNotFound(err))
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
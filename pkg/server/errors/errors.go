@@ -38,8 +38,11 @@ const (
 	InvalidScope            OAuth2ErrorType = "invalid_scope"
 	MethodNotAllowed        OAuth2ErrorType = "method_not_allowed"
 	NotFound                OAuth2ErrorType = "not_found"
+	PreconditionFailed      OAuth2ErrorType = "precondition_failed"
+	RequestEntityTooLarge   OAuth2ErrorType = "request_entity_too_large"
 	ServerError             OAuth2ErrorType = "server_error"
 	TemporarilyUnavailable  OAuth2ErrorType = "temporarily_unavailable"
+	TooManyRequests         OAuth2ErrorType = "too_many_requests"
 	UnauthorizedClient      OAuth2ErrorType = "unauthorized_client"
 	UnsupportedGrantType    OAuth2ErrorType = "unsupported_grant_type"
 	UnsupportedMediaType    OAuth2ErrorType = "unsupported_media_type"
@@ -55,6 +58,19 @@ type OAuth2Error struct {
 	// keep that in telemetry dats.
 	//nolint:tagliatelle
 	Description string `json:"error_description"`
+	// Details optionally describe why parts of the request were invalid.
+	Details []Detail `json:"details,omitempty"`
+}
+
+// Detail describes why part of a request was invalid, allowing clients to
+// e.g. highlight invalid form fields.
+type Detail struct {
+	// Pointer is an optional JSON pointer to a field in the request body.
+	Pointer string `json:"pointer,omitempty"`
+	// Reason is a terse, machine readable, reason e.g. "required".
+	Reason string `json:"reason"`
+	// Message is a verbose, human readable, message.
+	Message string `json:"message"`
 }
 
 var (
@@ -80,6 +96,10 @@ type Error struct {
 
 	// values are arbitrary key value pairs for logging.
 	values []any
+
+	// details are returned to the client to describe why parts of the
+	// request were invalid.
+	details []Detail
 }
 
 // newError returns a new HTTP error.
@@ -107,6 +127,36 @@ func (e *Error) WithValues(values ...any) *Error {
 	return e
 }
 
+// WithDetail augments the error with details of why part of the request was
+// invalid.  The pointer is a JSON pointer to a field in the request body, and
+// may be empty if the error doesn't relate to a specific field.
+func (e *Error) WithDetail(pointer, reason, message string) *Error {
+	return e.WithDetails(Detail{Pointer: pointer, Reason: reason, Message: message})
+}
+
+// WithDetails augments the error with details of why parts of the request were
+// invalid.
+func (e *Error) WithDetails(details ...Detail) *Error {
+	e.details = append(e.details, details...)
+
+	return e
+}
+
+// Details returns any details of why parts of the request were invalid.
+func (e *Error) Details() []Detail {
+	return e.details
+}
+
+// StatusCode returns the HTTP status code.
+func (e *Error) StatusCode() int {
+	return e.status
+}
+
+// Type returns the OAuth2 error type.
+func (e *Error) Type() OAuth2ErrorType {
+	return e.code
+}
+
 // Unwrap implements Go 1.13 errors.
 func (e *Error) Unwrap() error {
 	return ErrRequest
@@ -149,6 +199,7 @@ func (e *Error) Write(w http.ResponseWriter, r *http.Request) {
 	ge := &OAuth2Error{
 		Error:       e.code,
 		Description: e.description,
+		Details:     e.details,
 	}
 
 	body, err := json.Marshal(ge)
@@ -165,6 +216,14 @@ func (e *Error) Write(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// FromOAuth2Error reconstructs an error returned by an API, typically that of
+// another service, so that it can be propagated, remapped or wrapped.  Take care
+// when propagating errors verbatim, for example an authentication failure with
+// another service is probably a server error from the client's perspective.
+func FromOAuth2Error(status int, in *OAuth2Error) *Error {
+	return newError(status, in.Error, in.Description).WithDetails(in.Details...)
+}
+
 // HTTPForbidden is raised when a user isn't permitted to do something by RBAC.
 func HTTPForbidden(description string) *Error {
 	return newError(http.StatusForbidden, Forbidden, description)
@@ -177,13 +236,33 @@ func HTTPNotFound() *Error {
 
 // IsHTTPNotFound interrogates the error type.
 func IsHTTPNotFound(err error) bool {
+	return isHTTPStatus(err, http.StatusNotFound)
+}
+
+// IsHTTPConflict interrogates the error type.
+func IsHTTPConflict(err error) bool {
+	return isHTTPStatus(err, http.StatusConflict)
+}
+
+// IsHTTPForbidden interrogates the error type.
+func IsHTTPForbidden(err error) bool {
+	return isHTTPStatus(err, http.StatusForbidden)
+}
+
+// IsHTTPPreconditionFailed interrogates the error type.
+func IsHTTPPreconditionFailed(err error) bool {
+	return isHTTPStatus(err, http.StatusPreconditionFailed)
+}
+
+// isHTTPStatus returns true if the error is an HTTP error with the status code.
+func isHTTPStatus(err error, status int) bool {
 	httpError := &Error{}
 
 	if ok := errors.As(err, &httpError); !ok {
 		return false
 	}
 
-	if httpError.status != http.StatusNotFound {
+	if httpError.status != status {
 		return false
 	}
 
@@ -200,6 +279,35 @@ func HTTPConflict() *Error {
 	return newError(http.StatusConflict, Conflict, "the requested resource already exists")
 }
 
+// HTTPIdempotencyConflict is raised when an idempotency key is reused with a
+// different request, or while the original request is still in progress.
+func HTTPIdempotencyConflict(description string) *Error {
+	return newError(http.StatusConflict, Conflict, description)
+}
+
+// HTTPPreconditionFailed is raised when a conditional request does not match
+// the current state of a resource.
+func HTTPPreconditionFailed() *Error {
+	return newError(http.StatusPreconditionFailed, PreconditionFailed, "the resource has been modified")
+}
+
+// HTTPRequestEntityTooLarge is raised when the request body exceeds the
+// maximum size the server is willing to process.
+func HTTPRequestEntityTooLarge(description string) *Error {
+	return newError(http.StatusRequestEntityTooLarge, RequestEntityTooLarge, description)
+}
+
+// HTTPUnsupportedMediaType is raised when the request body is not in a format
+// supported by the server.
+func HTTPUnsupportedMediaType(description string) *Error {
+	return newError(http.StatusUnsupportedMediaType, UnsupportedMediaType, description)
+}
+
+// HTTPTooManyRequests is raised when a client has been rate limited.
+func HTTPTooManyRequests() *Error {
+	return newError(http.StatusTooManyRequests, TooManyRequests, "rate limit exceeded")
+}
+
 // OAuth2InvalidRequest indicates a client error.
 func OAuth2InvalidRequest(description string) *Error {
 	return newError(http.StatusBadRequest, InvalidRequest, description)
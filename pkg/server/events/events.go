@@ -0,0 +1,399 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/server/conversion"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// LastEventIDHeader is sent by clients when reconnecting to resume a stream.
+	LastEventIDHeader = "Last-Event-ID"
+
+	// DefaultHeartbeat is how often a comment is sent to keep idle connections
+	// open through proxies and load balancers.
+	DefaultHeartbeat = 15 * time.Second
+)
+
+// EventType is the type of server-sent event.
+type EventType string
+
+const (
+	// EventAdded is sent when a resource is created, or exists when the
+	// stream is started.
+	EventAdded EventType = "added"
+
+	// EventModified is sent when a resource is updated.
+	EventModified EventType = "modified"
+
+	// EventDeleted is sent when a resource is deleted, or no longer matches
+	// the stream's selector.
+	EventDeleted EventType = "deleted"
+)
+
+// Stream converts a watch on a resource type into a stream of server-sent events
+// containing resource read metadata.  The resource version of each event is used
+// as its ID, so clients can resume the stream with the Last-Event-ID header.
+// The stream runs until the request context is cancelled, typically by the client
+// disconnecting or the server shutting down, at which point clients are expected to
+// reconnect and resume.  Streams are exempt from the request timeout and server write
+// timeout, instead each write must complete within two heartbeat periods, so stalled
+// clients are disconnected.
+type Stream[T client.Object] struct {
+	// Client must support watches, the cached client passed to handlers does
+	// not, so use client.NewWithWatch.
+	Client client.WithWatch
+
+	// List is an empty list of the resource type e.g. &v1alpha1.FooList{}.
+	List client.ObjectList
+
+	// Namespace optionally restricts the watch to a namespace.
+	Namespace string
+
+	// OrganizationID restricts the stream to an organization's resources.
+	OrganizationID string
+
+	// ProjectID optionally restricts the stream to a project's resources.
+	ProjectID string
+
+	// Authorizer optionally filters resources to those the principal has
+	// permission to see.  Decisions are made per organization and project, as
+	// derived from the resource's labels, and cached for the stream's lifetime.
+	// When set, requests without a principal are rejected.
+	Authorizer authorization.Authorizer
+
+	// Permission is the permission required to read a resource, this must be
+	// set if an authorizer is.
+	Permission *authorization.Permission
+
+	// Tags optionally returns the tags for a resource.
+	Tags func(T) unikornv1.TagList
+
+	// Heartbeat overrides the default heartbeat period.
+	Heartbeat time.Duration
+}
+
+// streamer holds per request state.
+type streamer[T client.Object] struct {
+	*Stream[T]
+
+	// w is the response writer.
+	w http.ResponseWriter
+
+	// controller allows the response to be flushed.
+	controller *http.ResponseController
+
+	// heartbeat is how often a heartbeat is sent.
+	heartbeat time.Duration
+
+	// principal is the actor, if authenticated.
+	principal *principal.Principal
+
+	// decisions cache authorization decisions.
+	decisions map[string]bool
+
+	// resourceVersion is the last resource version sent to the client.
+	resourceVersion string
+}
+
+// selector returns the label selector for the stream.
+func (s *Stream[T]) selector() labels.Selector {
+	set := labels.Set{}
+
+	if s.OrganizationID != "" {
+		set[constants.OrganizationLabel] = s.OrganizationID
+	}
+
+	if s.ProjectID != "" {
+		set[constants.ProjectLabel] = s.ProjectID
+	}
+
+	return labels.SelectorFromSet(set)
+}
+
+// watch starts a watch from the resource version, if empty this sends synthetic
+// added events for all existing resources.
+func (s *streamer[T]) watch(ctx context.Context) (watch.Interface, error) {
+	options := &client.ListOptions{
+		Namespace:     s.Namespace,
+		LabelSelector: s.selector(),
+		Raw: &metav1.ListOptions{
+			ResourceVersion:     s.resourceVersion,
+			AllowWatchBookmarks: true,
+		},
+	}
+
+	return s.Client.Watch(ctx, s.List, options)
+}
+
+// allowed returns whether the principal is allowed to see the resource.
+func (s *streamer[T]) allowed(ctx context.Context, object T) bool {
+	if s.Authorizer == nil {
+		return true
+	}
+
+	objectLabels := object.GetLabels()
+
+	request := &authorization.Request{
+		Principal:      s.principal,
+		Permission:     s.Permission,
+		OrganizationID: objectLabels[constants.OrganizationLabel],
+		ProjectID:      objectLabels[constants.ProjectLabel],
+	}
+
+	key := request.OrganizationID + "/" + request.ProjectID
+
+	if allowed, ok := s.decisions[key]; ok {
+		return allowed
+	}
+
+	allowed, err := s.Authorizer.Authorize(ctx, request)
+	if err != nil {
+		// Don't cache errors, they may be transient.
+		log.FromContext(ctx).Error(err, "stream authorization failed", "organizationID", request.OrganizationID, "projectID", request.ProjectID)
+
+		return false
+	}
+
+	s.decisions[key] = allowed
+
+	return allowed
+}
+
+// extendWriteDeadline allows the next write to complete within two heartbeat
+// periods, this overrides the server's write timeout which would otherwise end
+// the stream.
+func (s *streamer[T]) extendWriteDeadline() error {
+	if err := s.controller.SetWriteDeadline(time.Now().Add(2 * s.heartbeat)); err != nil && !goerrors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// send writes data to the client and flushes it.
+func (s *streamer[T]) send(data []byte) error {
+	if err := s.extendWriteDeadline(); err != nil {
+		return err
+	}
+
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+
+	return s.controller.Flush()
+}
+
+// write sends an event to the client, an empty event type just updates the
+// client's last event ID.
+func (s *streamer[T]) write(eventType EventType, id string, data []byte) error {
+	var b strings.Builder
+
+	if eventType != "" {
+		fmt.Fprintf(&b, "event: %s\n", eventType)
+	}
+
+	fmt.Fprintf(&b, "id: %s\n", id)
+
+	if data != nil {
+		fmt.Fprintf(&b, "data: %s\n", data)
+	}
+
+	b.WriteString("\n")
+
+	return s.send([]byte(b.String()))
+}
+
+// sendHeartbeat sends a comment to keep the connection alive.
+func (s *streamer[T]) sendHeartbeat() error {
+	return s.send([]byte(": heartbeat\n\n"))
+}
+
+// handle processes a watch event.
+func (s *streamer[T]) handle(ctx context.Context, event watch.Event) error {
+	var eventType EventType
+
+	switch event.Type {
+	case watch.Added:
+		eventType = EventAdded
+	case watch.Modified:
+		eventType = EventModified
+	case watch.Deleted:
+		eventType = EventDeleted
+	case watch.Bookmark:
+	default:
+		return nil
+	}
+
+	object, ok := event.Object.(T)
+	if !ok {
+		return nil
+	}
+
+	s.resourceVersion = object.GetResourceVersion()
+
+	// Bookmarks just advance the client's resume point.
+	if eventType == "" {
+		return s.write("", s.resourceVersion, nil)
+	}
+
+	if !s.allowed(ctx, object) {
+		return nil
+	}
+
+	var tags unikornv1.TagList
+
+	if s.Tags != nil {
+		tags = s.Tags(object)
+	}
+
+	data, err := json.Marshal(conversion.ResourceReadMetadata(object, tags))
+	if err != nil {
+		return err
+	}
+
+	return s.write(eventType, s.resourceVersion, data)
+}
+
+// run streams events until the context is cancelled or an error occurs.  Watches
+// are periodically closed by the API server so are restarted from the last resource
+// version, and if that has expired, from the current state.
+func (s *streamer[T]) run(ctx context.Context, watcher watch.Interface) error {
+	defer func() {
+		watcher.Stop()
+	}()
+
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.sendHeartbeat(); err != nil {
+				return err
+			}
+		case event, ok := <-watcher.ResultChan():
+			if ok && event.Type == watch.Error {
+				err := kerrors.FromObject(event.Object)
+				if !kerrors.IsResourceExpired(err) && !kerrors.IsGone(err) {
+					return err
+				}
+
+				s.resourceVersion = ""
+				ok = false
+			}
+
+			if !ok {
+				watcher.Stop()
+
+				var err error
+
+				if watcher, err = s.watch(ctx); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := s.handle(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Stream[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	streamer := &streamer[T]{
+		Stream:          s,
+		w:               w,
+		controller:      http.NewResponseController(w),
+		heartbeat:       s.Heartbeat,
+		decisions:       map[string]bool{},
+		resourceVersion: r.Header.Get(LastEventIDHeader),
+	}
+
+	if streamer.heartbeat == 0 {
+		streamer.heartbeat = DefaultHeartbeat
+	}
+
+	if p, err := principal.FromContext(ctx); err == nil {
+		streamer.principal = p
+	}
+
+	// Without a principal every authorization decision would be made on behalf
+	// of nobody, so refuse rather than guess.
+	if s.Authorizer != nil && streamer.principal == nil {
+		errors.HandleError(w, r, errors.OAuth2AccessDenied("authentication required"))
+		return
+	}
+
+	watcher, err := streamer.watch(ctx)
+	if err != nil && streamer.resourceVersion != "" {
+		// The client may have sent garbage, or the resource version has expired,
+		// so start again with the current state.
+		streamer.resourceVersion = ""
+
+		watcher, err = streamer.watch(ctx)
+	}
+
+	if err != nil {
+		errors.HandleError(w, r, errors.OAuth2ServerError("unable to watch resources").WithError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if err := streamer.send(nil); err != nil {
+		watcher.Stop()
+
+		log.FromContext(ctx).Error(err, "unable to start event stream")
+
+		return
+	}
+
+	if err := streamer.run(ctx, watcher); err != nil {
+		log.FromContext(ctx).Error(err, "event stream terminated")
+	}
+}
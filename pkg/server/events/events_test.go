@@ -0,0 +1,403 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/events"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var errResourceVersion = goerrors.New("invalid resource version")
+
+// watchClient returns a new fake watch for every watch request, recording the
+// resource version it was started from.
+type watchClient struct {
+	client.WithWatch
+
+	lock             sync.Mutex
+	watchers         chan *watch.FakeWatcher
+	resourceVersions []string
+	fail             bool
+}
+
+func newWatchClient() *watchClient {
+	return &watchClient{
+		WithWatch: fake.NewClientBuilder().Build(),
+		watchers:  make(chan *watch.FakeWatcher, 16),
+	}
+}
+
+func (c *watchClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	options := &client.ListOptions{}
+	options.ApplyOptions(opts)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.resourceVersions = append(c.resourceVersions, options.Raw.ResourceVersion)
+
+	// Fail once, if requested, as if the resource version were garbage.
+	if c.fail {
+		c.fail = false
+
+		return nil, errResourceVersion
+	}
+
+	watcher := watch.NewFakeWithChanSize(16, false)
+
+	c.watchers <- watcher
+
+	return watcher, nil
+}
+
+// next returns the next watch started by the stream.
+func (c *watchClient) next(t *testing.T) *watch.FakeWatcher {
+	t.Helper()
+
+	select {
+	case watcher := <-c.watchers:
+		return watcher
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watch")
+	}
+
+	return nil
+}
+
+func (c *watchClient) started() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string{}, c.resourceVersions...)
+}
+
+// allowOrganization permits access to a single organization's resources.
+type allowOrganization struct {
+	organizationID string
+}
+
+func (a *allowOrganization) Authorize(ctx context.Context, request *authorization.Request) (bool, error) {
+	return request.Principal != nil && request.OrganizationID == a.organizationID, nil
+}
+
+func testResource(name, resourceVersion, organizationID string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: resourceVersion,
+			Labels: map[string]string{
+				constants.NameLabel:         name,
+				constants.OrganizationLabel: organizationID,
+			},
+		},
+	}
+}
+
+// event is a server-sent event.
+type event struct {
+	event string
+	id    string
+	data  string
+}
+
+// stream is a client side event stream.
+type stream struct {
+	response *http.Response
+	scanner  *bufio.Scanner
+}
+
+// connect starts a stream, optionally as a principal, resuming from an event ID.
+func connect(t *testing.T, handler http.Handler, p *principal.Principal, lastEventID string) *stream {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p != nil {
+			r = r.WithContext(principal.NewContext(r.Context(), p))
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+
+	t.Cleanup(server.Close)
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	if lastEventID != "" {
+		request.Header.Set(events.LastEventIDHeader, lastEventID)
+	}
+
+	response, err := server.Client().Do(request)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = response.Body.Close()
+	})
+
+	return &stream{
+		response: response,
+		scanner:  bufio.NewScanner(response.Body),
+	}
+}
+
+// next reads the next event, skipping heartbeats.
+func (s *stream) next(t *testing.T) event {
+	t.Helper()
+
+	var e event
+
+	var fields int
+
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+
+		if line == "" {
+			if fields == 0 {
+				continue
+			}
+
+			return e
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		fields++
+
+		key, value, _ := strings.Cut(line, ": ")
+
+		switch key {
+		case "event":
+			e.event = value
+		case "id":
+			e.id = value
+		case "data":
+			e.data = value
+		}
+	}
+
+	t.Fatal("stream ended unexpectedly", s.scanner.Err())
+
+	return e
+}
+
+// resourceID returns the resource ID from an event's data.
+func resourceID(t *testing.T, e event) string {
+	t.Helper()
+
+	var metadata openapi.ResourceReadMetadata
+
+	require.NoError(t, json.Unmarshal([]byte(e.data), &metadata))
+
+	return metadata.Id
+}
+
+func newStream(c *watchClient) *events.Stream[*corev1.ConfigMap] {
+	return &events.Stream[*corev1.ConfigMap]{
+		Client:    c,
+		List:      &corev1.ConfigMapList{},
+		Heartbeat: 10 * time.Millisecond,
+	}
+}
+
+// TestStream checks watch events are converted to server-sent events, and bookmarks
+// advance the client's last event ID without sending data.
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	c := newWatchClient()
+
+	s := connect(t, newStream(c), nil, "")
+
+	require.Equal(t, http.StatusOK, s.response.StatusCode)
+	require.Equal(t, "text/event-stream", s.response.Header.Get("Content-Type"))
+	require.Equal(t, "no-cache", s.response.Header.Get("Cache-Control"))
+
+	watcher := c.next(t)
+
+	watcher.Add(testResource("foo", "1", "a"))
+	watcher.Modify(testResource("foo", "2", "a"))
+	watcher.Delete(testResource("foo", "3", "a"))
+	watcher.Action(watch.Bookmark, testResource("", "4", ""))
+
+	e := s.next(t)
+	require.Equal(t, "added", e.event)
+	require.Equal(t, "1", e.id)
+	require.Equal(t, "foo", resourceID(t, e))
+
+	e = s.next(t)
+	require.Equal(t, "modified", e.event)
+	require.Equal(t, "2", e.id)
+
+	e = s.next(t)
+	require.Equal(t, "deleted", e.event)
+	require.Equal(t, "3", e.id)
+
+	require.Equal(t, event{id: "4"}, s.next(t))
+
+	require.Equal(t, []string{""}, c.started())
+}
+
+// TestStreamRestart checks watches closed by the API server are resumed from the
+// last resource version, and restarted from the current state if that has expired.
+func TestStreamRestart(t *testing.T) {
+	t.Parallel()
+
+	c := newWatchClient()
+
+	s := connect(t, newStream(c), nil, "")
+
+	watcher := c.next(t)
+	watcher.Add(testResource("foo", "1", "a"))
+
+	require.Equal(t, "1", s.next(t).id)
+
+	// Watch timeout, resume from where we left off.
+	watcher.Stop()
+
+	watcher = c.next(t)
+	watcher.Add(testResource("bar", "2", "a"))
+
+	require.Equal(t, "2", s.next(t).id)
+
+	// Resource version expired, start again.
+	watcher.Error(&metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusGone,
+		Reason: metav1.StatusReasonExpired,
+	})
+
+	watcher = c.next(t)
+	watcher.Add(testResource("foo", "5", "a"))
+
+	require.Equal(t, "5", s.next(t).id)
+
+	require.Equal(t, []string{"", "1", ""}, c.started())
+}
+
+// TestStreamResume checks clients can resume from the last event ID, and if that
+// is invalid, the stream starts from the current state.
+func TestStreamResume(t *testing.T) {
+	t.Parallel()
+
+	c := newWatchClient()
+
+	s := connect(t, newStream(c), nil, "42")
+
+	c.next(t).Add(testResource("foo", "43", "a"))
+
+	require.Equal(t, "43", s.next(t).id)
+	require.Equal(t, []string{"42"}, c.started())
+
+	c = newWatchClient()
+	c.fail = true
+
+	s = connect(t, newStream(c), nil, "garbage")
+
+	c.next(t).Add(testResource("foo", "43", "a"))
+
+	require.Equal(t, "43", s.next(t).id)
+	require.Equal(t, []string{"garbage", ""}, c.started())
+}
+
+// TestStreamAuthorization checks resources are filtered to those the principal is
+// allowed to see, and unauthenticated streams are rejected.
+func TestStreamAuthorization(t *testing.T) {
+	t.Parallel()
+
+	c := newWatchClient()
+
+	stream := newStream(c)
+	stream.Authorizer = &allowOrganization{organizationID: "a"}
+	stream.Permission = &authorization.Permission{
+		Resource: "widgets",
+		Action:   "read",
+		Scope:    authorization.ScopeOrganization,
+	}
+
+	s := connect(t, stream, nil, "")
+	require.Equal(t, http.StatusUnauthorized, s.response.StatusCode)
+
+	s = connect(t, stream, &principal.Principal{Subject: "alice"}, "")
+	require.Equal(t, http.StatusOK, s.response.StatusCode)
+
+	watcher := c.next(t)
+	watcher.Add(testResource("foo", "1", "b"))
+	watcher.Add(testResource("bar", "2", "a"))
+	watcher.Add(testResource("baz", "3", "b"))
+	watcher.Action(watch.Bookmark, testResource("", "4", ""))
+
+	e := s.next(t)
+	require.Equal(t, "added", e.event)
+	require.Equal(t, "bar", resourceID(t, e))
+
+	// Filtered events are skipped, but bookmarks still advance the stream.
+	require.Equal(t, event{id: "4"}, s.next(t))
+}
+
+// TestStreamCancel checks the stream, and its watch, end when the client goes away.
+func TestStreamCancel(t *testing.T) {
+	t.Parallel()
+
+	c := newWatchClient()
+
+	done := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+
+		newStream(c).ServeHTTP(w, r)
+	})
+
+	s := connect(t, handler, nil, "")
+
+	watcher := c.next(t)
+
+	require.NoError(t, s.response.Body.Close())
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end")
+	}
+
+	require.True(t, watcher.IsStopped())
+}
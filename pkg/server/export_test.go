@@ -0,0 +1,60 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/audit"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertificateLoader exposes the certificate loader to tests.
+type CertificateLoader = certificateLoader
+
+// MiddlewareOptions exposes the middleware options to tests.
+type MiddlewareOptions = middlewareOptions
+
+func NewCertificateLoader(ctx context.Context, reader client.Reader, key client.ObjectKey, interval time.Duration) (*CertificateLoader, error) {
+	return newCertificateLoader(ctx, reader, key, interval)
+}
+
+func (l *certificateLoader) Run(ctx context.Context) {
+	l.run(ctx)
+}
+
+func Listen(servers []*http.Server) ([]net.Listener, error) {
+	return listen(servers)
+}
+
+func GetProbeHandler(ready *atomic.Bool) http.Handler {
+	return getProbeHandler(ready)
+}
+
+func RunServers(ctx context.Context, o *Options, api *http.Server) error {
+	return run(ctx, o, api)
+}
+
+func GetHandler(f HandlerFactory, o *Options, m *MiddlewareOptions, schema *openapi.Schema, auditDispatcher *audit.Dispatcher, handler http.Handler) (http.Handler, error) {
+	return getHandler(f, o, m, nil, schema, auditDispatcher, handler)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accesslog
+
+import (
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Options defines configurable access logging options.
+type Options struct {
+	// SuccessSampleRatio is the ratio of successful requests that are logged,
+	// all other responses are always logged.  When nil all are logged.
+	SuccessSampleRatio *float64
+
+	// Headers enables logging of request headers, subject to redaction.
+	Headers bool
+}
+
+// AddFlags adds the options to the CLI flags.
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	o.SuccessSampleRatio = new(float64)
+
+	f.Float64Var(o.SuccessSampleRatio, "access-log-success-sample-ratio", 1, "Ratio of 2xx responses to log, between 0 and 1")
+	f.BoolVar(&o.Headers, "access-log-headers", false, "Log request headers, sensitive headers are redacted")
+}
+
+// sampled returns true if the response should be logged.
+func (o *Options) sampled(status int) bool {
+	if status < 200 || status >= 300 {
+		return true
+	}
+
+	if o.SuccessSampleRatio == nil {
+		return true
+	}
+
+	//nolint:gosec
+	return rand.Float64() < *o.SuccessSampleRatio
+}
+
+// clientAddress returns the client IP address.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// routeTemplate returns the templated route e.g. /api/v1/organizations/{organizationID}
+// which avoids leaking identifiers and bounds cardinality.
+func routeTemplate(r *http.Request, schema *openapi.Schema) string {
+	route, _, err := schema.FindRoute(r)
+	if err != nil {
+		return "unknown"
+	}
+
+	return route.Path
+}
+
+// headers returns the request headers with sensitive values redacted.
+func headers(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+
+	for key, values := range header {
+		name := strings.ToLower(key)
+
+		if middleware.IsRedactedHeader(name) {
+			out[name] = middleware.Redacted
+
+			continue
+		}
+
+		out[name] = strings.Join(values, ",")
+	}
+
+	return out
+}
+
+// Middleware logs a summary of each request once it has been handled.  For
+// principal and trace information to be available, this must be run after
+// OpenTelemetry, and before authentication.
+func Middleware(schema *openapi.Schema, options *Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			// Do this before the request is handled, as some handlers may
+			// modify the request.
+			route := routeTemplate(r, schema)
+
+			ctx, recorder := principal.NewRecorderContext(r.Context())
+
+			writer := middleware.NewLoggingResponseWriter(w)
+
+			next.ServeHTTP(writer, r.WithContext(ctx))
+
+			status := writer.StatusCode()
+
+			if !options.sampled(status) {
+				return
+			}
+
+			values := []any{
+				"http.method", r.Method,
+				"http.route", route,
+				"http.status", status,
+				"http.latency", time.Since(start).String(),
+				"http.size", writer.Size(),
+				"client.address", clientAddress(r),
+			}
+
+			if recorder.Principal != nil {
+				values = append(values, "principal", recorder.Principal.Subject)
+			}
+
+			if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+				values = append(values, "trace.id", spanContext.TraceID().String())
+			}
+
+			if options.Headers {
+				values = append(values, "http.headers", headers(r.Header))
+			}
+
+			log.Log.WithName("access").Info("request", values...)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accesslog_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/accesslog"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /widgets/{widgetID}:
+    parameters:
+    - name: widgetID
+      in: path
+      required: true
+      schema:
+        type: string
+    get:
+      responses:
+        '200':
+          description: ok
+`
+
+// lines captures access log lines, the global logger can only be set once, so
+// tests using this cannot be run in parallel.
+//
+//nolint:gochecknoglobals
+var lines = &logLines{}
+
+type logLines struct {
+	lock  sync.Mutex
+	lines []map[string]any
+}
+
+func (l *logLines) add(args string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	line := map[string]any{}
+
+	if err := json.Unmarshal([]byte(args), &line); err == nil {
+		l.lines = append(l.lines, line)
+	}
+}
+
+// take returns captured lines, and resets the capture.
+func (l *logLines) take() []map[string]any {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	out := l.lines
+	l.lines = nil
+
+	return out
+}
+
+func TestMain(m *testing.M) {
+	log.SetLogger(funcr.NewJSON(func(obj string) {
+		lines.add(obj)
+	}, funcr.Options{}))
+
+	os.Exit(m.Run())
+}
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// TestMiddleware checks requests are logged with the route template, principal and
+// redacted headers, and that successful requests are sampled.
+//
+//nolint:paralleltest
+func TestMiddleware(t *testing.T) {
+	testPrincipal := &principal.Principal{
+		Subject: "user@example.com",
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		status    int
+		options   accesslog.Options
+		principal *principal.Principal
+		expected  map[string]any
+		headers   map[string]any
+	}{
+		{
+			name:    "Success",
+			path:    "/widgets/foo",
+			status:  http.StatusOK,
+			options: accesslog.Options{SuccessSampleRatio: ptr.To(1.0)},
+			expected: map[string]any{
+				"http.method":    http.MethodGet,
+				"http.route":     "/widgets/{widgetID}",
+				"http.status":    float64(http.StatusOK),
+				"http.size":      float64(2),
+				"client.address": "192.0.2.1",
+			},
+		},
+		{
+			name:    "UnknownRoute",
+			path:    "/gadgets/foo",
+			status:  http.StatusNotFound,
+			options: accesslog.Options{},
+			expected: map[string]any{
+				"http.route":  "unknown",
+				"http.status": float64(http.StatusNotFound),
+			},
+		},
+		{
+			name:   "SuccessZeroValueOptions",
+			path:   "/widgets/foo",
+			status: http.StatusOK,
+			expected: map[string]any{
+				"http.status": float64(http.StatusOK),
+			},
+		},
+		{
+			name:    "SuccessNotSampled",
+			path:    "/widgets/foo",
+			status:  http.StatusOK,
+			options: accesslog.Options{SuccessSampleRatio: ptr.To(0.0)},
+		},
+		{
+			name:    "ErrorAlwaysLogged",
+			path:    "/widgets/foo",
+			status:  http.StatusInternalServerError,
+			options: accesslog.Options{},
+			expected: map[string]any{
+				"http.status": float64(http.StatusInternalServerError),
+			},
+		},
+		{
+			name:      "Principal",
+			path:      "/widgets/foo",
+			status:    http.StatusOK,
+			options:   accesslog.Options{SuccessSampleRatio: ptr.To(1.0)},
+			principal: testPrincipal,
+			expected: map[string]any{
+				"principal": testPrincipal.Subject,
+			},
+		},
+		{
+			name:    "Headers",
+			path:    "/widgets/foo",
+			status:  http.StatusOK,
+			options: accesslog.Options{SuccessSampleRatio: ptr.To(1.0), Headers: true},
+			headers: map[string]any{
+				"authorization": middleware.Redacted,
+				"cookie":        middleware.Redacted,
+				"user-agent":    "test/1.0",
+				"accept":        "application/json",
+			},
+		},
+	}
+
+	schema := testSchema(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := accesslog.Middleware(schema, &test.options)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Emulate authentication further down the chain.
+				if test.principal != nil {
+					_ = principal.NewContext(r.Context(), test.principal)
+				}
+
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte("ok"))
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			r.Header.Set("Authorization", "Bearer secret")
+			r.Header.Set("Cookie", "session=secret")
+			r.Header.Set("User-Agent", "test/1.0")
+			r.Header.Set("Accept", "application/json")
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			logged := lines.take()
+
+			if test.expected == nil && test.headers == nil {
+				require.Empty(t, logged)
+				return
+			}
+
+			require.Len(t, logged, 1)
+
+			line := logged[0]
+
+			require.Equal(t, "access", line["logger"])
+			require.Equal(t, "request", line["msg"])
+
+			for key, value := range test.expected {
+				require.Equal(t, value, line[key], key)
+			}
+
+			if test.headers == nil {
+				require.NotContains(t, line, "http.headers")
+				return
+			}
+
+			headers, ok := line["http.headers"].(map[string]any)
+			require.True(t, ok)
+
+			for key, value := range test.headers {
+				require.Equal(t, value, headers[key], key)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/trace"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+	"github.com/unikorn-cloud/core/pkg/util"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// SinkType defines where audit records are written to.
+type SinkType string
+
+const (
+	// SinkLog writes audit records to the structured log.
+	SinkLog SinkType = "log"
+
+	// SinkEvent writes audit records as Kubernetes events.
+	SinkEvent SinkType = "event"
+
+	// SinkResource writes audit records as AuditRecord custom resources,
+	// these can be queried by resource ID.
+	SinkResource SinkType = "resource"
+
+	// SinkWebhook posts audit records to an HTTP endpoint.
+	SinkWebhook SinkType = "webhook"
+)
+
+// SinksFlag wraps up a comma separated list of sinks in a flag that can be used
+// on the CLI.
+type SinksFlag struct {
+	Sinks []SinkType
+}
+
+var _ pflag.Value = &SinksFlag{}
+
+// String implements the pflag.Value interface.
+func (s *SinksFlag) String() string {
+	sinks := make([]string, len(s.Sinks))
+
+	for i, sink := range s.Sinks {
+		sinks[i] = string(sink)
+	}
+
+	return strings.Join(sinks, ",")
+}
+
+// Set implements the pflag.Value interface.
+func (s *SinksFlag) Set(in string) error {
+	valid := []SinkType{
+		SinkLog,
+		SinkEvent,
+		SinkResource,
+		SinkWebhook,
+	}
+
+	var sinks []SinkType
+
+	for _, value := range strings.Split(in, ",") {
+		if value == "" {
+			continue
+		}
+
+		sink := SinkType(value)
+
+		if !slices.Contains(valid, sink) {
+			return coreerrors.ErrParseFlag
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	s.Sinks = sinks
+
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *SinksFlag) Type() string {
+	return "strings"
+}
+
+// Options defines configurable audit options.
+type Options struct {
+	// Sinks defines where audit records are written to, if empty auditing
+	// is disabled.
+	Sinks SinksFlag
+
+	// Namespace is where events and audit record resources are created.
+	Namespace string
+
+	// MaxBodySize is the largest request body that is digested.
+	MaxBodySize int64
+
+	// WebhookURL is where the webhook sink posts audit records to.
+	WebhookURL string
+
+	// WebhookTimeout is how long to wait for the webhook to respond.
+	WebhookTimeout time.Duration
+
+	// WebhookHeaders are sent with every webhook request, typically for
+	// authentication.
+	WebhookHeaders map[string]string
+
+	// QueueSize is how many audit records may be waiting to be written to
+	// the sinks, before further records are dropped.
+	QueueSize int
+
+	// Retention is how long audit record resources are kept before they
+	// are deleted.
+	Retention time.Duration
+
+	// ReapInterval is how often audit record resources are checked for
+	// expiry.
+	ReapInterval time.Duration
+}
+
+// AddFlags adds the options to the CLI flags.
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	o.Sinks.Sinks = []SinkType{SinkLog}
+
+	f.Var(&o.Sinks, "audit-sinks", "Comma separated list of where to write audit records from [log event resource webhook], empty to disable")
+	f.StringVar(&o.Namespace, "audit-namespace", "", "Namespace to create audit events and resources in")
+	f.Int64Var(&o.MaxBodySize, "audit-max-body-size", 1<<20, "Maximum size in bytes of a request body to digest")
+	f.StringVar(&o.WebhookURL, "audit-webhook-url", "", "URL to post audit records to")
+	f.DurationVar(&o.WebhookTimeout, "audit-webhook-timeout", 5*time.Second, "How long to wait for the audit webhook to respond")
+	f.StringToStringVar(&o.WebhookHeaders, "audit-webhook-headers", nil, "Headers to send to the audit webhook, typically for authentication")
+	f.IntVar(&o.QueueSize, "audit-queue-size", 1024, "Maximum number of audit records waiting to be written, further records are dropped")
+	f.DurationVar(&o.Retention, "audit-retention", 90*24*time.Hour, "How long audit record resources are kept before being deleted")
+	f.DurationVar(&o.ReapInterval, "audit-reap-interval", time.Hour, "How often audit record resources are checked for expiry")
+}
+
+// audited returns true if the request method may mutate a resource.
+func audited(method string) bool {
+	return !slices.Contains([]string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace}, method)
+}
+
+// outcome summarizes the response status code.
+func outcome(status int) unikornv1.AuditOutcome {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return unikornv1.AuditOutcomeDenied
+	case status >= http.StatusBadRequest:
+		return unikornv1.AuditOutcomeFailure
+	}
+
+	return unikornv1.AuditOutcomeSuccess
+}
+
+// redact recursively replaces the values of sensitive JSON object keys.
+func redact(value any) any {
+	switch t := value.(type) {
+	case map[string]any:
+		for key, v := range t {
+			if middleware.IsRedactedField(key) {
+				t[key] = middleware.Redacted
+
+				continue
+			}
+
+			t[key] = redact(v)
+		}
+	case []any:
+		for i := range t {
+			t[i] = redact(t[i])
+		}
+	}
+
+	return value
+}
+
+// digest returns a SHA-256 digest of the request body.  JSON bodies have sensitive
+// fields redacted, so the digest cannot be used to confirm guesses of their values,
+// and are canonicalized so that formatting doesn't matter.
+func digest(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value any
+
+	if err := json.Unmarshal(body, &value); err == nil {
+		if data, err := json.Marshal(redact(value)); err == nil {
+			body = data
+		}
+	}
+
+	sum := sha256.Sum256(body)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// readBody reads the request body, up to the maximum size, so it can be digested,
+// then replaces it so it can be read again by the handler.  Nothing is returned
+// if the body is too large, as the digest would be incomplete.
+func readBody(r *http.Request, options *Options) []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, options.MaxBodySize+1))
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(body), r.Body),
+		Closer: r.Body,
+	}
+
+	if err != nil || int64(len(body)) > options.MaxBodySize {
+		return nil
+	}
+
+	return body
+}
+
+// resourceID returns the resource acted upon.  Where the route ends with a path
+// parameter e.g. PUT /api/v1/organizations/{organizationID}, then that identifies
+// the resource.  Otherwise the request is to a collection, typically a create, so
+// we use the ID returned in the response's metadata.
+func resourceID(route string, params map[string]string, status int, body *bytes.Buffer) string {
+	segment := route[strings.LastIndex(route, "/")+1:]
+
+	if name, ok := strings.CutPrefix(segment, "{"); ok {
+		return params[strings.TrimSuffix(name, "}")]
+	}
+
+	if status >= http.StatusMultipleChoices || body == nil {
+		return ""
+	}
+
+	var response struct {
+		Metadata struct {
+			ID string `json:"id"`
+		} `json:"metadata"`
+	}
+
+	if err := json.Unmarshal(body.Bytes(), &response); err != nil {
+		return ""
+	}
+
+	return response.Metadata.ID
+}
+
+// labels returns labels that allow audit records to be queried.  Values that
+// aren't valid labels are omitted, but are still recorded in the spec.
+func labels(spec *unikornv1.AuditRecordSpec) map[string]string {
+	values := map[string]string{
+		constants.OrganizationLabel:         spec.OrganizationID,
+		constants.ProjectLabel:              spec.ProjectID,
+		constants.ReferencedResourceIDLabel: spec.ResourceID,
+	}
+
+	labels := map[string]string{}
+
+	for key, value := range values {
+		if value != "" && len(validation.IsValidLabelValue(value)) == 0 {
+			labels[key] = value
+		}
+	}
+
+	return labels
+}
+
+// Middleware records an audit trail of all requests that may mutate a resource.
+// This must be run after authentication so the principal is known, and before
+// authorization so denied requests are recorded.  Services that perform their own
+// authentication are also supported, provided they add the principal to the
+// request context.  Records are written asynchronously by the dispatcher.
+func Middleware(schema *openapi.Schema, dispatcher *Dispatcher, options *Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if dispatcher == nil || len(dispatcher.sinks) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !audited(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			spec := unikornv1.AuditRecordSpec{
+				Time:   metav1.Now(),
+				Method: r.Method,
+				Path:   "unknown",
+			}
+
+			// Do this before the request is handled, as some handlers may
+			// modify the request.
+			route, params, err := schema.FindRoute(r)
+			if err == nil {
+				spec.Path = route.Path
+				spec.OperationID = route.Operation.OperationID
+				spec.OrganizationID = params[authorization.OrganizationIDParameter]
+				spec.ProjectID = params[authorization.ProjectIDParameter]
+			}
+
+			spec.RequestDigest = digest(readBody(r, options))
+
+			ctx, recorder := principal.NewRecorderContext(r.Context())
+
+			if p, err := principal.FromContext(ctx); err == nil {
+				recorder.Principal = p
+			}
+
+			writer := middleware.NewLoggingResponseWriter(w)
+
+			// Handlers may panic, in which case the request is recorded as a
+			// server error, as that's what recovery will respond with, then
+			// the panic is propagated.
+			defer func() {
+				recovered := recover()
+
+				spec.StatusCode = writer.StatusCode()
+
+				if recovered != nil {
+					spec.StatusCode = http.StatusInternalServerError
+				}
+
+				spec.Outcome = outcome(spec.StatusCode)
+
+				if route != nil {
+					spec.ResourceID = resourceID(route.Path, params, spec.StatusCode, writer.Body())
+				}
+
+				if recorder.Principal != nil {
+					spec.Issuer = recorder.Principal.Issuer
+					spec.Subject = recorder.Principal.Subject
+				}
+
+				if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+					spec.TraceID = spanContext.TraceID().String()
+				}
+
+				record := &unikornv1.AuditRecord{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   util.GenerateResourceID(),
+						Labels: labels(&spec),
+					},
+					Spec: spec,
+				}
+
+				dispatcher.Dispatch(ctx, record)
+
+				if recovered != nil {
+					panic(recovered)
+				}
+			}()
+
+			next.ServeHTTP(writer, r.WithContext(ctx))
+		})
+	}
+}
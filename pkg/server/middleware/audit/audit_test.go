@@ -0,0 +1,590 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/audit"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /api/v1/organizations/{organizationID}/projects/{projectID}/widgets:
+    parameters:
+    - name: organizationID
+      in: path
+      required: true
+      schema:
+        type: string
+    - name: projectID
+      in: path
+      required: true
+      schema:
+        type: string
+    get:
+      operationId: listWidgets
+      responses:
+        '200':
+          description: ok
+    post:
+      operationId: createWidget
+      responses:
+        '201':
+          description: ok
+  /api/v1/organizations/{organizationID}/projects/{projectID}/widgets/{widgetID}:
+    parameters:
+    - name: organizationID
+      in: path
+      required: true
+      schema:
+        type: string
+    - name: projectID
+      in: path
+      required: true
+      schema:
+        type: string
+    - name: widgetID
+      in: path
+      required: true
+      schema:
+        type: string
+    put:
+      operationId: updateWidget
+      responses:
+        '200':
+          description: ok
+`
+
+const (
+	collectionPath = "/api/v1/organizations/org/projects/proj/widgets"
+	resourcePath   = collectionPath + "/widget"
+)
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+func testOptions() *audit.Options {
+	return &audit.Options{
+		MaxBodySize: 1024,
+		QueueSize:   16,
+	}
+}
+
+// recordingSink remembers audit records.
+type recordingSink struct {
+	records []*unikornv1.AuditRecord
+}
+
+func (s *recordingSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	s.records = append(s.records, record)
+
+	return nil
+}
+
+// statusHandler drains the request body and responds with the status and body.
+func statusHandler(status int, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+// serve handles a single request, then flushes the dispatcher so any audit
+// records are written before they are returned.
+func serve(t *testing.T, handler http.Handler, r *http.Request, options *audit.Options) (*httptest.ResponseRecorder, []*unikornv1.AuditRecord) {
+	t.Helper()
+
+	sink := &recordingSink{}
+
+	dispatcher := audit.NewDispatcher([]audit.Sink{sink}, options)
+
+	w := httptest.NewRecorder()
+
+	audit.Middleware(testSchema(t), dispatcher, options)(handler).ServeHTTP(w, r)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	dispatcher.Run(ctx)
+
+	return w, sink.records
+}
+
+func sha256sum(in string) string {
+	sum := sha256.Sum256([]byte(in))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// TestRecord checks the request is recorded.
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodPut, resourcePath, strings.NewReader(`{}`))
+	r = r.WithContext(principal.NewContext(r.Context(), &principal.Principal{Issuer: "https://issuer", Subject: "alice"}))
+
+	w, records := serve(t, statusHandler(http.StatusOK, `{}`), r, testOptions())
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, records, 1)
+
+	record := records[0]
+	require.NotEmpty(t, record.Name)
+	require.False(t, record.Spec.Time.IsZero())
+	require.Equal(t, "https://issuer", record.Spec.Issuer)
+	require.Equal(t, "alice", record.Spec.Subject)
+	require.Equal(t, "org", record.Spec.OrganizationID)
+	require.Equal(t, "proj", record.Spec.ProjectID)
+	require.Equal(t, "updateWidget", record.Spec.OperationID)
+	require.Equal(t, http.MethodPut, record.Spec.Method)
+	require.Equal(t, "/api/v1/organizations/{organizationID}/projects/{projectID}/widgets/{widgetID}", record.Spec.Path)
+	require.Equal(t, http.StatusOK, record.Spec.StatusCode)
+
+	expected := map[string]string{
+		constants.OrganizationLabel:         "org",
+		constants.ProjectLabel:              "proj",
+		constants.ReferencedResourceIDLabel: "widget",
+	}
+
+	require.Equal(t, expected, record.Labels)
+}
+
+// TestUnaudited checks requests that cannot mutate resources are not recorded.
+func TestUnaudited(t *testing.T) {
+	t.Parallel()
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		t.Run(method, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(method, collectionPath, nil)
+
+			w, records := serve(t, statusHandler(http.StatusOK, `{}`), r, testOptions())
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Empty(t, records)
+		})
+	}
+}
+
+// TestUnknownRoute checks requests that don't match the schema are still recorded.
+func TestUnknownRoute(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodPost, "/unknown", nil)
+
+	_, records := serve(t, statusHandler(http.StatusNotFound, `{}`), r, testOptions())
+	require.Len(t, records, 1)
+	require.Equal(t, "unknown", records[0].Spec.Path)
+	require.Empty(t, records[0].Spec.ResourceID)
+	require.Empty(t, records[0].Labels)
+}
+
+// TestNoSinks checks the middleware is disabled without any sinks.
+func TestNoSinks(t *testing.T) {
+	t.Parallel()
+
+	handler := statusHandler(http.StatusOK, `{}`)
+
+	for _, dispatcher := range []*audit.Dispatcher{nil, audit.NewDispatcher(nil, testOptions())} {
+		wrapped := audit.Middleware(testSchema(t), dispatcher, testOptions())(handler)
+
+		// The request goes straight through to the handler.
+		w := httptest.NewRecorder()
+		wrapped.ServeHTTP(w, httptest.NewRequest(http.MethodPost, collectionPath, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+// TestDigest checks request bodies are canonicalized and redacted before
+// digesting, and that the handler still sees the original body.
+func TestDigest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		body   string
+		digest string
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:   "Canonical",
+			body:   "{\n  \"name\": \"foo\",\n  \"count\": 1\n}",
+			digest: sha256sum(`{"count":1,"name":"foo"}`),
+		},
+		{
+			name:   "Redacted",
+			body:   `{"name":"foo","password":"hunter2"}`,
+			digest: sha256sum(`{"name":"foo","password":"[REDACTED]"}`),
+		},
+		{
+			name:   "RedactedCaseInsensitiveSubstring",
+			body:   `{"adminPassword":"hunter2","AccessToken":"abc"}`,
+			digest: sha256sum(`{"AccessToken":"[REDACTED]","adminPassword":"[REDACTED]"}`),
+		},
+		{
+			name:   "RedactedNested",
+			body:   `{"spec":{"kubeconfig":{"clusters":[]}},"users":[{"name":"bar","privateKey":"xyz"}]}`,
+			digest: sha256sum(`{"spec":{"kubeconfig":"[REDACTED]"},"users":[{"name":"bar","privateKey":"[REDACTED]"}]}`),
+		},
+		{
+			name:   "NotJSON",
+			body:   "password=hunter2",
+			digest: sha256sum("password=hunter2"),
+		},
+		{
+			name: "TooLarge",
+			body: `{"name":"` + strings.Repeat("x", 1024) + `"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var received string
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				received = string(body)
+
+				w.WriteHeader(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(http.MethodPut, resourcePath, strings.NewReader(test.body))
+
+			_, records := serve(t, handler, r, testOptions())
+			require.Len(t, records, 1)
+			require.Equal(t, test.digest, records[0].Spec.RequestDigest)
+			require.Equal(t, test.body, received)
+		})
+	}
+}
+
+// TestDigestSecretIndependent checks the digest cannot be used to confirm guesses
+// of secret values.
+func TestDigestSecretIndependent(t *testing.T) {
+	t.Parallel()
+
+	var digests []string
+
+	for _, body := range []string{`{"name":"foo","password":"a"}`, `{"password":"b","name":"foo"}`} {
+		r := httptest.NewRequest(http.MethodPut, resourcePath, strings.NewReader(body))
+
+		_, records := serve(t, statusHandler(http.StatusOK, `{}`), r, testOptions())
+		require.Len(t, records, 1)
+
+		digests = append(digests, records[0].Spec.RequestDigest)
+	}
+
+	require.Equal(t, digests[0], digests[1])
+}
+
+// TestResourceID checks the resource is identified from the path, or from the
+// response to a create.
+func TestResourceID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		status     int
+		body       string
+		resourceID string
+	}{
+		{
+			name:       "PathParameter",
+			method:     http.MethodPut,
+			path:       resourcePath,
+			status:     http.StatusOK,
+			body:       `{"metadata":{"id":"other"}}`,
+			resourceID: "widget",
+		},
+		{
+			name:       "PathParameterFailure",
+			method:     http.MethodPut,
+			path:       resourcePath,
+			status:     http.StatusForbidden,
+			body:       `{}`,
+			resourceID: "widget",
+		},
+		{
+			name:       "Created",
+			method:     http.MethodPost,
+			path:       collectionPath,
+			status:     http.StatusCreated,
+			body:       `{"metadata":{"id":"created"},"spec":{}}`,
+			resourceID: "created",
+		},
+		{
+			name:   "CreateFailed",
+			method: http.MethodPost,
+			path:   collectionPath,
+			status: http.StatusConflict,
+			body:   `{"metadata":{"id":"existing"}}`,
+		},
+		{
+			name:   "CreatedNotJSON",
+			method: http.MethodPost,
+			path:   collectionPath,
+			status: http.StatusCreated,
+			body:   `created`,
+		},
+		{
+			name:   "CreatedNoMetadata",
+			method: http.MethodPost,
+			path:   collectionPath,
+			status: http.StatusCreated,
+			body:   `{}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(test.method, test.path, nil)
+
+			_, records := serve(t, statusHandler(test.status, test.body), r, testOptions())
+			require.Len(t, records, 1)
+			require.Equal(t, test.resourceID, records[0].Spec.ResourceID)
+
+			if test.resourceID == "" {
+				require.NotContains(t, records[0].Labels, constants.ReferencedResourceIDLabel)
+			} else {
+				require.Equal(t, test.resourceID, records[0].Labels[constants.ReferencedResourceIDLabel])
+			}
+		})
+	}
+}
+
+// TestOutcome checks the outcome is derived from the response status code.
+func TestOutcome(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status  int
+		outcome unikornv1.AuditOutcome
+	}{
+		{
+			status:  http.StatusOK,
+			outcome: unikornv1.AuditOutcomeSuccess,
+		},
+		{
+			status:  http.StatusAccepted,
+			outcome: unikornv1.AuditOutcomeSuccess,
+		},
+		{
+			status:  http.StatusNotModified,
+			outcome: unikornv1.AuditOutcomeSuccess,
+		},
+		{
+			status:  http.StatusBadRequest,
+			outcome: unikornv1.AuditOutcomeFailure,
+		},
+		{
+			status:  http.StatusUnauthorized,
+			outcome: unikornv1.AuditOutcomeDenied,
+		},
+		{
+			status:  http.StatusForbidden,
+			outcome: unikornv1.AuditOutcomeDenied,
+		},
+		{
+			status:  http.StatusNotFound,
+			outcome: unikornv1.AuditOutcomeFailure,
+		},
+		{
+			status:  http.StatusInternalServerError,
+			outcome: unikornv1.AuditOutcomeFailure,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(http.StatusText(test.status), func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPut, resourcePath, nil)
+
+			_, records := serve(t, statusHandler(test.status, `{}`), r, testOptions())
+			require.Len(t, records, 1)
+			require.Equal(t, test.status, records[0].Spec.StatusCode)
+			require.Equal(t, test.outcome, records[0].Spec.Outcome)
+		})
+	}
+}
+
+// TestPanic checks requests are recorded as server errors when the handler
+// panics, whether or not a response was started, and the panic is propagated.
+func TestPanic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		respond bool
+	}{
+		{
+			name: "BeforeResponse",
+		},
+		{
+			name:    "AfterResponse",
+			respond: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sink := &recordingSink{}
+
+			dispatcher := audit.NewDispatcher([]audit.Sink{sink}, testOptions())
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.respond {
+					w.WriteHeader(http.StatusCreated)
+				}
+
+				panic("oops")
+			})
+
+			r := httptest.NewRequest(http.MethodPost, collectionPath, strings.NewReader(`{}`))
+			r = r.WithContext(principal.NewContext(r.Context(), &principal.Principal{Issuer: "https://issuer", Subject: "alice"}))
+
+			require.PanicsWithValue(t, "oops", func() {
+				audit.Middleware(testSchema(t), dispatcher, testOptions())(handler).ServeHTTP(httptest.NewRecorder(), r)
+			})
+
+			ctx, cancel := context.WithCancel(t.Context())
+			cancel()
+
+			dispatcher.Run(ctx)
+
+			require.Len(t, sink.records, 1)
+
+			spec := sink.records[0].Spec
+			require.Equal(t, "createWidget", spec.OperationID)
+			require.Equal(t, http.StatusInternalServerError, spec.StatusCode)
+			require.Equal(t, unikornv1.AuditOutcomeFailure, spec.Outcome)
+			require.Equal(t, "alice", spec.Subject)
+			require.Empty(t, spec.ResourceID)
+		})
+	}
+}
+
+// TestSinksFlag checks sinks are parsed from the CLI.
+func TestSinksFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		in    string
+		sinks []audit.SinkType
+		out   string
+		err   bool
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:  "Single",
+			in:    "log",
+			sinks: []audit.SinkType{audit.SinkLog},
+			out:   "log",
+		},
+		{
+			name:  "Multiple",
+			in:    "log,event,resource,webhook",
+			sinks: []audit.SinkType{audit.SinkLog, audit.SinkEvent, audit.SinkResource, audit.SinkWebhook},
+			out:   "log,event,resource,webhook",
+		},
+		{
+			name:  "EmptyElements",
+			in:    ",log,,event,",
+			sinks: []audit.SinkType{audit.SinkLog, audit.SinkEvent},
+			out:   "log,event",
+		},
+		{
+			name: "Invalid",
+			in:   "log,syslog",
+			err:  true,
+		},
+		{
+			name: "CaseSensitive",
+			in:   "LOG",
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			flag := &audit.SinksFlag{
+				Sinks: []audit.SinkType{audit.SinkLog},
+			}
+
+			err := flag.Set(test.in)
+
+			if test.err {
+				require.ErrorIs(t, err, coreerrors.ErrParseFlag)
+				require.Equal(t, []audit.SinkType{audit.SinkLog}, flag.Sinks)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.sinks, flag.Sinks)
+			require.Equal(t, test.out, flag.String())
+		})
+	}
+}
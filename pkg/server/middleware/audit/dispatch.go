@@ -0,0 +1,100 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	goerrors "errors"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var (
+	// ErrQueueFull is raised when an audit record is dropped because the
+	// sinks cannot keep up.
+	ErrQueueFull = goerrors.New("audit queue full")
+)
+
+// Dispatcher writes audit records to sinks asynchronously, so slow or unavailable
+// sinks don't delay responses.  Records are buffered in a bounded queue, and are
+// dropped, with an error logged, if the queue is full.
+type Dispatcher struct {
+	// sinks are where audit records are written to.
+	sinks []Sink
+	// queue buffers records waiting to be written.
+	queue chan *unikornv1.AuditRecord
+}
+
+// NewDispatcher creates a dispatcher, records are only written once Run is called.
+func NewDispatcher(sinks []Sink, options *Options) *Dispatcher {
+	return &Dispatcher{
+		sinks: sinks,
+		queue: make(chan *unikornv1.AuditRecord, options.QueueSize),
+	}
+}
+
+// Dispatch queues a record to be written, it never blocks.
+func (d *Dispatcher) Dispatch(ctx context.Context, record *unikornv1.AuditRecord) {
+	select {
+	case d.queue <- record:
+	default:
+		log.FromContext(ctx).Error(ErrQueueFull, "dropping audit record", "id", record.Name)
+	}
+}
+
+// write writes the record to all sinks, errors are logged as there is no one
+// to report them to.
+func (d *Dispatcher) write(ctx context.Context, record *unikornv1.AuditRecord) {
+	for _, sink := range d.sinks {
+		if err := sink.Write(ctx, record); err != nil {
+			log.FromContext(ctx).Error(err, "failed to write audit record", "id", record.Name)
+		}
+	}
+}
+
+// flush writes all queued records.
+func (d *Dispatcher) flush(ctx context.Context) {
+	for {
+		select {
+		case record := <-d.queue:
+			d.write(ctx, record)
+		default:
+			return
+		}
+	}
+}
+
+// Run writes queued records to the sinks until the context is cancelled, then
+// writes any records that are still queued.  The context should only be cancelled
+// once the server has shut down, so records of in-flight requests aren't lost.
+func (d *Dispatcher) Run(ctx context.Context) {
+	// Writes are allowed to complete after cancellation.
+	writeCtx := context.WithoutCancel(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.flush(writeCtx)
+
+			return
+		case record := <-d.queue:
+			d.write(writeCtx, record)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"context"
+	goerrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/audit"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var errTest = goerrors.New("test error")
+
+// blockingSink waits to be released before writing each record.
+type blockingSink struct {
+	release chan struct{}
+	records chan *unikornv1.AuditRecord
+}
+
+func newBlockingSink() *blockingSink {
+	return &blockingSink{
+		release: make(chan struct{}),
+		records: make(chan *unikornv1.AuditRecord, 16),
+	}
+}
+
+func (s *blockingSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	<-s.release
+
+	s.records <- record
+
+	return nil
+}
+
+// failingSink always fails.
+type failingSink struct{}
+
+func (s *failingSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	return errTest
+}
+
+func testRecord(name string) *unikornv1.AuditRecord {
+	return &unikornv1.AuditRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+}
+
+// TestDispatchAsync checks dispatching doesn't wait for sinks to write records.
+func TestDispatchAsync(t *testing.T) {
+	t.Parallel()
+
+	sink := newBlockingSink()
+
+	dispatcher := audit.NewDispatcher([]audit.Sink{sink}, testOptions())
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	go dispatcher.Run(ctx)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		dispatcher.Dispatch(t.Context(), testRecord("a"))
+		dispatcher.Dispatch(t.Context(), testRecord("b"))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "dispatch blocked on a sink")
+	}
+
+	close(sink.release)
+
+	require.Equal(t, "a", (<-sink.records).Name)
+	require.Equal(t, "b", (<-sink.records).Name)
+}
+
+// TestDispatchQueueFull checks records are dropped, rather than blocking, when
+// the queue is full.
+func TestDispatchQueueFull(t *testing.T) {
+	t.Parallel()
+
+	options := testOptions()
+	options.QueueSize = 2
+
+	sink := &recordingSink{}
+
+	dispatcher := audit.NewDispatcher([]audit.Sink{sink}, options)
+
+	for _, name := range []string{"a", "b", "c"} {
+		dispatcher.Dispatch(t.Context(), testRecord(name))
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	dispatcher.Run(ctx)
+
+	require.Len(t, sink.records, 2)
+	require.Equal(t, "a", sink.records[0].Name)
+	require.Equal(t, "b", sink.records[1].Name)
+}
+
+// TestDispatchFlush checks queued records are written when the dispatcher is
+// stopped, and that sink failures don't stop other sinks being written to.
+func TestDispatchFlush(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+
+	dispatcher := audit.NewDispatcher([]audit.Sink{&failingSink{}, sink}, testOptions())
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		dispatcher.Run(ctx)
+	}()
+
+	dispatcher.Dispatch(t.Context(), testRecord("a"))
+
+	cancel()
+	<-done
+
+	// The record may have been written before or after cancellation, either
+	// way it must not be lost.
+	require.Len(t, sink.records, 1)
+	require.Equal(t, "a", sink.records[0].Name)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// convertString omits empty strings.
+func convertString(in string) *string {
+	if in == "" {
+		return nil
+	}
+
+	return &in
+}
+
+// Convert converts an audit record to its API representation.
+func Convert(in *unikornv1.AuditRecord) *openapi.AuditRecord {
+	return &openapi.AuditRecord{
+		Id:             in.Name,
+		Time:           in.Spec.Time.Time,
+		Issuer:         convertString(in.Spec.Issuer),
+		Subject:        convertString(in.Spec.Subject),
+		OrganizationId: convertString(in.Spec.OrganizationID),
+		ProjectId:      convertString(in.Spec.ProjectID),
+		OperationId:    convertString(in.Spec.OperationID),
+		Method:         in.Spec.Method,
+		Path:           in.Spec.Path,
+		ResourceId:     convertString(in.Spec.ResourceID),
+		StatusCode:     in.Spec.StatusCode,
+		Outcome:        openapi.AuditOutcome(in.Spec.Outcome),
+		RequestDigest:  convertString(in.Spec.RequestDigest),
+		TraceId:        convertString(in.Spec.TraceID),
+	}
+}
+
+// Query returns the audit history of a resource, as recorded by the resource sink,
+// oldest first.  Records must also have all the provided labels, typically the
+// organization and project from the request path, so reads can be scoped in the
+// same way as the resource.  The reader should be uncached e.g. the manager's API
+// reader, otherwise every audit record will be cached in memory.
+func Query(ctx context.Context, cli client.Reader, namespace, resourceID string, labels map[string]string) (openapi.AuditRecords, error) {
+	selector := maps.Clone(labels)
+	if selector == nil {
+		selector = map[string]string{}
+	}
+
+	selector[constants.ReferencedResourceIDLabel] = resourceID
+
+	records := &unikornv1.AuditRecordList{}
+
+	if err := cli.List(ctx, records, client.InNamespace(namespace), client.MatchingLabels(selector)); err != nil {
+		return nil, errors.OAuth2ServerError("unable to list audit records").WithError(err)
+	}
+
+	slices.SortStableFunc(records.Items, func(a, b unikornv1.AuditRecord) int {
+		return a.Spec.Time.Compare(b.Spec.Time.Time)
+	})
+
+	out := make(openapi.AuditRecords, len(records.Items))
+
+	for i := range records.Items {
+		out[i] = *Convert(&records.Items[i])
+	}
+
+	return out, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/audit"
+)
+
+// TestQuery checks a resource's history is returned oldest first, scoped by the
+// provided labels.
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	labels := func(organizationID, resourceID string) map[string]string {
+		return map[string]string{
+			constants.OrganizationLabel:         organizationID,
+			constants.ReferencedResourceIDLabel: resourceID,
+		}
+	}
+
+	other := testAuditRecord("other-namespace", now, labels("org", "widget"))
+	other.Namespace = "other"
+
+	c := testClient(t,
+		testAuditRecord("second", now.Add(-time.Minute), labels("org", "widget")),
+		testAuditRecord("first", now.Add(-time.Hour), labels("org", "widget")),
+		testAuditRecord("other-resource", now, labels("org", "gadget")),
+		testAuditRecord("other-organization", now, labels("evil", "widget")),
+		other,
+	)
+
+	tests := []struct {
+		name       string
+		resourceID string
+		labels     map[string]string
+		ids        []string
+	}{
+		{
+			name:       "Resource",
+			resourceID: "widget",
+			ids:        []string{"first", "second", "other-organization"},
+		},
+		{
+			name:       "Scoped",
+			resourceID: "widget",
+			labels:     map[string]string{constants.OrganizationLabel: "org"},
+			ids:        []string{"first", "second"},
+		},
+		{
+			name:       "NotFound",
+			resourceID: "missing",
+			ids:        []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			records, err := audit.Query(t.Context(), c, testNamespace, test.resourceID, test.labels)
+			require.NoError(t, err)
+
+			ids := make([]string, len(records))
+
+			for i := range records {
+				ids[i] = records[i].Id
+			}
+
+			require.Equal(t, test.ids, ids)
+		})
+	}
+}
+
+// TestQueryLabelsUnmodified checks the caller's labels aren't modified.
+func TestQueryLabelsUnmodified(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{constants.OrganizationLabel: "org"}
+
+	_, err := audit.Query(t.Context(), testClient(t), testNamespace, "widget", labels)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{constants.OrganizationLabel: "org"}, labels)
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var (
+	// ErrSink is raised when a sink is misconfigured.
+	ErrSink = goerrors.New("audit sink invalid")
+)
+
+const (
+	// reapPageSize bounds how many audit records are read at once when reaping.
+	reapPageSize = 500
+)
+
+// Sink writes audit records somewhere.
+type Sink interface {
+	// Write writes an audit record, the record must not be modified.
+	Write(ctx context.Context, record *unikornv1.AuditRecord) error
+}
+
+// LogSink writes audit records to the structured log.
+type LogSink struct{}
+
+// Ensure the Sink interface is implemented.
+var _ Sink = &LogSink{}
+
+// NewLogSink creates a log sink.
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+// Write implements the Sink interface.
+func (s *LogSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	values := []any{
+		"id", record.Name,
+		"time", record.Spec.Time.UTC().Format(time.RFC3339),
+		"issuer", record.Spec.Issuer,
+		"subject", record.Spec.Subject,
+		"organizationID", record.Spec.OrganizationID,
+		"projectID", record.Spec.ProjectID,
+		"operationID", record.Spec.OperationID,
+		"http.method", record.Spec.Method,
+		"http.route", record.Spec.Path,
+		"http.status", record.Spec.StatusCode,
+		"resourceID", record.Spec.ResourceID,
+		"outcome", record.Spec.Outcome,
+		"requestDigest", record.Spec.RequestDigest,
+	}
+
+	if record.Spec.TraceID != "" {
+		values = append(values, "trace.id", record.Spec.TraceID)
+	}
+
+	log.Log.WithName("audit").Info("request", values...)
+
+	return nil
+}
+
+// EventSink writes audit records as Kubernetes events.  Events are labeled in the
+// same way as audit record resources so can be selected by resource ID, but are
+// garbage collected by Kubernetes, so are not suitable for long term retention.
+type EventSink struct {
+	// client is a Kubernetes client.
+	client client.Client
+	// namespace is where events are created.
+	namespace string
+	// component identifies the service that emitted the event.
+	component string
+}
+
+// Ensure the Sink interface is implemented.
+var _ Sink = &EventSink{}
+
+// NewEventSink creates an event sink.
+func NewEventSink(client client.Client, namespace, component string) *EventSink {
+	return &EventSink{
+		client:    client,
+		namespace: namespace,
+		component: component,
+	}
+}
+
+// Write implements the Sink interface.
+func (s *EventSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	eventType := corev1.EventTypeNormal
+
+	if record.Spec.Outcome != unikornv1.AuditOutcomeSuccess {
+		eventType = corev1.EventTypeWarning
+	}
+
+	subject := record.Spec.Subject
+	if subject == "" {
+		subject = "anonymous"
+	}
+
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.namespace,
+			Name:      record.Name,
+			Labels:    record.Labels,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: unikornv1.SchemeGroupVersion.String(),
+			Kind:       unikornv1.AuditRecordKind,
+			Namespace:  s.namespace,
+			Name:       record.Name,
+		},
+		Reason:         "Audit",
+		Message:        fmt.Sprintf("%s %s %s for resource %q returned %d", subject, record.Spec.Method, record.Spec.Path, record.Spec.ResourceID, record.Spec.StatusCode),
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: s.component},
+		FirstTimestamp: record.Spec.Time,
+		LastTimestamp:  record.Spec.Time,
+		Count:          1,
+	}
+
+	return s.client.Create(ctx, event)
+}
+
+// ResourceSink writes audit records as AuditRecord custom resources, these are
+// retained until reaped and can be queried with Query.
+type ResourceSink struct {
+	// client is a Kubernetes client.
+	client client.Client
+	// namespace is where audit records are created.
+	namespace string
+}
+
+// Ensure the Sink interface is implemented.
+var _ Sink = &ResourceSink{}
+
+// NewResourceSink creates a resource sink.  The client should be uncached, as
+// audit records are numerous and only read when reaped or queried.
+func NewResourceSink(client client.Client, namespace string) *ResourceSink {
+	return &ResourceSink{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Write implements the Sink interface.
+func (s *ResourceSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	record = record.DeepCopy()
+	record.Namespace = s.namespace
+
+	return s.client.Create(ctx, record)
+}
+
+// Reap deletes audit records older than the retention period.  Only metadata is
+// read, a page at a time, so memory use is bounded however many records there are.
+func (s *ResourceSink) Reap(ctx context.Context, retention time.Duration) error {
+	records := &metav1.PartialObjectMetadataList{}
+	records.SetGroupVersionKind(unikornv1.SchemeGroupVersion.WithKind(unikornv1.AuditRecordKind + "List"))
+
+	cutoff := time.Now().Add(-retention)
+
+	continueToken := ""
+
+	for {
+		options := []client.ListOption{
+			client.InNamespace(s.namespace),
+			client.Limit(reapPageSize),
+			client.Continue(continueToken),
+		}
+
+		if err := s.client.List(ctx, records, options...); err != nil {
+			return err
+		}
+
+		for i := range records.Items {
+			record := &records.Items[i]
+
+			if record.CreationTimestamp.After(cutoff) {
+				continue
+			}
+
+			// List items don't necessarily have a type, and it's required
+			// to delete metadata only objects.
+			record.SetGroupVersionKind(unikornv1.SchemeGroupVersion.WithKind(unikornv1.AuditRecordKind))
+
+			// Another replica may have beaten us to it.
+			if err := s.client.Delete(ctx, record); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+
+		continueToken = records.Continue
+
+		if continueToken == "" {
+			return nil
+		}
+	}
+}
+
+// RunReaper periodically deletes expired audit records until the context is
+// cancelled.  It is safe to run this on every server replica.
+func (s *ResourceSink) RunReaper(ctx context.Context, options *Options) {
+	log := log.FromContext(ctx)
+
+	ticker := time.NewTicker(options.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reap(ctx, options.Retention); err != nil {
+			log.Error(err, "failed to reap audit records")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WebhookSink posts audit records to an HTTP endpoint as JSON, using the
+// auditRecord schema.
+type WebhookSink struct {
+	// client is an HTTP client.
+	client *http.Client
+	// url is where audit records are posted to.
+	url string
+	// headers are sent with every request.
+	headers map[string]string
+}
+
+// Ensure the Sink interface is implemented.
+var _ Sink = &WebhookSink{}
+
+// NewWebhookSink creates a webhook sink.
+func NewWebhookSink(url string, timeout time.Duration, headers map[string]string) *WebhookSink {
+	return &WebhookSink{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		url:     url,
+		headers: headers,
+	}
+}
+
+// Write implements the Sink interface.
+func (s *WebhookSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	body, err := json.Marshal(Convert(record))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	for key, value := range s.headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: audit webhook returned %d", coreerrors.ErrAPIStatus, response.StatusCode)
+	}
+
+	return nil
+}
+
+// NewSinks creates the sinks defined by the options.  The component identifies
+// the service in events.  The client should be uncached, see NewResourceSink.
+func NewSinks(client client.Client, component string, options *Options) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(options.Sinks.Sinks))
+
+	for _, sink := range options.Sinks.Sinks {
+		switch sink {
+		case SinkLog:
+			sinks = append(sinks, NewLogSink())
+		case SinkEvent:
+			if options.Namespace == "" {
+				return nil, fmt.Errorf("%w: event sink requires a namespace", ErrSink)
+			}
+
+			sinks = append(sinks, NewEventSink(client, options.Namespace, component))
+		case SinkResource:
+			if options.Namespace == "" {
+				return nil, fmt.Errorf("%w: resource sink requires a namespace", ErrSink)
+			}
+
+			sinks = append(sinks, NewResourceSink(client, options.Namespace))
+		case SinkWebhook:
+			if options.WebhookURL == "" {
+				return nil, fmt.Errorf("%w: webhook sink requires a URL", ErrSink)
+			}
+
+			sinks = append(sinks, NewWebhookSink(options.WebhookURL, options.WebhookTimeout, options.WebhookHeaders))
+		}
+	}
+
+	return sinks, nil
+}
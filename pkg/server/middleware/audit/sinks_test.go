@@ -0,0 +1,259 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/audit"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+func testClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, unikornv1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func testAuditRecord(name string, created time.Time, labels map[string]string) *unikornv1.AuditRecord {
+	return &unikornv1.AuditRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         testNamespace,
+			Name:              name,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: unikornv1.AuditRecordSpec{
+			Time:       metav1.NewTime(created),
+			Method:     http.MethodPut,
+			Path:       "/widgets/{widgetID}",
+			StatusCode: http.StatusOK,
+			Outcome:    unikornv1.AuditOutcomeSuccess,
+		},
+	}
+}
+
+// TestNewSinks checks sinks are created from the options, and misconfiguration
+// is reported.
+func TestNewSinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options audit.Options
+		sinks   int
+		err     bool
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name: "All",
+			options: audit.Options{
+				Sinks:      audit.SinksFlag{Sinks: []audit.SinkType{audit.SinkLog, audit.SinkEvent, audit.SinkResource, audit.SinkWebhook}},
+				Namespace:  testNamespace,
+				WebhookURL: "https://example.com",
+			},
+			sinks: 4,
+		},
+		{
+			name: "EventNoNamespace",
+			options: audit.Options{
+				Sinks: audit.SinksFlag{Sinks: []audit.SinkType{audit.SinkEvent}},
+			},
+			err: true,
+		},
+		{
+			name: "ResourceNoNamespace",
+			options: audit.Options{
+				Sinks: audit.SinksFlag{Sinks: []audit.SinkType{audit.SinkResource}},
+			},
+			err: true,
+		},
+		{
+			name: "WebhookNoURL",
+			options: audit.Options{
+				Sinks: audit.SinksFlag{Sinks: []audit.SinkType{audit.SinkWebhook}},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sinks, err := audit.NewSinks(testClient(t), "test", &test.options)
+
+			if test.err {
+				require.ErrorIs(t, err, audit.ErrSink)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, sinks, test.sinks)
+		})
+	}
+}
+
+// TestEventSink checks audit records are written as events.
+func TestEventSink(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+
+	record := testAuditRecord("event", time.Now(), map[string]string{"foo": "bar"})
+	record.Spec.Outcome = unikornv1.AuditOutcomeDenied
+
+	require.NoError(t, audit.NewEventSink(c, testNamespace, "widgets").Write(t.Context(), record))
+
+	event := &corev1.Event{}
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: "event"}, event))
+	require.Equal(t, record.Labels, event.Labels)
+	require.Equal(t, corev1.EventTypeWarning, event.Type)
+	require.Equal(t, "widgets", event.Source.Component)
+	require.Equal(t, unikornv1.AuditRecordKind, event.InvolvedObject.Kind)
+}
+
+// TestResourceSink checks audit records are written in the configured namespace,
+// without modifying the original record.
+func TestResourceSink(t *testing.T) {
+	t.Parallel()
+
+	c := testClient(t)
+
+	record := testAuditRecord("resource", time.Now(), nil)
+	record.Namespace = ""
+
+	require.NoError(t, audit.NewResourceSink(c, testNamespace).Write(t.Context(), record))
+	require.Empty(t, record.Namespace)
+
+	written := &unikornv1.AuditRecord{}
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Namespace: testNamespace, Name: "resource"}, written))
+	require.Equal(t, record.Spec.Method, written.Spec.Method)
+	require.Equal(t, record.Spec.Path, written.Spec.Path)
+	require.Equal(t, record.Spec.Time.Unix(), written.Spec.Time.Unix())
+}
+
+// TestResourceSinkReap checks only audit records older than the retention period
+// are deleted, and only in the sink's namespace.
+func TestResourceSinkReap(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	other := testAuditRecord("other", now.Add(-48*time.Hour), nil)
+	other.Namespace = "other"
+
+	c := testClient(t,
+		testAuditRecord("expired", now.Add(-48*time.Hour), nil),
+		testAuditRecord("retained", now.Add(-time.Hour), nil),
+		other,
+	)
+
+	require.NoError(t, audit.NewResourceSink(c, testNamespace).Reap(t.Context(), 24*time.Hour))
+
+	records := &unikornv1.AuditRecordList{}
+	require.NoError(t, c.List(t.Context(), records))
+
+	names := make([]string, len(records.Items))
+
+	for i := range records.Items {
+		names[i] = records.Items[i].Name
+	}
+
+	require.ElementsMatch(t, []string{"retained", "other"}, names)
+}
+
+// TestWebhookSink checks audit records are posted to the webhook.
+func TestWebhookSink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+		err    bool
+	}{
+		{
+			name:   "Success",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "Failure",
+			status: http.StatusServiceUnavailable,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var received openapi.AuditRecord
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer secret" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				w.WriteHeader(test.status)
+			}))
+
+			defer server.Close()
+
+			sink := audit.NewWebhookSink(server.URL, time.Second, map[string]string{"Authorization": "Bearer secret"})
+
+			err := sink.Write(t.Context(), testAuditRecord("webhook", time.Now(), nil))
+
+			if test.err {
+				require.ErrorIs(t, err, coreerrors.ErrAPIStatus)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "webhook", received.Id)
+			require.Equal(t, openapi.AuditOutcome(unikornv1.AuditOutcomeSuccess), received.Outcome)
+		})
+	}
+}
@@ -0,0 +1,396 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/spf13/pflag"
+	"golang.org/x/time/rate"
+
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+	"github.com/unikorn-cloud/core/pkg/util/cache"
+)
+
+const (
+	// defaultJWKSReloadInterval is the minimum time between forced reloads
+	// of signing keys if not specified.
+	defaultJWKSReloadInterval = 10 * time.Second
+)
+
+var (
+	// ErrDiscovery is raised when the issuer's OIDC discovery document is
+	// unusable.
+	ErrDiscovery = goerrors.New("oidc discovery failed")
+
+	// ErrOptions is raised when the authenticator is misconfigured.
+	ErrOptions = goerrors.New("authentication options invalid")
+)
+
+type Options struct {
+	// Issuer is the expected token issuer, and is used to discover the
+	// JWKS endpoint.
+	Issuer string
+
+	// JWKSURL overrides the JWKS endpoint discovered from the issuer.
+	JWKSURL string
+
+	// Audience, if set, must be present in a token's audience claim.
+	Audience string
+
+	// JWKSRefresh is how often signing keys are reloaded.  Tokens signed
+	// with an unknown key will trigger a reload regardless.
+	JWKSRefresh time.Duration
+
+	// JWKSReloadInterval is the minimum time between reloads triggered by
+	// tokens signed with an unknown key, so clients cannot use random key
+	// IDs to hammer the issuer.  When zero a default is used.
+	JWKSReloadInterval time.Duration
+
+	// Leeway is the allowed clock skew when validating token times.
+	Leeway time.Duration
+}
+
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.Issuer, "jwt-issuer", "", "Expected JWT issuer, used to discover signing keys")
+	f.StringVar(&o.JWKSURL, "jwt-jwks-url", "", "Override the JWKS endpoint discovered from the issuer")
+	f.StringVar(&o.Audience, "jwt-audience", "", "Expected JWT audience, if set")
+	f.DurationVar(&o.JWKSRefresh, "jwt-jwks-refresh", time.Hour, "How often to reload JWT signing keys")
+	f.DurationVar(&o.JWKSReloadInterval, "jwt-jwks-reload-interval", defaultJWKSReloadInterval, "Minimum time between JWT signing key reloads triggered by unknown keys")
+	f.DurationVar(&o.Leeway, "jwt-leeway", jwt.DefaultLeeway, "Allowed clock skew when validating JWT times")
+}
+
+// signatureAlgorithms are the token signing algorithms we accept.
+func signatureAlgorithms() []jose.SignatureAlgorithm {
+	return []jose.SignatureAlgorithm{
+		jose.RS256,
+		jose.RS384,
+		jose.RS512,
+		jose.PS256,
+		jose.PS384,
+		jose.PS512,
+		jose.ES256,
+		jose.ES384,
+		jose.ES512,
+		jose.EdDSA,
+	}
+}
+
+// claims are the token claims we care about.
+type claims struct {
+	jwt.Claims
+
+	// Scope is a space separated list of scopes as per RFC 8693.
+	Scope string `json:"scope,omitempty"`
+
+	// Scp is a list of scopes as used by some providers.
+	Scp []string `json:"scp,omitempty"`
+}
+
+// scopes returns the union of all scopes in the token.
+func (c *claims) scopes() []string {
+	return append(strings.Fields(c.Scope), c.Scp...)
+}
+
+// Authenticator verifies bearer tokens.
+type Authenticator struct {
+	// options define the authenticator behaviour.
+	options *Options
+
+	// client is used to retrieve signing keys.
+	client *http.Client
+
+	// lock protects the keys cache.
+	lock sync.Mutex
+
+	// keys caches the issuer's signing keys.
+	keys *cache.TimeoutCache[*jose.JSONWebKeySet]
+
+	// reloads limits how often keys are reloaded due to unknown keys.
+	reloads *rate.Limiter
+}
+
+// New creates a new authenticator.  The issuer is mandatory, as without it any
+// token signed by a trusted key would be accepted, regardless of who issued it.
+func New(options *Options) (*Authenticator, error) {
+	if options.Issuer == "" {
+		return nil, fmt.Errorf("%w: issuer must be specified", ErrOptions)
+	}
+
+	reloadInterval := options.JWKSReloadInterval
+	if reloadInterval == 0 {
+		reloadInterval = defaultJWKSReloadInterval
+	}
+
+	a := &Authenticator{
+		options: options,
+		client:  &http.Client{},
+		keys:    cache.New[*jose.JSONWebKeySet](options.JWKSRefresh),
+		reloads: rate.NewLimiter(rate.Every(reloadInterval), 1),
+	}
+
+	return a, nil
+}
+
+// getJSON does a GET on the URL and unmarshals the response.
+func (a *Authenticator) getJSON(ctx context.Context, url string, out any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: GET %s returned %d", coreerrors.ErrAPIStatus, url, response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// jwksURL returns the JWKS endpoint.
+func (a *Authenticator) jwksURL(ctx context.Context) (string, error) {
+	if a.options.JWKSURL != "" {
+		return a.options.JWKSURL, nil
+	}
+
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"` //nolint:tagliatelle
+	}
+
+	if err := a.getJSON(ctx, strings.TrimSuffix(a.options.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return "", err
+	}
+
+	if discovery.JWKSURI == "" {
+		return "", fmt.Errorf("%w: jwks_uri not defined", ErrDiscovery)
+	}
+
+	return discovery.JWKSURI, nil
+}
+
+// getKeys returns the issuer's signing keys, optionally forcing a reload.  Forced
+// reloads are rate limited, in which case the cached keys are returned.
+func (a *Authenticator) getKeys(ctx context.Context, reload bool) (*jose.JSONWebKeySet, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if keys, ok := a.keys.Get(); ok && (!reload || !a.reloads.Allow()) {
+		return keys, nil
+	}
+
+	url, err := a.jwksURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &jose.JSONWebKeySet{}
+
+	if err := a.getJSON(ctx, url, keys); err != nil {
+		return nil, err
+	}
+
+	a.keys.Set(keys)
+
+	return keys, nil
+}
+
+// Verify checks the token's signature and claims, returning the principal
+// on success.
+func (a *Authenticator) Verify(ctx context.Context, token string) (*principal.Principal, error) {
+	parsed, err := jwt.ParseSigned(token, signatureAlgorithms())
+	if err != nil {
+		return nil, errors.OAuth2AccessDenied("token is malformed").WithError(err)
+	}
+
+	keys, err := a.getKeys(ctx, false)
+	if err != nil {
+		return nil, errors.OAuth2ServerError("unable to get signing keys").WithError(err)
+	}
+
+	// Keys may have been rotated, so refresh if we don't recognise the signer.
+	if keyID := parsed.Headers[0].KeyID; keyID != "" && len(keys.Key(keyID)) == 0 {
+		if keys, err = a.getKeys(ctx, true); err != nil {
+			return nil, errors.OAuth2ServerError("unable to get signing keys").WithError(err)
+		}
+	}
+
+	c := &claims{}
+
+	if err := parsed.Claims(keys, c); err != nil {
+		return nil, errors.OAuth2AccessDenied("token signature invalid").WithError(err)
+	}
+
+	expected := jwt.Expected{
+		Issuer: a.options.Issuer,
+		Time:   time.Now(),
+	}
+
+	if a.options.Audience != "" {
+		expected.AnyAudience = jwt.Audience{a.options.Audience}
+	}
+
+	if err := c.ValidateWithLeeway(expected, a.options.Leeway); err != nil {
+		return nil, errors.OAuth2AccessDenied("token validation failed").WithError(err)
+	}
+
+	p := &principal.Principal{
+		Issuer:  c.Issuer,
+		Subject: c.Subject,
+		Scopes:  c.scopes(),
+		Token:   token,
+	}
+
+	return p, nil
+}
+
+// bearerToken extracts the bearer token from the request.
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", errors.OAuth2AccessDenied("authorization header missing")
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", errors.OAuth2AccessDenied("authorization header malformed")
+	}
+
+	return token, nil
+}
+
+// securityRequirement returns the security requirement for the route, this will
+// be nil if no security is required.
+func securityRequirement(route *routers.Route) openapi3.SecurityRequirement {
+	security := route.Operation.Security
+	if security == nil {
+		security = &route.Spec.Security
+	}
+
+	// Schema validation ensures there is only one.
+	if len(*security) == 0 {
+		return nil
+	}
+
+	return (*security)[0]
+}
+
+// supportedScheme returns true if the scheme is satisfied by a bearer token.
+func supportedScheme(scheme *openapi3.SecurityScheme) bool {
+	switch scheme.Type {
+	case "oauth2", "openIdConnect":
+		return true
+	case "http":
+		return strings.EqualFold(scheme.Scheme, "bearer")
+	}
+
+	return false
+}
+
+// requiredScopes returns the scopes required by the route.
+func requiredScopes(route *routers.Route, requirement openapi3.SecurityRequirement) ([]string, error) {
+	var scopes []string
+
+	for name, s := range requirement {
+		schemeRef, ok := route.Spec.Components.SecuritySchemes[name]
+		if !ok || schemeRef.Value == nil {
+			return nil, errors.OAuth2ServerError("security scheme not defined").WithValues("scheme", name)
+		}
+
+		if !supportedScheme(schemeRef.Value) {
+			return nil, errors.OAuth2ServerError("security scheme not supported").WithValues("scheme", name)
+		}
+
+		scopes = append(scopes, s...)
+	}
+
+	return scopes, nil
+}
+
+// authenticate does the heavy lifting of the middleware, returning the request
+// to pass on.
+func authenticate(r *http.Request, schema *openapi.Schema, authenticator *Authenticator) (*http.Request, error) {
+	route, _, err := schema.FindRoute(r)
+	if err != nil {
+		// Let the router handle missing routes.
+		return r, nil //nolint:nilerr
+	}
+
+	requirement := securityRequirement(route)
+	if requirement == nil {
+		return r, nil
+	}
+
+	scopes, err := requiredScopes(route, requirement)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := bearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := authenticator.Verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, scope := range scopes {
+		if !p.HasScope(scope) {
+			return nil, errors.OAuth2InvalidScope("token does not have the required scope").WithValues("scope", scope)
+		}
+	}
+
+	return r.WithContext(principal.NewContext(r.Context(), p)), nil
+}
+
+// Middleware authenticates requests against the route's security requirements
+// as defined by the schema.  Routes without requirements are passed through, and
+// routes that don't exist are left for the router to handle.
+func Middleware(schema *openapi.Schema, authenticator *Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request, err := authenticate(r, schema, authenticator)
+			if err != nil {
+				errors.HandleError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, request)
+		})
+	}
+}
@@ -0,0 +1,471 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authentication"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+)
+
+const (
+	testAudience = "unikorn"
+	testSubject  = "user@example.com"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+security:
+- oauth2: []
+paths:
+  /public:
+    get:
+      security: []
+      responses:
+        '200':
+          description: ok
+  /private:
+    get:
+      responses:
+        '200':
+          description: ok
+  /scoped:
+    get:
+      security:
+      - oauth2: [write]
+      responses:
+        '200':
+          description: ok
+  /unsupported:
+    get:
+      security:
+      - apiKey: []
+      responses:
+        '200':
+          description: ok
+components:
+  securitySchemes:
+    oauth2:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://example.com/token
+          scopes:
+            write: write things
+    apiKey:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// issuer is a fake OIDC issuer.
+type issuer struct {
+	server *httptest.Server
+
+	// lock protects the key as it's rotated by tests.
+	lock  sync.Mutex
+	key   *ecdsa.PrivateKey
+	keyID string
+
+	// jwksRequests counts how many times the keys have been fetched.
+	jwksRequests atomic.Int32
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+
+	i := &issuer{
+		keyID: "key-1",
+	}
+
+	i.rotate(t, i.keyID)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   i.server.URL,
+			"jwks_uri": i.server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		i.jwksRequests.Add(1)
+
+		i.lock.Lock()
+		defer i.lock.Unlock()
+
+		keys := jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{
+				{Key: i.key.Public(), KeyID: i.keyID, Algorithm: string(jose.ES256), Use: "sig"},
+			},
+		}
+
+		_ = json.NewEncoder(w).Encode(keys)
+	})
+
+	i.server = httptest.NewServer(mux)
+	t.Cleanup(i.server.Close)
+
+	return i
+}
+
+// rotate replaces the signing key.
+func (i *issuer) rotate(t *testing.T, keyID string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.key = key
+	i.keyID = keyID
+}
+
+// token returns a token signed by the issuer, modified by the mutator.
+func (i *issuer) token(t *testing.T, mutate func(*jwt.Claims, *map[string]any)) string {
+	t.Helper()
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return sign(t, i.key, i.keyID, i.server.URL, mutate)
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, keyID, issuer string, mutate func(*jwt.Claims, *map[string]any)) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keyID))
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	claims := &jwt.Claims{
+		Issuer:   issuer,
+		Subject:  testSubject,
+		Audience: jwt.Audience{testAudience},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+
+	private := map[string]any{}
+
+	if mutate != nil {
+		mutate(claims, &private)
+	}
+
+	token, err := jwt.Signed(signer).Claims(claims).Claims(private).Serialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func newAuthenticator(t *testing.T, i *issuer) *authentication.Authenticator {
+	t.Helper()
+
+	a, err := authentication.New(&authentication.Options{
+		Issuer:      i.server.URL,
+		Audience:    testAudience,
+		JWKSRefresh: time.Hour,
+		Leeway:      time.Second,
+	})
+	require.NoError(t, err)
+
+	return a
+}
+
+// TestNewInvalid checks the issuer is mandatory, as issuer validation is
+// skipped without one.
+func TestNewInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := authentication.New(&authentication.Options{})
+	require.ErrorIs(t, err, authentication.ErrOptions)
+}
+
+// TestMiddleware checks tokens are required and verified as defined by the
+// route's security requirements.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	i := newIssuer(t)
+
+	untrusted, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+		code          errors.OAuth2ErrorType
+	}{
+		{
+			name:   "Public",
+			path:   "/public",
+			status: http.StatusOK,
+		},
+		{
+			name:   "UnknownRoute",
+			path:   "/unknown",
+			status: http.StatusOK,
+		},
+		{
+			name:          "Valid",
+			path:          "/private",
+			authorization: "Bearer " + i.token(t, nil),
+			status:        http.StatusOK,
+		},
+		{
+			name:          "CaseInsensitiveScheme",
+			path:          "/private",
+			authorization: "bearer " + i.token(t, nil),
+			status:        http.StatusOK,
+		},
+		{
+			name:   "Missing",
+			path:   "/private",
+			status: http.StatusUnauthorized,
+			code:   errors.AccessDenied,
+		},
+		{
+			name:          "WrongScheme",
+			path:          "/private",
+			authorization: "Basic Zm9vOmJhcg==",
+			status:        http.StatusUnauthorized,
+			code:          errors.AccessDenied,
+		},
+		{
+			name:          "Malformed",
+			path:          "/private",
+			authorization: "Bearer not-a-token",
+			status:        http.StatusUnauthorized,
+			code:          errors.AccessDenied,
+		},
+		{
+			name:          "UntrustedSigner",
+			path:          "/private",
+			authorization: "Bearer " + sign(t, untrusted, i.keyID, i.server.URL, nil),
+			status:        http.StatusUnauthorized,
+			code:          errors.AccessDenied,
+		},
+		{
+			name: "WrongIssuer",
+			path: "/private",
+			authorization: "Bearer " + i.token(t, func(c *jwt.Claims, _ *map[string]any) {
+				c.Issuer = "https://evil.example.com"
+			}),
+			status: http.StatusUnauthorized,
+			code:   errors.AccessDenied,
+		},
+		{
+			name: "WrongAudience",
+			path: "/private",
+			authorization: "Bearer " + i.token(t, func(c *jwt.Claims, _ *map[string]any) {
+				c.Audience = jwt.Audience{"other"}
+			}),
+			status: http.StatusUnauthorized,
+			code:   errors.AccessDenied,
+		},
+		{
+			name: "Expired",
+			path: "/private",
+			authorization: "Bearer " + i.token(t, func(c *jwt.Claims, _ *map[string]any) {
+				c.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			}),
+			status: http.StatusUnauthorized,
+			code:   errors.AccessDenied,
+		},
+		{
+			name:          "MissingScope",
+			path:          "/scoped",
+			authorization: "Bearer " + i.token(t, nil),
+			status:        http.StatusUnauthorized,
+			code:          errors.InvalidScope,
+		},
+		{
+			name: "Scope",
+			path: "/scoped",
+			authorization: "Bearer " + i.token(t, func(_ *jwt.Claims, private *map[string]any) {
+				(*private)["scope"] = "read write"
+			}),
+			status: http.StatusOK,
+		},
+		{
+			name: "Scp",
+			path: "/scoped",
+			authorization: "Bearer " + i.token(t, func(_ *jwt.Claims, private *map[string]any) {
+				(*private)["scp"] = []string{"write"}
+			}),
+			status: http.StatusOK,
+		},
+		{
+			name:          "UnsupportedScheme",
+			path:          "/unsupported",
+			authorization: "Bearer " + i.token(t, nil),
+			status:        http.StatusInternalServerError,
+			code:          errors.ServerError,
+		},
+	}
+
+	handler := authentication.Middleware(testSchema(t), newAuthenticator(t, i))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			require.Equal(t, test.status, w.Code, w.Body.String())
+
+			if test.code != "" {
+				var e errors.OAuth2Error
+
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+				require.Equal(t, test.code, e.Error)
+			}
+		})
+	}
+}
+
+// TestPrincipal checks the verified principal is passed to the handler.
+func TestPrincipal(t *testing.T) {
+	t.Parallel()
+
+	i := newIssuer(t)
+
+	var p *principal.Principal
+
+	handler := authentication.Middleware(testSchema(t), newAuthenticator(t, i))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		p, err = principal.FromContext(r.Context())
+		require.NoError(t, err)
+	}))
+
+	token := i.token(t, func(_ *jwt.Claims, private *map[string]any) {
+		(*private)["scope"] = "read write"
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/scoped", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, p)
+	require.Equal(t, i.server.URL, p.Issuer)
+	require.Equal(t, testSubject, p.Subject)
+	require.Equal(t, []string{"read", "write"}, p.Scopes)
+	require.Equal(t, token, p.Token)
+}
+
+// TestKeyRotation checks keys are cached, and reloaded when a token is signed by
+// an unknown key.
+func TestKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	i := newIssuer(t)
+	a := newAuthenticator(t, i)
+
+	_, err := a.Verify(t.Context(), i.token(t, nil))
+	require.NoError(t, err)
+
+	_, err = a.Verify(t.Context(), i.token(t, nil))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), i.jwksRequests.Load())
+
+	i.rotate(t, "key-2")
+
+	_, err = a.Verify(t.Context(), i.token(t, nil))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), i.jwksRequests.Load())
+}
+
+// TestKeyReloadRateLimit checks tokens signed by unknown keys cannot be used to
+// repeatedly reload keys from the issuer.
+func TestKeyReloadRateLimit(t *testing.T) {
+	t.Parallel()
+
+	i := newIssuer(t)
+	a := newAuthenticator(t, i)
+
+	_, err := a.Verify(t.Context(), i.token(t, nil))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), i.jwksRequests.Load())
+
+	for n := range 10 {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		_, err = a.Verify(t.Context(), sign(t, key, "unknown-"+strconv.Itoa(n), i.server.URL, nil))
+		require.Error(t, err)
+	}
+
+	require.Equal(t, int32(2), i.jwksRequests.Load())
+
+	// Known keys still work.
+	_, err = a.Verify(t.Context(), i.token(t, nil))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), i.jwksRequests.Load())
+}
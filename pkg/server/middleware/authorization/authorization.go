@@ -0,0 +1,227 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+)
+
+const (
+	// Extension is the operation extension that declares the permission
+	// required to perform the operation.
+	Extension = "x-required-permission"
+
+	// NoPermissionExtension allows secured operations to opt out of
+	// declaring a required permission e.g. where any authenticated
+	// user may perform the operation.
+	NoPermissionExtension = "x-no-required-permission"
+
+	// OrganizationIDParameter is the path parameter that identifies an
+	// organization.
+	OrganizationIDParameter = "organizationID"
+
+	// ProjectIDParameter is the path parameter that identifies a project.
+	ProjectIDParameter = "projectID"
+)
+
+var (
+	// ErrPermission is raised when a permission declaration is invalid.
+	ErrPermission = goerrors.New("permission invalid")
+)
+
+// Scope defines at what level a permission is granted.
+type Scope string
+
+const (
+	// ScopeGlobal permissions apply to all resources.
+	ScopeGlobal Scope = "global"
+
+	// ScopeOrganization permissions apply to resources within an organization.
+	ScopeOrganization Scope = "organization"
+
+	// ScopeProject permissions apply to resources within a project.
+	ScopeProject Scope = "project"
+)
+
+// Permission is the value of the operation extension.
+type Permission struct {
+	// Resource is the type of resource being acted upon.
+	Resource string `json:"resource"`
+
+	// Action is what is being done to the resource e.g. create, read, update
+	// or delete.
+	Action string `json:"action"`
+
+	// Scope is the level at which the permission must be granted.
+	Scope Scope `json:"scope"`
+}
+
+// requiredParameters returns the path parameters required to evaluate the scope.
+func (p *Permission) requiredParameters() []string {
+	switch p.Scope {
+	case ScopeOrganization:
+		return []string{OrganizationIDParameter}
+	case ScopeProject:
+		return []string{OrganizationIDParameter, ProjectIDParameter}
+	}
+
+	return nil
+}
+
+// Validate checks the permission is well formed and can be evaluated for the
+// given path parameters.
+func (p *Permission) Validate(parameters []string) error {
+	if p.Resource == "" || p.Action == "" {
+		return fmt.Errorf("%w: resource and action must be defined", ErrPermission)
+	}
+
+	if !slices.Contains([]Scope{ScopeGlobal, ScopeOrganization, ScopeProject}, p.Scope) {
+		return fmt.Errorf("%w: scope %q not supported", ErrPermission, p.Scope)
+	}
+
+	for _, parameter := range p.requiredParameters() {
+		if !slices.Contains(parameters, parameter) {
+			return fmt.Errorf("%w: %s scope requires the %s path parameter", ErrPermission, p.Scope, parameter)
+		}
+	}
+
+	return nil
+}
+
+// PermissionFromOperation returns the permission required by an operation, or
+// nil if none is declared.
+func PermissionFromOperation(operation *openapi3.Operation) (*Permission, error) {
+	value, ok := operation.Extensions[Extension]
+	if !ok {
+		return nil, nil //nolint:nilnil
+	}
+
+	// Extensions are generically decoded, so round trip to get a typed value.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	permission := &Permission{}
+
+	if err := json.Unmarshal(data, permission); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPermission, err)
+	}
+
+	return permission, nil
+}
+
+// Request is passed to an authorizer to make a decision.
+type Request struct {
+	// Principal is the authenticated actor, this may be nil if authentication
+	// is not performed by the core authentication middleware.
+	Principal *principal.Principal
+
+	// Permission is the permission required by the operation.
+	Permission *Permission
+
+	// OrganizationID is set for organization and project scoped permissions.
+	OrganizationID string
+
+	// ProjectID is set for project scoped permissions.
+	ProjectID string
+}
+
+// Authorizer makes access decisions, typically by asking an identity service.
+type Authorizer interface {
+	// Authorize returns true if the request is permitted.
+	Authorize(ctx context.Context, request *Request) (bool, error)
+}
+
+// authorize does the heavy lifting of the middleware.
+func authorize(r *http.Request, schema *openapi.Schema, authorizer Authorizer) error {
+	route, params, err := schema.FindRoute(r)
+	if err != nil {
+		// Let the router handle missing routes.
+		return nil //nolint:nilerr
+	}
+
+	permission, err := PermissionFromOperation(route.Operation)
+	if err != nil {
+		return errors.OAuth2ServerError("unable to parse required permission").WithError(err)
+	}
+
+	if permission == nil {
+		return nil
+	}
+
+	request := &Request{
+		Permission: permission,
+	}
+
+	if p, err := principal.FromContext(r.Context()); err == nil {
+		request.Principal = p
+	}
+
+	for _, parameter := range permission.requiredParameters() {
+		value, ok := params[parameter]
+		if !ok {
+			return errors.OAuth2ServerError("required permission path parameter missing").WithValues("parameter", parameter)
+		}
+
+		switch parameter {
+		case OrganizationIDParameter:
+			request.OrganizationID = value
+		case ProjectIDParameter:
+			request.ProjectID = value
+		}
+	}
+
+	allowed, err := authorizer.Authorize(r.Context(), request)
+	if err != nil {
+		return errors.OAuth2ServerError("authorization failed").WithError(err)
+	}
+
+	if !allowed {
+		return errors.HTTPForbidden("operation is not permitted").WithValues("resource", permission.Resource, "action", permission.Action, "scope", permission.Scope)
+	}
+
+	return nil
+}
+
+// Middleware authorizes requests against the route's required permission as
+// defined by the schema.  Routes without a required permission are passed through,
+// and routes that don't exist are left for the router to handle.  This must be
+// run after authentication.
+func Middleware(schema *openapi.Schema, authorizer Authorizer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := authorize(r, schema, authorizer); err != nil {
+				errors.HandleError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
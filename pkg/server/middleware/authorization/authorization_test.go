@@ -0,0 +1,376 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization_test
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+)
+
+var errTest = goerrors.New("test error")
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /public:
+    get:
+      responses:
+        '200':
+          description: ok
+  /widgets:
+    get:
+      x-required-permission:
+        resource: widgets
+        action: read
+        scope: global
+      responses:
+        '200':
+          description: ok
+  /organizations/{organizationID}/widgets:
+    parameters:
+    - name: organizationID
+      in: path
+      required: true
+      schema:
+        type: string
+    get:
+      x-required-permission:
+        resource: widgets
+        action: read
+        scope: organization
+      responses:
+        '200':
+          description: ok
+  /organizations/{organizationID}/projects/{projectID}/widgets:
+    parameters:
+    - name: organizationID
+      in: path
+      required: true
+      schema:
+        type: string
+    - name: projectID
+      in: path
+      required: true
+      schema:
+        type: string
+    post:
+      x-required-permission:
+        resource: widgets
+        action: create
+        scope: project
+      responses:
+        '201':
+          description: created
+  /broken:
+    get:
+      x-required-permission: 42
+      responses:
+        '200':
+          description: ok
+  /missing/widgets:
+    get:
+      x-required-permission:
+        resource: widgets
+        action: read
+        scope: organization
+      responses:
+        '200':
+          description: ok
+`
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// authorizer records the last request and returns a canned response.
+type authorizer struct {
+	lock    sync.Mutex
+	request *authorization.Request
+	allowed bool
+	err     error
+}
+
+func (a *authorizer) Authorize(ctx context.Context, request *authorization.Request) (bool, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.request = request
+
+	return a.allowed, a.err
+}
+
+// TestMiddleware checks the authorizer is called with the required permission and
+// scope, and that its decision is enforced.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	testPrincipal := &principal.Principal{
+		Issuer:  "https://example.com",
+		Subject: "user@example.com",
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		principal *principal.Principal
+		allowed   bool
+		err       error
+		status    int
+		request   *authorization.Request
+	}{
+		{
+			name:   "NoPermission",
+			method: http.MethodGet,
+			path:   "/public",
+			status: http.StatusOK,
+		},
+		{
+			name:   "UnknownRoute",
+			method: http.MethodGet,
+			path:   "/unknown",
+			status: http.StatusOK,
+		},
+		{
+			name:      "GlobalAllowed",
+			method:    http.MethodGet,
+			path:      "/widgets",
+			principal: testPrincipal,
+			allowed:   true,
+			status:    http.StatusOK,
+			request: &authorization.Request{
+				Principal: testPrincipal,
+				Permission: &authorization.Permission{
+					Resource: "widgets",
+					Action:   "read",
+					Scope:    authorization.ScopeGlobal,
+				},
+			},
+		},
+		{
+			name:      "GlobalDenied",
+			method:    http.MethodGet,
+			path:      "/widgets",
+			principal: testPrincipal,
+			status:    http.StatusForbidden,
+		},
+		{
+			name:    "Unauthenticated",
+			method:  http.MethodGet,
+			path:    "/widgets",
+			allowed: true,
+			status:  http.StatusOK,
+			request: &authorization.Request{
+				Permission: &authorization.Permission{
+					Resource: "widgets",
+					Action:   "read",
+					Scope:    authorization.ScopeGlobal,
+				},
+			},
+		},
+		{
+			name:      "Organization",
+			method:    http.MethodGet,
+			path:      "/organizations/foo/widgets",
+			principal: testPrincipal,
+			allowed:   true,
+			status:    http.StatusOK,
+			request: &authorization.Request{
+				Principal: testPrincipal,
+				Permission: &authorization.Permission{
+					Resource: "widgets",
+					Action:   "read",
+					Scope:    authorization.ScopeOrganization,
+				},
+				OrganizationID: "foo",
+			},
+		},
+		{
+			name:      "Project",
+			method:    http.MethodPost,
+			path:      "/organizations/foo/projects/bar/widgets",
+			principal: testPrincipal,
+			allowed:   true,
+			status:    http.StatusOK,
+			request: &authorization.Request{
+				Principal: testPrincipal,
+				Permission: &authorization.Permission{
+					Resource: "widgets",
+					Action:   "create",
+					Scope:    authorization.ScopeProject,
+				},
+				OrganizationID: "foo",
+				ProjectID:      "bar",
+			},
+		},
+		{
+			name:      "AuthorizerError",
+			method:    http.MethodGet,
+			path:      "/widgets",
+			principal: testPrincipal,
+			allowed:   true,
+			err:       errTest,
+			status:    http.StatusInternalServerError,
+		},
+		{
+			name:      "InvalidExtension",
+			method:    http.MethodGet,
+			path:      "/broken",
+			principal: testPrincipal,
+			allowed:   true,
+			status:    http.StatusInternalServerError,
+		},
+		{
+			name:      "MissingParameter",
+			method:    http.MethodGet,
+			path:      "/missing/widgets",
+			principal: testPrincipal,
+			allowed:   true,
+			status:    http.StatusInternalServerError,
+		},
+	}
+
+	schema := testSchema(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := &authorizer{
+				allowed: test.allowed,
+				err:     test.err,
+			}
+
+			handler := authorization.Middleware(schema, a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest(test.method, test.path, nil)
+
+			if test.principal != nil {
+				r = r.WithContext(principal.NewContext(r.Context(), test.principal))
+			}
+
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			require.Equal(t, test.status, w.Code, w.Body.String())
+
+			if test.status == http.StatusForbidden {
+				var e errors.OAuth2Error
+
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+				require.Equal(t, errors.Forbidden, e.Error)
+			}
+
+			if test.request != nil {
+				require.Equal(t, test.request, a.request)
+			}
+		})
+	}
+}
+
+// TestPermissionValidate checks permission declarations are validated against the
+// path parameters available to the operation.
+func TestPermissionValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		permission authorization.Permission
+		parameters []string
+		valid      bool
+	}{
+		{
+			name:       "Global",
+			permission: authorization.Permission{Resource: "widgets", Action: "read", Scope: authorization.ScopeGlobal},
+			valid:      true,
+		},
+		{
+			name:       "Organization",
+			permission: authorization.Permission{Resource: "widgets", Action: "read", Scope: authorization.ScopeOrganization},
+			parameters: []string{authorization.OrganizationIDParameter},
+			valid:      true,
+		},
+		{
+			name:       "OrganizationMissingParameter",
+			permission: authorization.Permission{Resource: "widgets", Action: "read", Scope: authorization.ScopeOrganization},
+		},
+		{
+			name:       "Project",
+			permission: authorization.Permission{Resource: "widgets", Action: "read", Scope: authorization.ScopeProject},
+			parameters: []string{authorization.OrganizationIDParameter, authorization.ProjectIDParameter},
+			valid:      true,
+		},
+		{
+			name:       "ProjectMissingParameter",
+			permission: authorization.Permission{Resource: "widgets", Action: "read", Scope: authorization.ScopeProject},
+			parameters: []string{authorization.OrganizationIDParameter},
+		},
+		{
+			name:       "MissingResource",
+			permission: authorization.Permission{Action: "read", Scope: authorization.ScopeGlobal},
+		},
+		{
+			name:       "MissingAction",
+			permission: authorization.Permission{Resource: "widgets", Scope: authorization.ScopeGlobal},
+		},
+		{
+			name:       "InvalidScope",
+			permission: authorization.Permission{Resource: "widgets", Action: "read", Scope: "universe"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.permission.Validate(test.parameters)
+
+			if test.valid {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, authorization.ErrPermission)
+		})
+	}
+}
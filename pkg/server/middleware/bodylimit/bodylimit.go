@@ -0,0 +1,53 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bodylimit
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+)
+
+const (
+	// defaultMaxSize is used when no limit is specified.
+	defaultMaxSize = 16 << 20
+)
+
+// Middleware limits the size of request bodies so that clients cannot exhaust
+// memory, e.g. request validation buffers the whole body.  Requests that declare a larger body are rejected up front, otherwise
+// reads fail once the limit is reached.  A size of zero or less uses a default.
+func Middleware(maxSize int64) func(http.Handler) http.Handler {
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxSize {
+				errors.HandleError(w, r, errors.HTTPRequestEntityTooLarge(fmt.Sprintf("request body exceeds the maximum size of %d bytes", maxSize)))
+				return
+			}
+
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
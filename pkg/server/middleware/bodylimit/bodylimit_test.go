@@ -0,0 +1,142 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bodylimit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/bodylimit"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+)
+
+// TestMiddleware checks request bodies are limited whether or not the size is
+// declared up front.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"name":"` + strings.Repeat("a", 1024) + `"}`)
+
+	tests := []struct {
+		name     string
+		maxSize  int64
+		method   string
+		body     []byte
+		declared bool
+		status   int
+		called   bool
+	}{
+		{
+			name:     "NoBody",
+			maxSize:  16,
+			method:   http.MethodGet,
+			declared: true,
+			status:   http.StatusOK,
+			called:   true,
+		},
+		{
+			name:     "WithinLimit",
+			maxSize:  2048,
+			method:   http.MethodPost,
+			body:     body,
+			declared: true,
+			status:   http.StatusOK,
+			called:   true,
+		},
+		{
+			name:     "DeclaredTooLarge",
+			maxSize:  16,
+			method:   http.MethodPost,
+			body:     body,
+			declared: true,
+			status:   http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:    "UndeclaredTooLarge",
+			maxSize: 16,
+			method:  http.MethodPost,
+			body:    body,
+			status:  http.StatusRequestEntityTooLarge,
+			called:  true,
+		},
+		{
+			name:     "Default",
+			method:   http.MethodPost,
+			body:     body,
+			declared: true,
+			status:   http.StatusOK,
+			called:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var called bool
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+
+				if r.Method == http.MethodGet {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+
+				var request map[string]string
+
+				if err := util.ReadJSONBody(r, &request, util.WithMaxBodySize(1<<30)); err != nil {
+					errors.HandleError(w, r, err)
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(test.method, "/", bytes.NewReader(test.body))
+			r.Header.Set("Content-Type", "application/json")
+
+			if !test.declared {
+				r.ContentLength = -1
+			}
+
+			w := httptest.NewRecorder()
+
+			bodylimit.Middleware(test.maxSize)(handler).ServeHTTP(w, r)
+
+			require.Equal(t, test.status, w.Code, w.Body.String())
+			require.Equal(t, test.called, called)
+
+			if test.status == http.StatusOK {
+				return
+			}
+
+			var e openapi.Error
+
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+			require.Equal(t, openapi.RequestEntityTooLarge, e.Error)
+		})
+	}
+}
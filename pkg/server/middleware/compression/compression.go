@@ -0,0 +1,499 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	goerrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/pflag"
+
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Encoding is a supported content encoding.
+type Encoding string
+
+const (
+	// EncodingGzip is widely supported.
+	EncodingGzip Encoding = "gzip"
+
+	// EncodingZstd is faster and compresses better than gzip, so is
+	// preferred where the client supports it.
+	EncodingZstd Encoding = "zstd"
+
+	// EncodingIdentity means no encoding.
+	EncodingIdentity Encoding = "identity"
+)
+
+// Options defines configurable compression options.
+type Options struct {
+	// MinSize is the smallest response that will be compressed, below this
+	// the overhead of compression outweighs the benefit.
+	MinSize int
+
+	// MaxRequestSize limits the size of decompressed request bodies, to
+	// protect against decompression bombs.
+	MaxRequestSize int64
+}
+
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	f.IntVar(&o.MinSize, "compression-min-size", 1024, "Minimum response size in bytes to compress, negative to disable compression")
+	f.Int64Var(&o.MaxRequestSize, "compression-max-request-size", 16<<20, "Maximum size in bytes of a decompressed request body")
+}
+
+//nolint:gochecknoglobals
+var (
+	// gzipWriters pools gzip encoders, as they are expensive to allocate.
+	gzipWriters = sync.Pool{
+		New: func() any {
+			return gzip.NewWriter(nil)
+		},
+	}
+
+	// zstdWriters pools zstd encoders, as they are expensive to allocate.
+	zstdWriters = sync.Pool{
+		New: func() any {
+			// This only errors on invalid options.
+			w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+
+			return w
+		},
+	}
+)
+
+// encoder is a generic compressing writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// newEncoder returns a pooled encoder for the encoding.
+func newEncoder(encoding Encoding, w io.Writer) encoder {
+	var e encoder
+
+	switch encoding {
+	case EncodingGzip:
+		//nolint:forcetypeassert
+		e = gzipWriters.Get().(*gzip.Writer)
+	case EncodingZstd:
+		//nolint:forcetypeassert
+		e = zstdWriters.Get().(*zstd.Encoder)
+	default:
+		return nil
+	}
+
+	e.Reset(w)
+
+	return e
+}
+
+// releaseEncoder returns an encoder to the pool.
+func releaseEncoder(encoding Encoding, e encoder) {
+	e.Reset(nil)
+
+	switch encoding {
+	case EncodingGzip:
+		gzipWriters.Put(e)
+	case EncodingZstd:
+		zstdWriters.Put(e)
+	}
+}
+
+// negotiate selects the preferred encoding from the Accept-Encoding header as per
+// RFC 9110, returning identity if nothing we support is acceptable.
+func negotiate(r *http.Request) Encoding {
+	weights := map[Encoding]float64{}
+
+	var wildcard *float64
+
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, element := range strings.Split(value, ",") {
+			coding, parameters, _ := strings.Cut(strings.TrimSpace(element), ";")
+
+			weight := 1.0
+
+			if q, ok := strings.CutPrefix(strings.TrimSpace(parameters), "q="); ok {
+				if v, err := strconv.ParseFloat(q, 64); err == nil {
+					weight = v
+				}
+			}
+
+			coding = strings.ToLower(strings.TrimSpace(coding))
+
+			if coding == "*" {
+				wildcard = &weight
+
+				continue
+			}
+
+			weights[Encoding(coding)] = weight
+		}
+	}
+
+	best := EncodingIdentity
+	bestWeight := 0.0
+
+	// Order defines our preference where weights are equal.
+	for _, encoding := range []Encoding{EncodingZstd, EncodingGzip} {
+		weight, ok := weights[encoding]
+		if !ok && wildcard != nil {
+			weight, ok = *wildcard, true
+		}
+
+		if ok && weight > bestWeight {
+			best = encoding
+			bestWeight = weight
+		}
+	}
+
+	return best
+}
+
+// compressible returns true if the content type benefits from compression.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// responseWriter buffers the response until it knows whether it should be
+// compressed, that is it's compressible and large enough.
+type responseWriter struct {
+	next     http.ResponseWriter
+	options  *Options
+	encoding Encoding
+
+	// code is the status code, deferred until we know the encoding.
+	code int
+	// buffer holds the response until we decide whether to compress it.
+	buffer bytes.Buffer
+	// decided is set once we've committed to an encoding.
+	decided bool
+	// encoder is set if the response is being compressed.
+	encoder encoder
+}
+
+// Check the correct interface is implmented.
+var _ http.ResponseWriter = &responseWriter{}
+
+func (w *responseWriter) Header() http.Header {
+	return w.next.Header()
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if w.code != 0 {
+		return
+	}
+
+	w.code = statusCode
+
+	// Informational responses are sent immediately.
+	if statusCode < http.StatusOK {
+		w.code = 0
+
+		w.next.WriteHeader(statusCode)
+	}
+}
+
+// eligible returns whether the response can be compressed.
+func (w *responseWriter) eligible() bool {
+	header := w.Header()
+
+	if w.encoding == EncodingIdentity || header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	if w.code == http.StatusNoContent || w.code == http.StatusNotModified {
+		return false
+	}
+
+	return compressible(header.Get("Content-Type"))
+}
+
+// decide commits to an encoding and sends the headers.
+func (w *responseWriter) decide(compress bool) {
+	w.decided = true
+
+	header := w.Header()
+
+	if compressible(header.Get("Content-Type")) {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	if compress {
+		header.Set("Content-Encoding", string(w.encoding))
+		header.Del("Content-Length")
+
+		// Entity tags are left alone, they identify the resource version for
+		// If-Match preconditions, rather than the representation.
+
+		w.encoder = newEncoder(w.encoding, w.next)
+	}
+
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+
+	w.next.WriteHeader(w.code)
+}
+
+// drain writes out the buffered body.
+func (w *responseWriter) drain() error {
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+
+	var err error
+
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buffer.Bytes())
+	} else {
+		_, err = w.next.Write(w.buffer.Bytes())
+	}
+
+	w.buffer.Reset()
+
+	return err
+}
+
+func (w *responseWriter) Write(body []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(body)
+		}
+
+		return w.next.Write(body)
+	}
+
+	if !w.eligible() {
+		w.decide(false)
+
+		return w.next.Write(body)
+	}
+
+	w.buffer.Write(body)
+
+	if w.buffer.Len() >= w.options.MinSize {
+		w.decide(true)
+
+		if err := w.drain(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(body), nil
+}
+
+// Flush implements http.Flusher, once flushed we can no longer buffer so, if we
+// haven't already started compressing, the response is sent uncompressed.
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+
+	if err := w.drain(); err != nil {
+		return
+	}
+
+	if w.encoder != nil && w.encoder.Flush() != nil {
+		return
+	}
+
+	// Not all writers support flushing, in which case there's nothing to do.
+	_ = http.NewResponseController(w.next).Flush()
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.next
+}
+
+// close completes the response.
+func (w *responseWriter) close() error {
+	if !w.decided {
+		// Handlers that write nothing may still set a status code.
+		if w.buffer.Len() == 0 && w.code == 0 {
+			return nil
+		}
+
+		w.decide(false)
+	}
+
+	if err := w.drain(); err != nil {
+		return err
+	}
+
+	if w.encoder == nil {
+		return nil
+	}
+
+	defer releaseEncoder(w.encoding, w.encoder)
+
+	return w.encoder.Close()
+}
+
+// zstdReader adapts a zstd decoder to an io.ReadCloser, closing the
+// underlying request body too.
+type zstdReader struct {
+	*zstd.Decoder
+	body io.Closer
+}
+
+func (r *zstdReader) Close() error {
+	r.Decoder.Close()
+
+	return r.body.Close()
+}
+
+// gzipReader closes the underlying request body too.
+type gzipReader struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (r *gzipReader) Close() error {
+	if err := r.Reader.Close(); err != nil {
+		return err
+	}
+
+	return r.body.Close()
+}
+
+// sourceReader records errors reading the compressed request body, so they can be
+// told apart from errors decoding it.
+type sourceReader struct {
+	io.Reader
+	err error
+}
+
+func (r *sourceReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && !goerrors.Is(err, io.EOF) {
+		r.err = err
+	}
+
+	return n, err
+}
+
+// decodingReader reports corrupt request bodies e.g. gzip checksum or header
+// errors, flate corruption and zstd decode errors, as client errors.  Otherwise
+// handlers would treat them as server errors, as they would any other read error.
+type decodingReader struct {
+	io.ReadCloser
+	source   *sourceReader
+	encoding Encoding
+}
+
+func (r *decodingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err == nil || goerrors.Is(err, io.EOF) || r.source.err != nil {
+		return n, err
+	}
+
+	return n, errors.OAuth2InvalidRequest("request body is not valid " + string(r.encoding)).WithError(err)
+}
+
+// decompress replaces a compressed request body with a decompressing reader.
+func decompress(w http.ResponseWriter, r *http.Request, options *Options) error {
+	encoding := Encoding(strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))))
+
+	var body io.ReadCloser
+
+	source := &sourceReader{
+		Reader: r.Body,
+	}
+
+	switch encoding {
+	case "", EncodingIdentity:
+		return nil
+	case EncodingGzip:
+		reader, err := gzip.NewReader(source)
+		if err != nil {
+			return errors.OAuth2InvalidRequest("request body is not valid gzip").WithError(err)
+		}
+
+		body = &gzipReader{Reader: reader, body: r.Body}
+	case EncodingZstd:
+		reader, err := zstd.NewReader(source, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return errors.OAuth2InvalidRequest("request body is not valid zstd").WithError(err)
+		}
+
+		body = &zstdReader{Decoder: reader, body: r.Body}
+	default:
+		return errors.HTTPUnsupportedMediaType("request content encoding must be gzip or zstd")
+	}
+
+	body = &decodingReader{
+		ReadCloser: body,
+		source:     source,
+		encoding:   encoding,
+	}
+
+	r.Body = http.MaxBytesReader(w, body, options.MaxRequestSize)
+	r.ContentLength = -1
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+
+	return nil
+}
+
+// Middleware compresses responses where the client supports it, and decompresses
+// request bodies.  This must be run after any middleware that measures response
+// sizes, e.g. OpenTelemetry and access logging, so they see what was actually sent.
+func Middleware(options *Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := decompress(w, r, options); err != nil {
+				errors.HandleError(w, r, err)
+				return
+			}
+
+			if options.MinSize < 0 || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writer := &responseWriter{
+				next:     w,
+				options:  options,
+				encoding: negotiate(r),
+			}
+
+			next.ServeHTTP(writer, r)
+
+			if err := writer.close(); err != nil {
+				log.FromContext(r.Context()).Error(err, "failed to write response")
+			}
+		})
+	}
+}
@@ -0,0 +1,535 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compression_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/compression"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+)
+
+// testBody is large enough to be compressed with the default options.
+//
+//nolint:gochecknoglobals
+var testBody = `{"data":"` + strings.Repeat("a", 2048) + `"}`
+
+func testOptions() *compression.Options {
+	return &compression.Options{
+		MinSize:        1024,
+		MaxRequestSize: 4096,
+	}
+}
+
+// jsonHandler returns the body as JSON.
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+// decode returns the response body, decoding it as per its content encoding.
+func decode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var reader io.Reader = w.Body
+
+	switch w.Header().Get("Content-Encoding") {
+	case "gzip":
+		r, err := gzip.NewReader(reader)
+		require.NoError(t, err)
+
+		reader = r
+	case "zstd":
+		r, err := zstd.NewReader(reader)
+		require.NoError(t, err)
+
+		defer r.Close()
+
+		reader = r
+	}
+
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+// TestNegotiation checks the content encoding is negotiated as per RFC 9110.
+func TestNegotiation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		acceptEncoding []string
+		encoding       string
+	}{
+		{
+			name: "None",
+		},
+		{
+			name:           "Gzip",
+			acceptEncoding: []string{"gzip"},
+			encoding:       "gzip",
+		},
+		{
+			name:           "PreferZstd",
+			acceptEncoding: []string{"gzip, zstd"},
+			encoding:       "zstd",
+		},
+		{
+			name:           "Weighted",
+			acceptEncoding: []string{"zstd;q=0.5, gzip;q=0.8"},
+			encoding:       "gzip",
+		},
+		{
+			name:           "CaseInsensitive",
+			acceptEncoding: []string{"GZIP"},
+			encoding:       "gzip",
+		},
+		{
+			name:           "MultipleHeaders",
+			acceptEncoding: []string{"br", "gzip"},
+			encoding:       "gzip",
+		},
+		{
+			name:           "Unsupported",
+			acceptEncoding: []string{"br, deflate"},
+		},
+		{
+			name:           "Wildcard",
+			acceptEncoding: []string{"*"},
+			encoding:       "zstd",
+		},
+		{
+			name:           "WildcardExcludes",
+			acceptEncoding: []string{"zstd;q=0, *"},
+			encoding:       "gzip",
+		},
+		{
+			name:           "Rejected",
+			acceptEncoding: []string{"gzip;q=0, zstd;q=0"},
+		},
+		{
+			name:           "IdentityRejected",
+			acceptEncoding: []string{"gzip, identity;q=0"},
+			encoding:       "gzip",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			for _, value := range test.acceptEncoding {
+				r.Header.Add("Accept-Encoding", value)
+			}
+
+			w := httptest.NewRecorder()
+
+			compression.Middleware(testOptions())(jsonHandler(testBody)).ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, test.encoding, w.Header().Get("Content-Encoding"))
+			require.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			require.Equal(t, testBody, decode(t, w))
+		})
+	}
+}
+
+// TestEligibility checks only responses that benefit from compression are compressed.
+func TestEligibility(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		method   string
+		options  *compression.Options
+		handler  http.Handler
+		encoding string
+	}{
+		{
+			name:     "Large",
+			handler:  jsonHandler(testBody),
+			encoding: "gzip",
+		},
+		{
+			name:    "Small",
+			handler: jsonHandler(`{}`),
+		},
+		{
+			name:     "SmallWrites",
+			encoding: "gzip",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				for i := range len(testBody) {
+					_, _ = w.Write([]byte{testBody[i]})
+				}
+			}),
+		},
+		{
+			name:    "Disabled",
+			options: &compression.Options{MinSize: -1},
+			handler: jsonHandler(testBody),
+		},
+		{
+			name:    "Head",
+			method:  http.MethodHead,
+			handler: jsonHandler(testBody),
+		},
+		{
+			name: "NotCompressible",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/octet-stream")
+				_, _ = w.Write([]byte(testBody))
+			}),
+		},
+		{
+			name:     "StructuredSuffix",
+			encoding: "gzip",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/merge-patch+json")
+				_, _ = w.Write([]byte(testBody))
+			}),
+		},
+		{
+			name: "AlreadyEncoded",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", "br")
+				_, _ = w.Write([]byte(testBody))
+			}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			method := http.MethodGet
+			if test.method != "" {
+				method = test.method
+			}
+
+			options := testOptions()
+			if test.options != nil {
+				options = test.options
+			}
+
+			r := httptest.NewRequest(method, "/", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+
+			w := httptest.NewRecorder()
+
+			compression.Middleware(options)(test.handler).ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+
+			if test.encoding == "" {
+				require.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"))
+				return
+			}
+
+			require.Equal(t, test.encoding, w.Header().Get("Content-Encoding"))
+			require.Empty(t, w.Header().Get("Content-Length"))
+			require.Equal(t, testBody, decode(t, w))
+		})
+	}
+}
+
+// TestStatusCode checks status codes are preserved, including for empty responses.
+func TestStatusCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{
+			name:   "Created",
+			status: http.StatusCreated,
+			body:   testBody,
+		},
+		{
+			name:   "NoContent",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "Accepted",
+			status: http.StatusAccepted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+
+			w := httptest.NewRecorder()
+
+			compression.Middleware(testOptions())(handler).ServeHTTP(w, r)
+
+			require.Equal(t, test.status, w.Code)
+			require.Equal(t, test.body, decode(t, w))
+		})
+	}
+}
+
+// TestFlush checks streaming responses are sent as they are flushed, rather than
+// being buffered.
+func TestFlush(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan string)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", openapi.EventStreamMediaType)
+		w.WriteHeader(http.StatusOK)
+
+		// Headers are deferred until the encoding is decided, so flush them
+		// so the client sees the response start.
+		controller := http.NewResponseController(w)
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+
+		for event := range events {
+			if _, err := w.Write([]byte(event)); err != nil {
+				return
+			}
+
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	server := httptest.NewServer(compression.Middleware(testOptions())(handler))
+	defer server.Close()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	response, err := server.Client().Do(request)
+	require.NoError(t, err)
+
+	defer response.Body.Close()
+
+	require.Empty(t, response.Header.Get("Content-Encoding"))
+
+	reader := bufio.NewReader(response.Body)
+
+	for _, event := range []string{"id: 1\n", "id: 2\n"} {
+		events <- event
+
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, event, line)
+	}
+
+	close(events)
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	w := gzip.NewWriter(&buffer)
+
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buffer.Bytes()
+}
+
+func zstdData(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	w, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	return w.EncodeAll(data, nil)
+}
+
+// corrupt flips a bit in the compressed payload, leaving headers intact.
+func corrupt(data []byte, offset int) []byte {
+	out := bytes.Clone(data)
+	out[len(out)-offset] ^= 0xff
+
+	return out
+}
+
+// TestDecompression checks request bodies are decompressed, limited in size, and
+// that corrupt bodies are reported as client errors.
+func TestDecompression(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"data":"` + strings.Repeat("a", 1024) + `"}`)
+	bomb := []byte(`{"data":"` + strings.Repeat("a", 1<<20) + `"}`)
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+		error    openapi.ErrorError
+	}{
+		{
+			name:   "Identity",
+			body:   body,
+			status: http.StatusOK,
+		},
+		{
+			name:     "Gzip",
+			encoding: "gzip",
+			body:     gzipData(t, body),
+			status:   http.StatusOK,
+		},
+		{
+			name:     "Zstd",
+			encoding: "zstd",
+			body:     zstdData(t, body),
+			status:   http.StatusOK,
+		},
+		{
+			name:     "Unsupported",
+			encoding: "br",
+			body:     body,
+			status:   http.StatusUnsupportedMediaType,
+			error:    openapi.UnsupportedMediaType,
+		},
+		{
+			name:     "GzipBadHeader",
+			encoding: "gzip",
+			body:     body,
+			status:   http.StatusBadRequest,
+			error:    openapi.InvalidRequest,
+		},
+		{
+			name:     "GzipBadChecksum",
+			encoding: "gzip",
+			body:     corrupt(gzipData(t, body), 8),
+			status:   http.StatusBadRequest,
+			error:    openapi.InvalidRequest,
+		},
+		{
+			name:     "GzipCorrupt",
+			encoding: "gzip",
+			body:     corrupt(gzipData(t, body), 12),
+			status:   http.StatusBadRequest,
+			error:    openapi.InvalidRequest,
+		},
+		{
+			name:     "GzipTruncated",
+			encoding: "gzip",
+			body:     gzipData(t, body)[:20],
+			status:   http.StatusBadRequest,
+			error:    openapi.InvalidRequest,
+		},
+		{
+			name:     "ZstdCorrupt",
+			encoding: "zstd",
+			body:     corrupt(zstdData(t, body), 4),
+			status:   http.StatusBadRequest,
+			error:    openapi.InvalidRequest,
+		},
+		{
+			name:     "GzipBomb",
+			encoding: "gzip",
+			body:     gzipData(t, bomb),
+			status:   http.StatusRequestEntityTooLarge,
+			error:    openapi.RequestEntityTooLarge,
+		},
+		{
+			name:     "ZstdBomb",
+			encoding: "zstd",
+			body:     zstdData(t, bomb),
+			status:   http.StatusRequestEntityTooLarge,
+			error:    openapi.RequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var request map[string]string
+
+				if err := util.ReadJSONBody(r, &request, util.WithMaxBodySize(1<<30)); err != nil {
+					errors.HandleError(w, r, err)
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+			r.Header.Set("Content-Type", "application/json")
+
+			if test.encoding != "" {
+				r.Header.Set("Content-Encoding", test.encoding)
+			}
+
+			w := httptest.NewRecorder()
+
+			compression.Middleware(testOptions())(handler).ServeHTTP(w, r)
+
+			require.Equal(t, test.status, w.Code)
+
+			if test.status == http.StatusOK {
+				return
+			}
+
+			var e openapi.Error
+
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+			require.Equal(t, test.error, e.Error)
+		})
+	}
+}
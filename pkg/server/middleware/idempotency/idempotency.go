@@ -0,0 +1,265 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	goerrors "errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// KeyHeader is sent by clients to make a request idempotent.
+	KeyHeader = "Idempotency-Key"
+
+	// ReplayedHeader is set on replayed responses.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// maxKeyLength bounds the size of keys clients may send.
+	maxKeyLength = 255
+)
+
+// Options defines configurable idempotency options.
+type Options struct {
+	// TTL is how long keys are remembered for.
+	TTL time.Duration
+
+	// ReservationTTL is how long a key is reserved for while the request is
+	// in progress.  This is short, so keys aren't locked out for the full TTL
+	// if a replica dies mid-request, but must outlive the request timeout.
+	// On success the reservation is extended to the full TTL.
+	ReservationTTL time.Duration
+
+	// MaxKeys bounds the number of keys the memory store remembers, the least
+	// recently used are discarded first.
+	MaxKeys int
+
+	// MaxBodySize limits the size of request bodies that are hashed.
+	MaxBodySize int64
+}
+
+// AddFlags adds the options to the CLI flags.
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	f.DurationVar(&o.TTL, "idempotency-ttl", 24*time.Hour, "How long idempotency keys are remembered for")
+	f.DurationVar(&o.ReservationTTL, "idempotency-reservation-ttl", time.Minute, "How long idempotency keys are reserved for while in progress, this should be longer than the request timeout")
+	f.IntVar(&o.MaxKeys, "idempotency-max-keys", 16384, "Maximum number of idempotency keys to remember in memory")
+	f.Int64Var(&o.MaxBodySize, "idempotency-max-body-size", 1<<20, "Maximum size in bytes of an idempotent request body")
+}
+
+// replayHeaders are the response headers that are replayed, others e.g. trace IDs
+// and rate limits are specific to the replay.
+//
+//nolint:gochecknoglobals
+var replayHeaders = []string{
+	"Content-Type",
+	"Location",
+	"ETag",
+}
+
+// storeKey scopes the client's key to the principal, so one client cannot replay
+// another's response.  If the core authentication middleware isn't in use, then
+// fall back to the client's credentials.
+func storeKey(r *http.Request, key string) string {
+	hash := sha256.New()
+
+	if p, err := principal.FromContext(r.Context()); err == nil {
+		hash.Write([]byte(p.Issuer))
+		hash.Write([]byte{0})
+		hash.Write([]byte(p.Subject))
+	} else {
+		hash.Write([]byte(r.Header.Get("Authorization")))
+	}
+
+	hash.Write([]byte{0})
+	hash.Write([]byte(key))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// requestHash identifies the request, so we can detect key reuse.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// readBody reads the request body so it can be hashed, then replaces it so it
+// can be read again by the handler.
+func readBody(w http.ResponseWriter, r *http.Request, options *Options) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, options.MaxBodySize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+
+		if goerrors.As(err, &maxBytesError) {
+			return nil, errors.HTTPRequestEntityTooLarge("idempotent request body exceeds the maximum size of " + strconv.FormatInt(options.MaxBodySize, 10) + " bytes")
+		}
+
+		return nil, errors.OAuth2InvalidRequest("unable to read request body").WithError(err)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// replay writes the original response.
+func replay(w http.ResponseWriter, r *http.Request, record *Record) {
+	for key, values := range record.Header {
+		w.Header()[key] = values
+	}
+
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+
+	if _, err := w.Write(record.Body); err != nil {
+		log.FromContext(r.Context()).Error(err, "failed to write response")
+	}
+}
+
+// Middleware makes POST requests with an idempotency key idempotent.  The first
+// successful response for a key is remembered and replayed for any retries.
+// Unsuccessful responses are forgotten so the request can be retried.  This must be
+// run after authentication, so keys can be scoped to the principal.
+//
+//nolint:cyclop
+func Middleware(store Store, options *Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(KeyHeader)
+
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			if len(key) > maxKeyLength {
+				errors.HandleError(w, r, errors.OAuth2InvalidRequest("idempotency key too long"))
+				return
+			}
+
+			body, err := readBody(w, r, options)
+			if err != nil {
+				errors.HandleError(w, r, err)
+				return
+			}
+
+			key = storeKey(r, key)
+			hash := requestHash(r, body)
+
+			existing, err := store.Reserve(ctx, key, &Record{RequestHash: hash}, options.ReservationTTL)
+			if err != nil {
+				errors.HandleError(w, r, errors.OAuth2ServerError("unable to reserve idempotency key").WithError(err))
+				return
+			}
+
+			if existing != nil {
+				switch {
+				case existing.RequestHash != hash:
+					errors.HandleError(w, r, errors.HTTPIdempotencyConflict("idempotency key has been used for a different request"))
+				case !existing.Completed:
+					errors.HandleError(w, r, errors.HTTPIdempotencyConflict("a request with the same idempotency key is in progress"))
+				default:
+					replay(w, r, existing)
+				}
+
+				return
+			}
+
+			log := log.FromContext(ctx)
+
+			// The request context may have timed out by the time we are done, but
+			// the store still needs updating.
+			ctx = context.WithoutCancel(ctx)
+
+			// Release the key on failure, including panics, so it can be retried.
+			completed := false
+
+			defer func() {
+				if completed {
+					return
+				}
+
+				if err := store.Release(ctx, key); err != nil {
+					log.Error(err, "failed to release idempotency key")
+				}
+			}()
+
+			writer := middleware.NewLoggingResponseWriter(w)
+
+			next.ServeHTTP(writer, r)
+
+			// Only remember successful responses, streamed responses have
+			// no body to remember, so cannot be replayed either.
+			status := writer.StatusCode()
+
+			if status < http.StatusOK || status >= http.StatusMultipleChoices {
+				return
+			}
+
+			if writer.Body() == nil && writer.Size() != 0 {
+				return
+			}
+
+			record := &Record{
+				RequestHash: hash,
+				Completed:   true,
+				StatusCode:  status,
+				Header:      http.Header{},
+			}
+
+			for _, header := range replayHeaders {
+				if values := writer.Header().Values(header); len(values) != 0 {
+					record.Header[header] = values
+				}
+			}
+
+			if writer.Body() != nil {
+				record.Body = writer.Body().Bytes()
+			}
+
+			if err := store.Complete(ctx, key, record, options.TTL); err != nil {
+				log.Error(err, "failed to record idempotent response")
+
+				return
+			}
+
+			completed = true
+		})
+	}
+}
@@ -0,0 +1,379 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/server/middleware/idempotency"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+)
+
+func testOptions() *idempotency.Options {
+	return &idempotency.Options{
+		TTL:            time.Hour,
+		ReservationTTL: time.Minute,
+		MaxKeys:        16,
+		MaxBodySize:    1024,
+	}
+}
+
+// echoHandler returns the request body, counting the number of times it's called.
+func echoHandler(calls *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/widgets/1")
+		w.Header().Set("X-Trace-Id", "original")
+		w.WriteHeader(http.StatusCreated)
+
+		_, _ = w.Write(body)
+	})
+}
+
+func newRequest(method, path, key, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+
+	if key != "" {
+		r.Header.Set(idempotency.KeyHeader, key)
+	}
+
+	return r
+}
+
+func do(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	return w
+}
+
+// TestReplay checks retries get the original response without calling the handler.
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	handler := idempotency.Middleware(idempotency.NewMemoryStore(16), testOptions())(echoHandler(&calls))
+
+	first := do(handler, newRequest(http.MethodPost, "/widgets", "key", `{"name":"foo"}`))
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	second := do(handler, newRequest(http.MethodPost, "/widgets", "key", `{"name":"foo"}`))
+	require.Equal(t, http.StatusCreated, second.Code)
+	require.Equal(t, "true", second.Header().Get(idempotency.ReplayedHeader))
+	require.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	require.Equal(t, "/widgets/1", second.Header().Get("Location"))
+	require.Empty(t, second.Header().Get("X-Trace-Id"))
+	require.JSONEq(t, `{"name":"foo"}`, second.Body.String())
+
+	require.EqualValues(t, 1, calls.Load())
+}
+
+// TestPassthrough checks requests that aren't idempotent are handled as normal.
+func TestPassthrough(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		key    string
+	}{
+		{
+			name:   "NoKey",
+			method: http.MethodPost,
+		},
+		{
+			name:   "NotPost",
+			method: http.MethodPut,
+			key:    "key",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			handler := idempotency.Middleware(idempotency.NewMemoryStore(16), testOptions())(echoHandler(&calls))
+
+			for range 2 {
+				w := do(handler, newRequest(test.method, "/widgets", test.key, `{}`))
+				require.Equal(t, http.StatusCreated, w.Code)
+				require.Empty(t, w.Header().Get(idempotency.ReplayedHeader))
+			}
+
+			require.EqualValues(t, 2, calls.Load())
+		})
+	}
+}
+
+// TestInvalid checks bad requests are rejected before the handler is called.
+func TestInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		key    string
+		body   string
+		status int
+	}{
+		{
+			name:   "KeyTooLong",
+			key:    strings.Repeat("k", 256),
+			body:   `{}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "BodyTooLarge",
+			key:    "key",
+			body:   strings.Repeat(" ", 1025),
+			status: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			handler := idempotency.Middleware(idempotency.NewMemoryStore(16), testOptions())(echoHandler(&calls))
+
+			w := do(handler, newRequest(http.MethodPost, "/widgets", test.key, test.body))
+			require.Equal(t, test.status, w.Code)
+			require.Zero(t, calls.Load())
+		})
+	}
+}
+
+// TestConflict checks reusing a key for a different request is a conflict.
+func TestConflict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{
+			name: "Body",
+			path: "/widgets",
+			body: `{"name":"bar"}`,
+		},
+		{
+			name: "Path",
+			path: "/gadgets",
+			body: `{"name":"foo"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			handler := idempotency.Middleware(idempotency.NewMemoryStore(16), testOptions())(echoHandler(&calls))
+
+			w := do(handler, newRequest(http.MethodPost, "/widgets", "key", `{"name":"foo"}`))
+			require.Equal(t, http.StatusCreated, w.Code)
+
+			w = do(handler, newRequest(http.MethodPost, test.path, "key", test.body))
+			require.Equal(t, http.StatusConflict, w.Code)
+			require.EqualValues(t, 1, calls.Load())
+		})
+	}
+}
+
+// TestInProgress checks concurrent requests with the same key are a conflict.
+func TestInProgress(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	handler := idempotency.Middleware(idempotency.NewMemoryStore(16), testOptions())(blocking)
+
+	var wg sync.WaitGroup
+
+	var first *httptest.ResponseRecorder
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		first = do(handler, newRequest(http.MethodPost, "/widgets", "key", `{}`))
+	}()
+
+	<-started
+
+	w := do(handler, newRequest(http.MethodPost, "/widgets", "key", `{}`))
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, http.StatusCreated, first.Code)
+}
+
+// TestRelease checks failed requests release the key so they can be retried.
+func TestRelease(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		panics  bool
+	}{
+		{
+			name: "ServerError",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "ClientError",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			},
+		},
+		{
+			name: "Panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			panics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := idempotency.NewMemoryStore(16)
+
+			failing := idempotency.Middleware(store, testOptions())(test.handler)
+
+			if test.panics {
+				require.Panics(t, func() {
+					do(failing, newRequest(http.MethodPost, "/widgets", "key", `{}`))
+				})
+			} else {
+				do(failing, newRequest(http.MethodPost, "/widgets", "key", `{}`))
+			}
+
+			var calls atomic.Int32
+
+			handler := idempotency.Middleware(store, testOptions())(echoHandler(&calls))
+
+			w := do(handler, newRequest(http.MethodPost, "/widgets", "key", `{}`))
+			require.Equal(t, http.StatusCreated, w.Code)
+			require.Empty(t, w.Header().Get(idempotency.ReplayedHeader))
+			require.EqualValues(t, 1, calls.Load())
+		})
+	}
+}
+
+// TestPrincipalScope checks keys are scoped to the principal.
+func TestPrincipalScope(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	handler := idempotency.Middleware(idempotency.NewMemoryStore(16), testOptions())(echoHandler(&calls))
+
+	for _, subject := range []string{"alice", "bob"} {
+		r := newRequest(http.MethodPost, "/widgets", "key", `{}`)
+		r = r.WithContext(principal.NewContext(r.Context(), &principal.Principal{Issuer: "https://issuer", Subject: subject}))
+
+		w := do(handler, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Empty(t, w.Header().Get(idempotency.ReplayedHeader))
+	}
+
+	require.EqualValues(t, 2, calls.Load())
+}
+
+// ttlStore records the lifetimes requested of the memory store.
+type ttlStore struct {
+	*idempotency.MemoryStore
+
+	reserve  time.Duration
+	complete time.Duration
+}
+
+func (s *ttlStore) Reserve(ctx context.Context, key string, record *idempotency.Record, ttl time.Duration) (*idempotency.Record, error) {
+	s.reserve = ttl
+
+	return s.MemoryStore.Reserve(ctx, key, record, ttl)
+}
+
+func (s *ttlStore) Complete(ctx context.Context, key string, record *idempotency.Record, ttl time.Duration) error {
+	s.complete = ttl
+
+	return s.MemoryStore.Complete(ctx, key, record, ttl)
+}
+
+// TestLease checks keys are reserved with a short lease that is extended on
+// completion.
+func TestLease(t *testing.T) {
+	t.Parallel()
+
+	options := testOptions()
+
+	store := &ttlStore{
+		MemoryStore: idempotency.NewMemoryStore(16),
+	}
+
+	var calls atomic.Int32
+
+	handler := idempotency.Middleware(store, options)(echoHandler(&calls))
+
+	w := do(handler, newRequest(http.MethodPost, "/widgets", "key", `{}`))
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, options.ReservationTTL, store.reserve)
+	require.Equal(t, options.TTL, store.complete)
+}
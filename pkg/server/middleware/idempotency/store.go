@@ -0,0 +1,141 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/utils/lru"
+)
+
+// Record is what's remembered about a request.
+type Record struct {
+	// RequestHash identifies the request, so we can detect a key being
+	// reused for a different request.
+	RequestHash string
+
+	// Completed is false while the original request is in progress.
+	Completed bool
+
+	// StatusCode is the original response status code.
+	StatusCode int
+
+	// Header contains the original response headers to replay.
+	Header http.Header
+
+	// Body is the original response body.
+	Body []byte
+}
+
+// Store remembers requests by idempotency key.  Stores must be shared between
+// all replicas of a service to be fully effective.
+type Store interface {
+	// Reserve atomically creates an incomplete record for the key if one does
+	// not exist, returning nil.  Otherwise the existing record is returned.
+	// The ttl is a short lease, so the key is freed if the request never
+	// completes or is released.
+	Reserve(ctx context.Context, key string, record *Record, ttl time.Duration) (*Record, error)
+
+	// Complete updates the record for the key with the response, extending
+	// its expiry to the ttl.
+	Complete(ctx context.Context, key string, record *Record, ttl time.Duration) error
+
+	// Release deletes the record for the key, allowing the request to be
+	// retried e.g. after a server error.
+	Release(ctx context.Context, key string) error
+}
+
+// memoryEntry is a record with an expiry time.
+type memoryEntry struct {
+	record  *Record
+	expires time.Time
+}
+
+// MemoryStore is a process local store, this is only suitable for services with
+// a single replica, or where clients are pinned to a replica.
+type MemoryStore struct {
+	// lock serializes access to the records.
+	lock sync.Mutex
+
+	// records maps from key to a record, the least recently used are
+	// discarded first.
+	records *lru.Cache
+}
+
+// Ensure the Store interface is implemented.
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore creates a memory store that holds at most maxKeys records.
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	return &MemoryStore{
+		records: lru.New(maxKeys),
+	}
+}
+
+// get returns an unexpired record.
+func (s *MemoryStore) get(key string) *Record {
+	value, ok := s.records.Get(key)
+	if !ok {
+		return nil
+	}
+
+	entry, ok := value.(*memoryEntry)
+	if !ok || time.Now().After(entry.expires) {
+		s.records.Remove(key)
+
+		return nil
+	}
+
+	return entry.record
+}
+
+// Reserve implements the Store interface.
+func (s *MemoryStore) Reserve(ctx context.Context, key string, record *Record, ttl time.Duration) (*Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if existing := s.get(key); existing != nil {
+		return existing, nil
+	}
+
+	s.records.Add(key, &memoryEntry{record: record, expires: time.Now().Add(ttl)})
+
+	return nil, nil //nolint:nilnil
+}
+
+// Complete implements the Store interface.
+func (s *MemoryStore) Complete(ctx context.Context, key string, record *Record, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.records.Add(key, &memoryEntry{record: record, expires: time.Now().Add(ttl)})
+
+	return nil
+}
+
+// Release implements the Store interface.
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.records.Remove(key)
+
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idempotency_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/server/middleware/idempotency"
+)
+
+// TestMemoryStoreLifecycle checks records are reserved, completed and released.
+func TestMemoryStoreLifecycle(t *testing.T) {
+	t.Parallel()
+
+	store := idempotency.NewMemoryStore(16)
+
+	existing, err := store.Reserve(t.Context(), "key", &idempotency.Record{RequestHash: "hash"}, time.Minute)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	existing, err = store.Reserve(t.Context(), "key", &idempotency.Record{RequestHash: "other"}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	require.Equal(t, "hash", existing.RequestHash)
+	require.False(t, existing.Completed)
+
+	require.NoError(t, store.Complete(t.Context(), "key", &idempotency.Record{RequestHash: "hash", Completed: true}, time.Hour))
+
+	existing, err = store.Reserve(t.Context(), "key", &idempotency.Record{}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	require.True(t, existing.Completed)
+
+	require.NoError(t, store.Release(t.Context(), "key"))
+
+	existing, err = store.Reserve(t.Context(), "key", &idempotency.Record{}, time.Minute)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+}
+
+// TestMemoryStoreExpiry checks records expire, and that completion extends
+// the expiry.
+func TestMemoryStoreExpiry(t *testing.T) {
+	t.Parallel()
+
+	const ttl = 20 * time.Millisecond
+
+	store := idempotency.NewMemoryStore(16)
+
+	existing, err := store.Reserve(t.Context(), "reserved", &idempotency.Record{}, ttl)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	existing, err = store.Reserve(t.Context(), "completed", &idempotency.Record{}, ttl)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	require.NoError(t, store.Complete(t.Context(), "completed", &idempotency.Record{Completed: true}, time.Hour))
+
+	time.Sleep(2 * ttl)
+
+	existing, err = store.Reserve(t.Context(), "reserved", &idempotency.Record{}, ttl)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	existing, err = store.Reserve(t.Context(), "completed", &idempotency.Record{}, ttl)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	require.True(t, existing.Completed)
+}
+
+// TestMemoryStoreEviction checks the least recently used records are discarded
+// when the store is full.
+func TestMemoryStoreEviction(t *testing.T) {
+	t.Parallel()
+
+	store := idempotency.NewMemoryStore(2)
+
+	for _, key := range []string{"a", "b"} {
+		existing, err := store.Reserve(t.Context(), key, &idempotency.Record{RequestHash: key}, time.Hour)
+		require.NoError(t, err)
+		require.Nil(t, existing)
+	}
+
+	// Touch "a" so "b" is the least recently used.
+	existing, err := store.Reserve(t.Context(), "a", &idempotency.Record{}, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+
+	existing, err = store.Reserve(t.Context(), "c", &idempotency.Record{RequestHash: "c"}, time.Hour)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	existing, err = store.Reserve(t.Context(), "a", &idempotency.Record{}, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+
+	existing, err = store.Reserve(t.Context(), "b", &idempotency.Record{}, time.Hour)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+}
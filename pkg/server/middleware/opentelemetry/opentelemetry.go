@@ -19,11 +19,13 @@ package opentelemetry
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/spf13/pflag"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -32,11 +34,59 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.22.0"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/unikorn-cloud/core/pkg/openapi"
 	"github.com/unikorn-cloud/core/pkg/server/middleware"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// OperationIDKey is the span attribute that records the OpenAPI operation ID.
+const OperationIDKey = attribute.Key("openapi.operation.id")
+
+type Options struct {
+	// HeaderAllowList, if set, limits the headers that are captured as span
+	// attributes.
+	HeaderAllowList []string
+
+	// HeaderDenyList defines headers that are never captured as span attributes,
+	// in addition to those redacted by default.
+	HeaderDenyList []string
+}
+
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	f.StringSliceVar(&o.HeaderAllowList, "otel-header-allow", nil, "Headers to capture as span attributes, if not set all headers are captured")
+	f.StringSliceVar(&o.HeaderDenyList, "otel-header-deny", nil, "Headers to never capture as span attributes, in addition to the default redacted headers")
+}
+
+// recordedHeaders are headers that are already recorded as dedicated span
+// attributes, so needn't be duplicated.  Names are lower case.
+func recordedHeaders() []string {
+	return []string{
+		"user-agent",
+	}
+}
+
+// captureHeader returns true if a header can be captured.
+func (o *Options) captureHeader(name string) bool {
+	if middleware.IsRedactedHeader(name) || slices.Contains(recordedHeaders(), name) {
+		return false
+	}
+
+	if o == nil {
+		return true
+	}
+
+	if slices.ContainsFunc(o.HeaderDenyList, func(s string) bool { return strings.EqualFold(s, name) }) {
+		return false
+	}
+
+	if len(o.HeaderAllowList) != 0 {
+		return slices.ContainsFunc(o.HeaderAllowList, func(s string) bool { return strings.EqualFold(s, name) })
+	}
+
+	return true
+}
+
 // logValuesFromSpan gets a generic set of key/value pairs from a span for logging.
 func logValuesFromSpanContext(name string, s trace.SpanContext) []any {
 	return []any{
@@ -80,23 +130,14 @@ func (*LoggingSpanProcessor) ForceFlush(ctx context.Context) error {
 	return nil
 }
 
-// headerBlackList are headers we shouldn't collect, or are covered somewhere
-// other than http.request.header ot http.response.header.
-func headerBlackList() []string {
-	return []string{
-		"authorization",
-		"user-agent",
-	}
-}
-
-func httpHeaderAttributes(header http.Header, prefix string) []attribute.KeyValue {
+func httpHeaderAttributes(header http.Header, prefix string, options *Options) []attribute.KeyValue {
 	attr := make([]attribute.KeyValue, 0, len(header))
 
 	for key, values := range header {
 		normalizedKey := strings.ToLower(key)
 
 		// DO NOT EXPOSE PRIVATE INFORMATION.
-		if slices.Contains(headerBlackList(), normalizedKey) {
+		if !options.captureHeader(normalizedKey) {
 			continue
 		}
 
@@ -112,11 +153,27 @@ func httpHeaderAttributes(header http.Header, prefix string) []attribute.KeyValu
 	return attr
 }
 
+// splitHostPort splits a host and optional port, handling IPv6 addresses.
+func splitHostPort(hostport string) (string, int, bool) {
+	host, portString, err := net.SplitHostPort(hostport)
+	if err != nil {
+		// No port, but IPv6 addresses may still be bracketed.
+		return strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]"), 0, false
+	}
+
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return host, 0, false
+	}
+
+	return host, port, true
+}
+
 // httpRequestAttributes gets all the attr it can from a request.
 // This is done on a best effort basis!
 //
 //nolint:cyclop
-func httpRequestAttributes(r *http.Request) []attribute.KeyValue {
+func httpRequestAttributes(r *http.Request, options *Options) []attribute.KeyValue {
 	var attr []attribute.KeyValue
 
 	/* Protocol Processing */
@@ -150,7 +207,7 @@ func httpRequestAttributes(r *http.Request) []attribute.KeyValue {
 	}
 
 	attr = append(attr, semconv.HTTPRequestBodySize(int(r.ContentLength)))
-	attr = append(attr, httpHeaderAttributes(r.Header, "http.request.header")...)
+	attr = append(attr, httpHeaderAttributes(r.Header, "http.request.header", options)...)
 
 	// User Agent Processing.
 	if userAgent := r.UserAgent(); userAgent != "" {
@@ -160,8 +217,11 @@ func httpRequestAttributes(r *http.Request) []attribute.KeyValue {
 	/* URL Processing */
 	scheme := "http"
 
-	if r.URL.Scheme != "" {
+	switch {
+	case r.URL.Scheme != "":
 		scheme = r.URL.Scheme
+	case r.TLS != nil:
+		scheme = "https"
 	}
 
 	attr = append(attr, semconv.URLScheme(scheme))
@@ -176,43 +236,42 @@ func httpRequestAttributes(r *http.Request) []attribute.KeyValue {
 	}
 
 	/* Server processing */
-	serverHostPort := strings.Split(r.URL.Host, ":")
+	host := r.Host
+
+	if r.URL.Host != "" {
+		host = r.URL.Host
+	}
 
-	serverPort := 80
+	serverAddress, serverPort, ok := splitHostPort(host)
+	if !ok {
+		serverPort = 80
 
-	if len(serverHostPort) > 1 {
-		t, err := strconv.Atoi(serverHostPort[1])
-		if err == nil {
-			serverPort = t
+		if scheme == "https" {
+			serverPort = 443
 		}
 	}
 
-	attr = append(attr, semconv.ServerAddress(serverHostPort[0]))
+	attr = append(attr, semconv.ServerAddress(serverAddress))
 	attr = append(attr, semconv.ServerPort(serverPort))
 
 	/* Client processing */
-	clientHostPort := strings.Split(r.RemoteAddr, ":")
-	attr = append(attr, semconv.ClientAddress(clientHostPort[0]))
+	clientAddress, clientPort, ok := splitHostPort(r.RemoteAddr)
 
-	if clientPort, err := strconv.Atoi(clientHostPort[1]); err == nil {
+	attr = append(attr, semconv.ClientAddress(clientAddress))
+
+	if ok {
 		attr = append(attr, semconv.ClientPort(clientPort))
 	}
 
 	return attr
 }
 
-func httpResponseAttributes(w *middleware.LoggingResponseWriter) []attribute.KeyValue {
-	var bodySize int
-
-	if body := w.Body(); body != nil {
-		bodySize = body.Len()
-	}
-
+func httpResponseAttributes(w *middleware.LoggingResponseWriter, options *Options) []attribute.KeyValue {
 	var attr []attribute.KeyValue
 
 	attr = append(attr, semconv.HTTPResponseStatusCode(w.StatusCode()))
-	attr = append(attr, semconv.HTTPResponseBodySize(bodySize))
-	attr = append(attr, httpHeaderAttributes(w.Header(), "http.response.header")...)
+	attr = append(attr, semconv.HTTPResponseBodySize(w.Size()))
+	attr = append(attr, httpHeaderAttributes(w.Header(), "http.response.header", options)...)
 
 	return attr
 }
@@ -227,8 +286,38 @@ func httpStatusToOtelCode(status int) (codes.Code, string) {
 	return code, http.StatusText(status)
 }
 
+// routeAttributes returns the span name and any attributes derived from the
+// OpenAPI route.  Span names use the route template so that cardinality is bounded.
+func routeAttributes(r *http.Request, schema *openapi.Schema) (string, []attribute.KeyValue) {
+	if schema == nil {
+		return r.Method, nil
+	}
+
+	route, _, err := schema.FindRoute(r)
+	if err != nil {
+		return r.Method, nil
+	}
+
+	attr := []attribute.KeyValue{
+		semconv.HTTPRoute(route.Path),
+	}
+
+	if route.Operation != nil && route.Operation.OperationID != "" {
+		attr = append(attr, OperationIDKey.String(route.Operation.OperationID))
+	}
+
+	return r.Method + " " + route.Path, attr
+}
+
 // Middleware attaches logging context to the request.
 func Middleware(serviceName, version string) func(next http.Handler) http.Handler {
+	return MiddlewareWithOptions(serviceName, version, nil, nil)
+}
+
+// MiddlewareWithOptions attaches logging context to the request.  If a schema is
+// provided, spans are named after the route template, and header capture can be
+// tuned with options.  Either may be nil.
+func MiddlewareWithOptions(serviceName, version string, schema *openapi.Schema, options *Options) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract the tracing information from the HTTP headers.
@@ -239,13 +328,15 @@ func Middleware(serviceName, version string) func(next http.Handler) http.Handle
 
 			attr = append(attr, semconv.ServiceName(serviceName))
 			attr = append(attr, semconv.ServiceVersion(version))
-			attr = append(attr, httpRequestAttributes(r)...)
+			attr = append(attr, httpRequestAttributes(r, options)...)
+
+			name, routeAttr := routeAttributes(r, schema)
+
+			attr = append(attr, routeAttr...)
 
 			tracer := otel.GetTracerProvider().Tracer("opentelemetry middleware")
 
 			// Begin the span processing.
-			name := r.URL.Path
-
 			ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attr...))
 			defer span.End()
 
@@ -260,7 +351,7 @@ func Middleware(serviceName, version string) func(next http.Handler) http.Handle
 			next.ServeHTTP(writer, request)
 
 			// Extract HTTP response information for logging purposes.
-			span.SetAttributes(httpResponseAttributes(writer)...)
+			span.SetAttributes(httpResponseAttributes(writer, options)...)
 			span.SetStatus(httpStatusToOtelCode(writer.StatusCode()))
 		})
 	}
@@ -0,0 +1,284 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opentelemetry_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/opentelemetry"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /widgets/{widgetID}:
+    parameters:
+    - name: widgetID
+      in: path
+      required: true
+      schema:
+        type: string
+    get:
+      operationId: getWidget
+      responses:
+        '200':
+          description: ok
+`
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// serve handles the request with the middleware and returns the recorded span.
+// This modifies the global tracer provider, so callers cannot run in parallel.
+func serve(t *testing.T, middleware func(http.Handler) http.Handler, r *http.Request) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+
+	provider := otel.GetTracerProvider()
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+	})
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Custom", "value")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	return spans[0]
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	out := map[attribute.Key]attribute.Value{}
+
+	for _, kv := range span.Attributes() {
+		out[kv.Key] = kv.Value
+	}
+
+	return out
+}
+
+// TestSpanName checks spans are named by route template when a schema is provided.
+//
+//nolint:paralleltest
+func TestSpanName(t *testing.T) {
+	schema := testSchema(t)
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		path       string
+		span       string
+		route      string
+		operation  string
+	}{
+		{
+			name:       "NoSchema",
+			middleware: opentelemetry.Middleware("test", "v1"),
+			path:       "/widgets/foo",
+			span:       http.MethodGet,
+		},
+		{
+			name:       "Route",
+			middleware: opentelemetry.MiddlewareWithOptions("test", "v1", schema, nil),
+			path:       "/widgets/foo",
+			span:       "GET /widgets/{widgetID}",
+			route:      "/widgets/{widgetID}",
+			operation:  "getWidget",
+		},
+		{
+			name:       "UnknownRoute",
+			middleware: opentelemetry.MiddlewareWithOptions("test", "v1", schema, nil),
+			path:       "/gadgets/foo",
+			span:       http.MethodGet,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			span := serve(t, test.middleware, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			require.Equal(t, test.span, span.Name())
+
+			attr := attributes(span)
+
+			require.Equal(t, "test", attr["service.name"].AsString())
+			require.Equal(t, test.route, attr["http.route"].AsString())
+			require.Equal(t, test.operation, attr[opentelemetry.OperationIDKey].AsString())
+		})
+	}
+}
+
+// TestAddresses checks client and server addresses are parsed, including IPv6
+// addresses with and without ports.
+//
+//nolint:paralleltest
+func TestAddresses(t *testing.T) {
+	tests := []struct {
+		name          string
+		host          string
+		remoteAddr    string
+		serverAddress string
+		serverPort    int64
+		clientAddress string
+		clientPort    int64
+	}{
+		{
+			name:          "IPv4",
+			host:          "192.0.2.1:8080",
+			remoteAddr:    "192.0.2.2:1234",
+			serverAddress: "192.0.2.1",
+			serverPort:    8080,
+			clientAddress: "192.0.2.2",
+			clientPort:    1234,
+		},
+		{
+			name:          "IPv6",
+			host:          "[2001:db8::1]:8080",
+			remoteAddr:    "[2001:db8::2]:1234",
+			serverAddress: "2001:db8::1",
+			serverPort:    8080,
+			clientAddress: "2001:db8::2",
+			clientPort:    1234,
+		},
+		{
+			name:          "IPv6NoPort",
+			host:          "[2001:db8::1]",
+			remoteAddr:    "[2001:db8::2]",
+			serverAddress: "2001:db8::1",
+			serverPort:    80,
+			clientAddress: "2001:db8::2",
+		},
+		{
+			name:          "Hostname",
+			host:          "example.com",
+			remoteAddr:    "192.0.2.2:1234",
+			serverAddress: "example.com",
+			serverPort:    80,
+			clientAddress: "192.0.2.2",
+			clientPort:    1234,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Host = test.host
+			r.RemoteAddr = test.remoteAddr
+
+			attr := attributes(serve(t, opentelemetry.Middleware("test", "v1"), r))
+
+			require.Equal(t, test.serverAddress, attr["server.address"].AsString())
+			require.Equal(t, test.serverPort, attr["server.port"].AsInt64())
+			require.Equal(t, test.clientAddress, attr["client.address"].AsString())
+
+			if test.clientPort == 0 {
+				require.NotContains(t, attr, attribute.Key("client.port"))
+				return
+			}
+
+			require.Equal(t, test.clientPort, attr["client.port"].AsInt64())
+		})
+	}
+}
+
+// TestHeaders checks sensitive headers are never captured, and capture can be
+// controlled with allow and deny lists.
+//
+//nolint:paralleltest
+func TestHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  *opentelemetry.Options
+		captured []string
+		omitted  []string
+	}{
+		{
+			name:     "Default",
+			captured: []string{"http.request.header.accept", "http.request.header.x-request-id", "http.response.header.x-custom"},
+			omitted:  []string{"http.request.header.authorization", "http.request.header.user-agent"},
+		},
+		{
+			name: "DenyList",
+			options: &opentelemetry.Options{
+				HeaderDenyList: []string{"X-Request-ID"},
+			},
+			captured: []string{"http.request.header.accept"},
+			omitted:  []string{"http.request.header.authorization", "http.request.header.x-request-id"},
+		},
+		{
+			name: "AllowList",
+			options: &opentelemetry.Options{
+				HeaderAllowList: []string{"Accept", "Authorization"},
+			},
+			captured: []string{"http.request.header.accept"},
+			omitted:  []string{"http.request.header.authorization", "http.request.header.x-request-id", "http.response.header.x-custom"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", "application/json")
+			r.Header.Set("Authorization", "Bearer secret")
+			r.Header.Set("User-Agent", "test/1.0")
+			r.Header.Set("X-Request-ID", "1234")
+
+			attr := attributes(serve(t, opentelemetry.MiddlewareWithOptions("test", "v1", nil, test.options), r))
+
+			// User agent is always recorded, just not as a header.
+			require.Equal(t, "test/1.0", attr["user_agent.original"].AsString())
+
+			for _, key := range test.captured {
+				require.Contains(t, attr, attribute.Key(key))
+			}
+
+			for _, key := range test.omitted {
+				require.NotContains(t, attr, attribute.Key(key))
+			}
+		})
+	}
+}
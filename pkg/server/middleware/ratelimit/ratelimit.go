@@ -0,0 +1,340 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/spf13/pflag"
+	"golang.org/x/time/rate"
+
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+
+	"k8s.io/utils/lru"
+)
+
+const (
+	// Extension is the operation extension that overrides the default
+	// rate limit for an operation.
+	Extension = "x-rate-limit"
+)
+
+var (
+	// ErrLimit is raised when a rate limit declaration is invalid.
+	ErrLimit = goerrors.New("rate limit invalid")
+)
+
+// Key defines how requests are mapped to a rate limiting bucket.
+type Key string
+
+const (
+	// KeyPrincipal limits requests per authenticated principal.
+	KeyPrincipal Key = "principal"
+
+	// KeyOrganization limits requests per organization path parameter, so
+	// all users of an organization share the same limit.
+	KeyOrganization Key = "organization"
+
+	// KeyClient limits requests per client IP address.
+	KeyClient Key = "client"
+)
+
+// KeyFlag wraps up the rate limit key in a flag that can be used on the CLI.
+type KeyFlag struct {
+	Key Key
+}
+
+var _ pflag.Value = &KeyFlag{}
+
+// String implements the pflag.Value interface.
+func (s *KeyFlag) String() string {
+	return string(s.Key)
+}
+
+// Set implements the pflag.Value interface.
+func (s *KeyFlag) Set(in string) error {
+	valid := []Key{
+		KeyPrincipal,
+		KeyOrganization,
+		KeyClient,
+	}
+
+	value := Key(in)
+
+	if !slices.Contains(valid, value) {
+		return coreerrors.ErrParseFlag
+	}
+
+	s.Key = value
+
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (s *KeyFlag) Type() string {
+	return "string"
+}
+
+const (
+	// defaultMaxClients is the number of rate limiters that are tracked if
+	// not specified, an unbounded cache would grow with every client.
+	defaultMaxClients = 16384
+)
+
+// Options defines configurable rate limiting options.
+type Options struct {
+	// Rate is the default number of requests per second allowed for
+	// each key, zero disables rate limiting.
+	Rate float64
+
+	// Burst is the default number of requests that may be made in excess
+	// of the rate.
+	Burst int
+
+	// Key defines how requests are mapped to a rate limit.  Where the key
+	// is unavailable e.g. the request is unauthenticated, this falls back
+	// to the client IP address.
+	Key KeyFlag
+
+	// MaxClients bounds the number of rate limiters that are tracked, the
+	// least recently used are discarded first.  When not positive a default
+	// is used.
+	MaxClients int
+
+	// ClientIPHeader, if set, is a header e.g. X-Forwarded-For that a trusted
+	// load balancer sets to the client IP address.  Without this all clients
+	// behind a load balancer share the same client key.
+	ClientIPHeader string
+}
+
+// AddFlags adds the options to the CLI flags.
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	o.Key.Key = KeyPrincipal
+
+	f.Float64Var(&o.Rate, "rate-limit-rate", 0, "Default requests per second allowed per key, 0 disables rate limiting")
+	f.IntVar(&o.Burst, "rate-limit-burst", 20, "Default number of requests allowed in excess of the rate")
+	f.Var(&o.Key, "rate-limit-key", "How to key rate limits from [principal organization client]")
+	f.IntVar(&o.MaxClients, "rate-limit-max-clients", defaultMaxClients, "Maximum number of rate limiters to track")
+	f.StringVar(&o.ClientIPHeader, "rate-limit-client-ip-header", "", "Header set by a trusted load balancer containing the client IP address e.g. X-Forwarded-For")
+}
+
+// Limit is the value of the operation extension.
+type Limit struct {
+	// Rate is the number of requests per second allowed, zero disables
+	// rate limiting for the operation.
+	Rate float64 `json:"rate"`
+
+	// Burst is the number of requests that may be made in excess of the rate.
+	Burst int `json:"burst"`
+}
+
+// LimitFromOperation returns the rate limit override for an operation, or
+// nil if none is declared.
+func LimitFromOperation(operation *openapi3.Operation) (*Limit, error) {
+	value, ok := operation.Extensions[Extension]
+	if !ok {
+		return nil, nil //nolint:nilnil
+	}
+
+	// Extensions are generically decoded, so round trip to get a typed value.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := &Limit{}
+
+	if err := json.Unmarshal(data, limit); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLimit, err)
+	}
+
+	if limit.Rate < 0 || limit.Burst < 0 || (limit.Rate > 0 && limit.Burst == 0) {
+		return nil, fmt.Errorf("%w: rate and burst must be positive", ErrLimit)
+	}
+
+	return limit, nil
+}
+
+// limiter keeps track of token buckets.
+type limiter struct {
+	options *Options
+
+	// lock serializes lookup and creation of token buckets.
+	lock sync.Mutex
+
+	// buckets maps from operation and key to a token bucket.
+	buckets *lru.Cache
+}
+
+// get returns a token bucket for the key, creating it if it doesn't exist.
+func (l *limiter) get(key string, limit *Limit) *rate.Limiter {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if value, ok := l.buckets.Get(key); ok {
+		if bucket, ok := value.(*rate.Limiter); ok {
+			return bucket
+		}
+	}
+
+	bucket := rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+
+	l.buckets.Add(key, bucket)
+
+	return bucket
+}
+
+// clientAddress returns the client IP address.  When a client IP header is
+// configured, the last address is used, as that is the one appended by the trusted
+// load balancer, anything before it may be forged by the client.
+func (l *limiter) clientAddress(r *http.Request) string {
+	if l.options.ClientIPHeader != "" {
+		if values := r.Header.Values(l.options.ClientIPHeader); len(values) > 0 {
+			addresses := strings.Split(values[len(values)-1], ",")
+
+			if address := strings.TrimSpace(addresses[len(addresses)-1]); address != "" {
+				return address
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// key returns the rate limiting key for the request.
+func (l *limiter) key(r *http.Request, params map[string]string) string {
+	switch l.options.Key.Key {
+	case KeyPrincipal:
+		if p, err := principal.FromContext(r.Context()); err == nil {
+			return "principal:" + p.Issuer + "/" + p.Subject
+		}
+	case KeyOrganization:
+		if organizationID, ok := params[authorization.OrganizationIDParameter]; ok {
+			return "organization:" + organizationID
+		}
+	case KeyClient:
+	}
+
+	return "client:" + l.clientAddress(r)
+}
+
+// setHeaders reports the state of the token bucket to the client.
+func setHeaders(w http.ResponseWriter, bucket *rate.Limiter, limit *Limit, tokens float64) {
+	remaining := max(0, int(math.Floor(tokens)))
+	reset := int(math.Ceil((float64(limit.Burst) - tokens) / limit.Rate))
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(bucket.Burst()))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(max(0, reset)))
+}
+
+// limit does the heavy lifting of the middleware.
+func (l *limiter) limit(w http.ResponseWriter, r *http.Request, schema *openapi.Schema) error {
+	route, params, err := schema.FindRoute(r)
+	if err != nil {
+		// Let the router handle missing routes.
+		return nil //nolint:nilerr
+	}
+
+	limit := &Limit{
+		Rate:  l.options.Rate,
+		Burst: l.options.Burst,
+	}
+
+	// By default all operations share a bucket, overrides get their own.
+	key := l.key(r, params)
+
+	override, err := LimitFromOperation(route.Operation)
+	if err != nil {
+		return errors.OAuth2ServerError("unable to parse rate limit").WithError(err)
+	}
+
+	if override != nil {
+		limit = override
+		key = route.Method + " " + route.Path + " " + key
+	}
+
+	if limit.Rate == 0 {
+		return nil
+	}
+
+	bucket := l.get(key, limit)
+
+	now := time.Now()
+
+	allowed := bucket.AllowN(now, 1)
+
+	tokens := bucket.TokensAt(now)
+
+	setHeaders(w, bucket, limit, tokens)
+
+	if !allowed {
+		retry := int(math.Ceil((1 - tokens) / limit.Rate))
+
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, retry)))
+
+		return errors.HTTPTooManyRequests()
+	}
+
+	return nil
+}
+
+// Middleware applies token bucket rate limiting to requests.  Limits default to
+// those defined by the options, and may be overridden per operation by the schema.
+// For principal based keys this must be run after authentication.
+func Middleware(schema *openapi.Schema, options *Options) func(http.Handler) http.Handler {
+	maxClients := options.MaxClients
+	if maxClients <= 0 {
+		maxClients = defaultMaxClients
+	}
+
+	l := &limiter{
+		options: options,
+		buckets: lru.New(maxClients),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := l.limit(w, r, schema); err != nil {
+				errors.HandleError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+
+	coreerrors "github.com/unikorn-cloud/core/pkg/errors"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/ratelimit"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /organizations/{organizationID}/widgets:
+    parameters:
+    - name: organizationID
+      in: path
+      required: true
+      schema:
+        type: string
+    get:
+      responses:
+        '200':
+          description: ok
+    post:
+      x-rate-limit:
+        rate: 0.001
+        burst: 1
+      responses:
+        '201':
+          description: created
+  /unlimited:
+    get:
+      x-rate-limit:
+        rate: 0
+      responses:
+        '200':
+          description: ok
+  /broken:
+    get:
+      x-rate-limit:
+        rate: 1
+      responses:
+        '200':
+          description: ok
+`
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// request describes a request and the expected response.
+type request struct {
+	method        string
+	path          string
+	subject       string
+	remoteAddr    string
+	forwardedFor  string
+	status        int
+	remaining     string
+	rateLimitSent bool
+}
+
+// TestMiddleware checks requests are limited per key, once the burst is exhausted.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	get := func(path, subject, remoteAddr, forwardedFor string, status int) request {
+		return request{
+			method:        http.MethodGet,
+			path:          path,
+			subject:       subject,
+			remoteAddr:    remoteAddr,
+			forwardedFor:  forwardedFor,
+			status:        status,
+			rateLimitSent: true,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		options  ratelimit.Options
+		requests []request
+	}{
+		{
+			name: "Disabled",
+			options: ratelimit.Options{
+				Key: ratelimit.KeyFlag{Key: ratelimit.KeyPrincipal},
+			},
+			requests: []request{
+				{method: http.MethodGet, path: "/organizations/a/widgets", status: http.StatusOK},
+				{method: http.MethodGet, path: "/organizations/a/widgets", status: http.StatusOK},
+			},
+		},
+		{
+			name: "Principal",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 2,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyPrincipal},
+			},
+			requests: []request{
+				{method: http.MethodGet, path: "/organizations/a/widgets", subject: "alice", remoteAddr: "192.0.2.1:1", status: http.StatusOK, remaining: "1", rateLimitSent: true},
+				{method: http.MethodGet, path: "/organizations/b/widgets", subject: "alice", remoteAddr: "192.0.2.2:1", status: http.StatusOK, remaining: "0", rateLimitSent: true},
+				{method: http.MethodGet, path: "/organizations/a/widgets", subject: "alice", remoteAddr: "192.0.2.3:1", status: http.StatusTooManyRequests, remaining: "0", rateLimitSent: true},
+				// Different principal, same client address.
+				get("/organizations/a/widgets", "bob", "192.0.2.1:1", "", http.StatusOK),
+			},
+		},
+		{
+			name: "PrincipalFallsBackToClient",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 1,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyPrincipal},
+			},
+			requests: []request{
+				get("/organizations/a/widgets", "", "192.0.2.1:1", "", http.StatusOK),
+				get("/organizations/a/widgets", "", "192.0.2.1:2", "", http.StatusTooManyRequests),
+				get("/organizations/a/widgets", "", "192.0.2.2:1", "", http.StatusOK),
+			},
+		},
+		{
+			name: "Organization",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 1,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyOrganization},
+			},
+			requests: []request{
+				get("/organizations/a/widgets", "alice", "192.0.2.1:1", "", http.StatusOK),
+				get("/organizations/a/widgets", "bob", "192.0.2.2:1", "", http.StatusTooManyRequests),
+				get("/organizations/b/widgets", "alice", "192.0.2.1:1", "", http.StatusOK),
+			},
+		},
+		{
+			name: "Client",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 1,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyClient},
+			},
+			requests: []request{
+				get("/organizations/a/widgets", "alice", "192.0.2.1:1", "", http.StatusOK),
+				get("/organizations/a/widgets", "bob", "192.0.2.1:2", "", http.StatusTooManyRequests),
+				get("/organizations/a/widgets", "alice", "[2001:db8::1]:1", "", http.StatusOK),
+			},
+		},
+		{
+			name: "ClientForwardedIgnored",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 1,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyClient},
+			},
+			requests: []request{
+				get("/organizations/a/widgets", "", "10.0.0.1:1", "192.0.2.1", http.StatusOK),
+				get("/organizations/a/widgets", "", "10.0.0.1:1", "192.0.2.2", http.StatusTooManyRequests),
+			},
+		},
+		{
+			name: "ClientForwarded",
+			options: ratelimit.Options{
+				Rate:           0.001,
+				Burst:          1,
+				Key:            ratelimit.KeyFlag{Key: ratelimit.KeyClient},
+				ClientIPHeader: "X-Forwarded-For",
+			},
+			requests: []request{
+				get("/organizations/a/widgets", "", "10.0.0.1:1", "192.0.2.1", http.StatusOK),
+				get("/organizations/a/widgets", "", "10.0.0.1:1", "192.0.2.2", http.StatusOK),
+				// Only the address appended by the load balancer is trusted.
+				get("/organizations/a/widgets", "", "10.0.0.1:1", "192.0.2.3, 192.0.2.1", http.StatusTooManyRequests),
+				// Missing headers fall back to the remote address.
+				get("/organizations/a/widgets", "", "10.0.0.1:1", "", http.StatusOK),
+			},
+		},
+		{
+			name: "OperationOverride",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 10,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyClient},
+			},
+			requests: []request{
+				{method: http.MethodPost, path: "/organizations/a/widgets", remoteAddr: "192.0.2.1:1", status: http.StatusOK, remaining: "0", rateLimitSent: true},
+				{method: http.MethodPost, path: "/organizations/a/widgets", remoteAddr: "192.0.2.1:1", status: http.StatusTooManyRequests, remaining: "0", rateLimitSent: true},
+				// The override has its own bucket.
+				{method: http.MethodGet, path: "/organizations/a/widgets", remoteAddr: "192.0.2.1:1", status: http.StatusOK, remaining: "9", rateLimitSent: true},
+			},
+		},
+		{
+			name: "OperationDisabled",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 1,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyClient},
+			},
+			requests: []request{
+				{method: http.MethodGet, path: "/unlimited", remoteAddr: "192.0.2.1:1", status: http.StatusOK},
+				{method: http.MethodGet, path: "/unlimited", remoteAddr: "192.0.2.1:1", status: http.StatusOK},
+			},
+		},
+		{
+			name: "OperationInvalid",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 1,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyClient},
+			},
+			requests: []request{
+				{method: http.MethodGet, path: "/broken", remoteAddr: "192.0.2.1:1", status: http.StatusInternalServerError},
+			},
+		},
+		{
+			name: "UnknownRoute",
+			options: ratelimit.Options{
+				Rate:  0.001,
+				Burst: 1,
+				Key:   ratelimit.KeyFlag{Key: ratelimit.KeyClient},
+			},
+			requests: []request{
+				{method: http.MethodGet, path: "/unknown", remoteAddr: "192.0.2.1:1", status: http.StatusOK},
+				{method: http.MethodGet, path: "/unknown", remoteAddr: "192.0.2.1:1", status: http.StatusOK},
+			},
+		},
+	}
+
+	schema := testSchema(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.options.MaxClients = 16
+
+			handler := ratelimit.Middleware(schema, &test.options)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			for i, request := range test.requests {
+				r := httptest.NewRequest(request.method, request.path, nil)
+				r.RemoteAddr = request.remoteAddr
+
+				if request.forwardedFor != "" {
+					r.Header.Set("X-Forwarded-For", request.forwardedFor)
+				}
+
+				if request.subject != "" {
+					r = r.WithContext(principal.NewContext(r.Context(), &principal.Principal{Issuer: "test", Subject: request.subject}))
+				}
+
+				w := httptest.NewRecorder()
+
+				handler.ServeHTTP(w, r)
+
+				require.Equal(t, request.status, w.Code, "request %d", i)
+
+				if !request.rateLimitSent {
+					require.Empty(t, w.Header().Get("RateLimit-Limit"), "request %d", i)
+					continue
+				}
+
+				require.NotEmpty(t, w.Header().Get("RateLimit-Limit"), "request %d", i)
+				require.NotEmpty(t, w.Header().Get("RateLimit-Reset"), "request %d", i)
+
+				if request.remaining != "" {
+					require.Equal(t, request.remaining, w.Header().Get("RateLimit-Remaining"), "request %d", i)
+				}
+
+				if request.status == http.StatusTooManyRequests {
+					require.NotEmpty(t, w.Header().Get("Retry-After"), "request %d", i)
+				}
+			}
+		})
+	}
+}
+
+// TestMaxClients checks the number of tracked clients is bounded, even when not
+// specified, by showing the least recently used client is forgotten.
+func TestMaxClients(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		maxClients int
+		clients    int
+	}{
+		{
+			name:       "Specified",
+			maxClients: 2,
+			clients:    2,
+		},
+		{
+			name:    "Zero",
+			clients: 16384,
+		},
+		{
+			name:       "Negative",
+			maxClients: -1,
+			clients:    16384,
+		},
+	}
+
+	schema := testSchema(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &ratelimit.Options{
+				Rate:       0.001,
+				Burst:      1,
+				Key:        ratelimit.KeyFlag{Key: ratelimit.KeyPrincipal},
+				MaxClients: test.maxClients,
+			}
+
+			handler := ratelimit.Middleware(schema, options)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			serve := func(subject string) int {
+				r := httptest.NewRequest(http.MethodGet, "/organizations/a/widgets", nil)
+				r = r.WithContext(principal.NewContext(r.Context(), &principal.Principal{Issuer: "test", Subject: subject}))
+
+				w := httptest.NewRecorder()
+
+				handler.ServeHTTP(w, r)
+
+				return w.Code
+			}
+
+			require.Equal(t, http.StatusOK, serve("target"))
+			require.Equal(t, http.StatusTooManyRequests, serve("target"))
+
+			for i := range test.clients {
+				require.Equal(t, http.StatusOK, serve("client-"+strconv.Itoa(i)))
+			}
+
+			require.Equal(t, http.StatusOK, serve("target"))
+		})
+	}
+}
+
+// TestKeyFlag checks only known keys are accepted.
+func TestKeyFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in    string
+		valid bool
+	}{
+		{in: "principal", valid: true},
+		{in: "organization", valid: true},
+		{in: "client", valid: true},
+		{in: "project"},
+		{in: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			t.Parallel()
+
+			flag := &ratelimit.KeyFlag{}
+
+			err := flag.Set(test.in)
+
+			if !test.valid {
+				require.ErrorIs(t, err, coreerrors.ErrParseFlag)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.in, flag.String())
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recovery
+
+import (
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.22.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// TraceIDHeader is returned on all responses so that users can quote it
+	// when reporting problems, allowing the request to be found in telemetry.
+	TraceIDHeader = "X-Trace-Id"
+)
+
+var (
+	// ErrPanic is raised when a handler panics.
+	ErrPanic = goerrors.New("handler panicked")
+)
+
+// responseWriter tracks whether the response has been started, in which case
+// we are unable to return an error to the client.
+type responseWriter struct {
+	http.ResponseWriter
+
+	written bool
+}
+
+func (w *responseWriter) Write(body []byte) (int, error) {
+	w.written = true
+
+	return w.ResponseWriter.Write(body)
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.written = true
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recoverPanic converts a panic into an error response.
+func recoverPanic(w *responseWriter, r *http.Request, recovered any) {
+	// This is used to deliberately abort a response, so respect that.
+	if recovered == http.ErrAbortHandler { //nolint:errorlint
+		panic(recovered)
+	}
+
+	stack := string(debug.Stack())
+
+	err := fmt.Errorf("%w: %v", ErrPanic, recovered)
+
+	span := trace.SpanFromContext(r.Context())
+	span.RecordError(err, trace.WithAttributes(semconv.ExceptionStacktrace(stack)))
+	span.SetStatus(codes.Error, err.Error())
+
+	log.FromContext(r.Context()).Error(err, "panic recovered", "stack", stack)
+
+	// Too late to do anything, the client will get a truncated response.
+	if w.written {
+		return
+	}
+
+	errors.HandleError(w, r, errors.OAuth2ServerError("an unexpected error occurred").WithError(err))
+}
+
+// Middleware recovers from handler panics, records them in telemetry and returns
+// a server error to the client.  It also adds the trace ID to all responses.  This
+// must be run after OpenTelemetry so the span is available.
+func Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+				w.Header().Set(TraceIDHeader, spanContext.TraceID().String())
+			}
+
+			writer := &responseWriter{
+				ResponseWriter: w,
+			}
+
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					recoverPanic(writer, r, recovered)
+				}
+			}()
+
+			next.ServeHTTP(writer, r)
+		})
+	}
+}
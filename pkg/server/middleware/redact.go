@@ -0,0 +1,65 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package middleware
+
+import (
+	"slices"
+	"strings"
+)
+
+// Redacted replaces sensitive values.
+const Redacted = "[REDACTED]"
+
+// RedactedHeaders are headers we shouldn't expose in telemetry or logs as they
+// contain credentials or other private information.  Names are lower case.
+func RedactedHeaders() []string {
+	return []string{
+		"authorization",
+		"cookie",
+		"proxy-authorization",
+		"set-cookie",
+		"x-api-key",
+	}
+}
+
+// IsRedactedHeader returns true if the header should not be exposed.
+func IsRedactedHeader(name string) bool {
+	return slices.Contains(RedactedHeaders(), strings.ToLower(name))
+}
+
+// RedactedFields are JSON object keys whose values we shouldn't expose, they
+// are matched case insensitively as substrings, so "password" matches
+// "adminPassword".  Names are lower case.
+func RedactedFields() []string {
+	return []string{
+		"credential",
+		"kubeconfig",
+		"password",
+		"privatekey",
+		"secret",
+		"token",
+	}
+}
+
+// IsRedactedField returns true if the JSON object key's value should not be exposed.
+func IsRedactedField(name string) bool {
+	name = strings.ToLower(name)
+
+	return slices.ContainsFunc(RedactedFields(), func(field string) bool {
+		return strings.Contains(name, field)
+	})
+}
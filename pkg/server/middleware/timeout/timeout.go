@@ -21,12 +21,28 @@ import (
 	"context"
 	"net/http"
 	"time"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
 )
 
 // Middleware adds a timeout to requests.
 func Middleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return MiddlewareWithSchema(timeout, nil)
+}
+
+// MiddlewareWithSchema adds a timeout to requests, except those for routes that
+// respond with event streams, which are unbounded.  Such handlers are responsible
+// for managing their own write deadlines.
+func MiddlewareWithSchema(timeout time.Duration, schema *openapi.Schema) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if schema != nil {
+				if route, _, err := schema.FindRoute(r); err == nil && openapi.IsEventStream(route) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 
@@ -0,0 +1,121 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timeout_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/timeout"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /widgets:
+    get:
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+  /widgets/events:
+    get:
+      responses:
+        '200':
+          description: ok
+          content:
+            text/event-stream:
+              schema:
+                type: string
+`
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// TestMiddleware checks requests have a deadline, unless they are event streams.
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	schema := testSchema(t)
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		path       string
+		deadline   bool
+	}{
+		{
+			name:       "NoSchema",
+			middleware: timeout.Middleware(time.Minute),
+			path:       "/widgets/events",
+			deadline:   true,
+		},
+		{
+			name:       "Route",
+			middleware: timeout.MiddlewareWithSchema(time.Minute, schema),
+			path:       "/widgets",
+			deadline:   true,
+		},
+		{
+			name:       "UnknownRoute",
+			middleware: timeout.MiddlewareWithSchema(time.Minute, schema),
+			path:       "/gadgets",
+			deadline:   true,
+		},
+		{
+			name:       "EventStream",
+			middleware: timeout.MiddlewareWithSchema(time.Minute, schema),
+			path:       "/widgets/events",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var deadline bool
+
+			handler := test.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, deadline = r.Context().Deadline()
+			}))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			require.Equal(t, test.deadline, deadline)
+		})
+	}
+}
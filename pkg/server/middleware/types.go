@@ -27,6 +27,10 @@ type LoggingResponseWriter struct {
 	next http.ResponseWriter
 	code int
 	body *bytes.Buffer
+	size int
+	// streaming is set once the response is flushed, at which point we stop
+	// buffering the body as it's potentially unbounded.
+	streaming bool
 }
 
 func NewLoggingResponseWriter(next http.ResponseWriter) *LoggingResponseWriter {
@@ -43,13 +47,19 @@ func (w *LoggingResponseWriter) Header() http.Header {
 }
 
 func (w *LoggingResponseWriter) Write(body []byte) (int, error) {
-	if w.body == nil {
-		w.body = &bytes.Buffer{}
+	if !w.streaming {
+		if w.body == nil {
+			w.body = &bytes.Buffer{}
+		}
+
+		w.body.Write(body)
 	}
 
-	w.body.Write(body)
+	n, err := w.next.Write(body)
+
+	w.size += n
 
-	return w.next.Write(body)
+	return n, err
 }
 
 func (w *LoggingResponseWriter) WriteHeader(statusCode int) {
@@ -57,6 +67,20 @@ func (w *LoggingResponseWriter) WriteHeader(statusCode int) {
 	w.next.WriteHeader(statusCode)
 }
 
+// Flush implements http.Flusher for streaming responses.
+func (w *LoggingResponseWriter) Flush() {
+	w.streaming = true
+	w.body = nil
+
+	// Not all writers support flushing, in which case there's nothing to do.
+	_ = http.NewResponseController(w.next).Flush()
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (w *LoggingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.next
+}
+
 func (w *LoggingResponseWriter) StatusCode() int {
 	if w.code == 0 {
 		return http.StatusOK
@@ -65,6 +89,12 @@ func (w *LoggingResponseWriter) StatusCode() int {
 	return w.code
 }
 
+// Body returns the response body, this will be nil for streaming responses.
 func (w *LoggingResponseWriter) Body() *bytes.Buffer {
 	return w.body
 }
+
+// Size returns the number of response body bytes written to the client.
+func (w *LoggingResponseWriter) Size() int {
+	return w.size
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"bytes"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/spf13/pflag"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	servererrors "github.com/unikorn-cloud/core/pkg/server/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Options defines configurable validation options.
+type Options struct {
+	// ValidateResponses checks responses against the schema too.  This is
+	// intended for debug and testing, responses are buffered and an invalid
+	// response is replaced with a server error.
+	ValidateResponses bool
+}
+
+// AddFlags adds the options to the CLI flags.
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	f.BoolVar(&o.ValidateResponses, "openapi-validate-responses", false, "Validate responses against the OpenAPI schema (debug only)")
+}
+
+const (
+	// bodyLocation is the location of request body errors.
+	bodyLocation = "request body"
+
+	// reasonInvalid is used when there is no more specific reason for a
+	// validation failure.
+	reasonInvalid = "invalid"
+)
+
+// fieldError describes a validation failure of a single field.
+type fieldError struct {
+	// location is where the field is e.g. "query parameter limit" or
+	// "request body".
+	location string
+
+	// pointer is a JSON pointer to the field within a structured value.
+	pointer string
+
+	// reason is a terse reason for the failure, typically the schema
+	// field that failed validation e.g. "required" or "pattern".
+	reason string
+
+	// message describes why validation failed.
+	message string
+}
+
+// String returns a human readable representation of the error.
+func (e *fieldError) String() string {
+	location := e.location
+
+	if e.pointer != "" {
+		location += " " + e.pointer
+	}
+
+	return location + ": " + e.message
+}
+
+// detail returns the error in a form that can be returned to the client.  Pointers
+// are only reported for the request body, as they are relative to that document.
+func (e *fieldError) detail() servererrors.Detail {
+	detail := servererrors.Detail{
+		Reason:  e.reason,
+		Message: e.String(),
+	}
+
+	if e.location == bodyLocation {
+		detail.Pointer = e.pointer
+	}
+
+	return detail
+}
+
+// schemaFieldErrors unpacks schema errors, which may be aggregated, into
+// individual fields.  Note that both aggregate and wrapping errors implement
+// unwrapping, so errors.As would skip a level, hence the type switch.
+func schemaFieldErrors(location string, err error) []fieldError {
+	//nolint:errorlint
+	switch t := err.(type) {
+	case openapi3.MultiError:
+		var out []fieldError
+
+		for _, e := range t {
+			out = append(out, schemaFieldErrors(location, e)...)
+		}
+
+		return out
+	case *openapi3.SchemaError:
+		var pointer string
+
+		if path := t.JSONPointer(); len(path) > 0 {
+			pointer = "/" + strings.Join(path, "/")
+		}
+
+		reason := t.SchemaField
+		if reason == "" {
+			reason = reasonInvalid
+		}
+
+		return []fieldError{
+			{
+				location: location,
+				pointer:  pointer,
+				reason:   reason,
+				message:  t.Reason,
+			},
+		}
+	}
+
+	return []fieldError{
+		{
+			location: location,
+			reason:   reasonInvalid,
+			message:  err.Error(),
+		},
+	}
+}
+
+// requestFieldErrors unpacks request validation errors into individual fields.
+func requestFieldErrors(err error) []fieldError {
+	//nolint:errorlint
+	switch t := err.(type) {
+	case openapi3.MultiError:
+		var out []fieldError
+
+		for _, e := range t {
+			out = append(out, requestFieldErrors(e)...)
+		}
+
+		return out
+	case *openapi3filter.RequestError:
+		location := "request"
+
+		switch {
+		case t.Parameter != nil:
+			location = t.Parameter.In + " parameter " + t.Parameter.Name
+		case t.RequestBody != nil:
+			location = bodyLocation
+		}
+
+		if t.Err == nil {
+			return []fieldError{
+				{
+					location: location,
+					reason:   reasonInvalid,
+					message:  t.Reason,
+				},
+			}
+		}
+
+		return schemaFieldErrors(location, t.Err)
+	}
+
+	return []fieldError{
+		{
+			location: "request",
+			reason:   reasonInvalid,
+			message:  err.Error(),
+		},
+	}
+}
+
+// validationError creates a client facing error with field level detail.
+func validationError(err error) *servererrors.Error {
+	var httpError *servererrors.Error
+
+	var maxBytesError *http.MaxBytesError
+
+	// Reading the body may fail, e.g. it's too large or corrupt, in which
+	// case report that rather than a validation failure.
+	switch {
+	case goerrors.As(err, &httpError):
+		return httpError
+	case goerrors.As(err, &maxBytesError):
+		return servererrors.HTTPRequestEntityTooLarge(fmt.Sprintf("request body exceeds the maximum size of %d bytes", maxBytesError.Limit))
+	}
+
+	fieldErrors := requestFieldErrors(err)
+
+	descriptions := make([]string, len(fieldErrors))
+	details := make([]servererrors.Detail, len(fieldErrors))
+
+	for i := range fieldErrors {
+		descriptions[i] = fieldErrors[i].String()
+		details[i] = fieldErrors[i].detail()
+	}
+
+	return servererrors.OAuth2InvalidRequest("request validation failed: " + strings.Join(descriptions, "; ")).WithDetails(details...).WithError(err)
+}
+
+// bufferingResponseWriter captures a response so it can be validated before
+// being sent to the client.
+type bufferingResponseWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+// Check the correct interface is implmented.
+var _ http.ResponseWriter = &bufferingResponseWriter{}
+
+func newBufferingResponseWriter() *bufferingResponseWriter {
+	return &bufferingResponseWriter{
+		header: http.Header{},
+		code:   http.StatusOK,
+	}
+}
+
+func (w *bufferingResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferingResponseWriter) Write(body []byte) (int, error) {
+	return w.body.Write(body)
+}
+
+func (w *bufferingResponseWriter) WriteHeader(statusCode int) {
+	w.code = statusCode
+}
+
+// flush sends the buffered response to the client.
+func (w *bufferingResponseWriter) flush(next http.ResponseWriter) {
+	for key, values := range w.header {
+		next.Header()[key] = values
+	}
+
+	next.WriteHeader(w.code)
+
+	if _, err := next.Write(w.body.Bytes()); err != nil {
+		log.Log.Error(err, "failed to write response")
+	}
+}
+
+// Middleware validates requests against the OpenAPI schema.  Requests that
+// don't match any route are passed through to the router for handling.
+// Authentication is expected to be handled elsewhere.  Nil options are treated
+// as the zero value.
+func Middleware(schema *openapi.Schema, options *Options) func(http.Handler) http.Handler {
+	if options == nil {
+		options = &Options{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, params, err := schema.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: params,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					MultiError:         true,
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				},
+			}
+
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				servererrors.HandleError(w, r, validationError(err))
+
+				return
+			}
+
+			// Event streams are unbounded, so cannot be buffered for validation.
+			if !options.ValidateResponses || openapi.IsEventStream(route) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writer := newBufferingResponseWriter()
+
+			next.ServeHTTP(writer, r)
+
+			responseInput := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 writer.code,
+				Header:                 writer.header,
+				Body:                   io.NopCloser(bytes.NewReader(writer.body.Bytes())),
+				Options: &openapi3filter.Options{
+					MultiError:            true,
+					IncludeResponseStatus: true,
+				},
+			}
+
+			if err := openapi3filter.ValidateResponse(r.Context(), responseInput); err != nil {
+				servererrors.HandleError(w, r, servererrors.OAuth2ServerError("response validation failed").WithError(err))
+
+				return
+			}
+
+			writer.flush(w)
+		})
+	}
+}
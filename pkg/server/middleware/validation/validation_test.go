@@ -0,0 +1,309 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/validation"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /widgets:
+    get:
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          minimum: 1
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+                  pattern: '^[a-z]+$'
+                size:
+                  type: integer
+      responses:
+        '201':
+          description: created
+  /widgets/events:
+    get:
+      responses:
+        '200':
+          description: ok
+          content:
+            text/event-stream:
+              schema:
+                type: string
+`
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// response is a handler that returns a fixed JSON body.
+func response(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+// TestRequestValidation checks requests are validated against the schema and
+// that failures are reported with field level detail.
+func TestRequestValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		limit   int64
+		status  int
+		details []errors.Detail
+	}{
+		{
+			name:   "ValidGet",
+			method: http.MethodGet,
+			path:   "/widgets?limit=10",
+			status: http.StatusOK,
+		},
+		{
+			name:   "InvalidQuery",
+			method: http.MethodGet,
+			path:   "/widgets?limit=0",
+			status: http.StatusBadRequest,
+			details: []errors.Detail{
+				{Reason: "minimum"},
+			},
+		},
+		{
+			name:   "ValidPost",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{"name":"foo"}`,
+			status: http.StatusCreated,
+		},
+		{
+			name:   "MissingRequired",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{}`,
+			status: http.StatusBadRequest,
+			details: []errors.Detail{
+				{Pointer: "/name", Reason: "required"},
+			},
+		},
+		{
+			name:   "MultipleErrors",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{"name":"FOO","size":"big"}`,
+			status: http.StatusBadRequest,
+			details: []errors.Detail{
+				{Pointer: "/name", Reason: "pattern"},
+				{Pointer: "/size", Reason: "type"},
+			},
+		},
+		{
+			name:   "TooLarge",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{"name":"foo"}`,
+			limit:  4,
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "UnknownRoute",
+			method: http.MethodGet,
+			path:   "/gadgets",
+			status: http.StatusOK,
+		},
+	}
+
+	handler := validation.Middleware(testSchema(t), &validation.Options{})(response(`{"name":"foo"}`))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			r.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+
+			if test.limit > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, test.limit)
+			}
+
+			handler.ServeHTTP(w, r)
+
+			require.Equal(t, test.status, w.Code, w.Body.String())
+
+			if test.details == nil {
+				return
+			}
+
+			var e errors.OAuth2Error
+
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+			require.Equal(t, errors.InvalidRequest, e.Error)
+			require.Len(t, e.Details, len(test.details))
+
+			// Message is human readable, so just check it's there,
+			// and ordering is not guaranteed across fields.
+			for _, expected := range test.details {
+				found := false
+
+				for _, actual := range e.Details {
+					if actual.Pointer == expected.Pointer && actual.Reason == expected.Reason {
+						require.NotEmpty(t, actual.Message)
+
+						found = true
+					}
+				}
+
+				require.True(t, found, "missing detail %v in %v", expected, e.Details)
+			}
+		})
+	}
+}
+
+// TestResponseValidation checks responses are only validated when enabled, and
+// invalid responses are replaced with a server error.
+func TestResponseValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options *validation.Options
+		body    string
+		status  int
+	}{
+		{
+			name:   "NilOptions",
+			body:   `{}`,
+			status: http.StatusOK,
+		},
+		{
+			name:    "Disabled",
+			options: &validation.Options{},
+			body:    `{}`,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "Valid",
+			options: &validation.Options{ValidateResponses: true},
+			body:    `{"name":"foo"}`,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "Invalid",
+			options: &validation.Options{ValidateResponses: true},
+			body:    `{}`,
+			status:  http.StatusInternalServerError,
+		},
+	}
+
+	schema := testSchema(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := validation.Middleware(schema, test.options)(response(test.body))
+
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/widgets", nil))
+
+			require.Equal(t, test.status, w.Code)
+
+			if test.status == http.StatusOK {
+				require.JSONEq(t, test.body, w.Body.String())
+				require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// TestResponseValidationEventStream checks event streams are passed through to the
+// client as they are written, rather than being buffered for validation, regardless
+// of what the client accepts.
+func TestResponseValidationEventStream(t *testing.T) {
+	t.Parallel()
+
+	handler := validation.Middleware(testSchema(t), &validation.Options{ValidateResponses: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("id: 1\n\n"))
+
+		require.NoError(t, http.NewResponseController(w).Flush())
+	}))
+
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/widgets/events", nil))
+
+	require.True(t, w.Flushed)
+	require.Equal(t, "id: 1\n\n", w.Body.String())
+}
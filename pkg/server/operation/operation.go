@@ -0,0 +1,345 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operation
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/principal"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+	coreutil "github.com/unikorn-cloud/core/pkg/util"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var (
+	// ErrCompleted is raised when updating an operation that has already
+	// succeeded or failed.
+	ErrCompleted = goerrors.New("operation already completed")
+
+	// ErrPanic is raised when work panics.
+	ErrPanic = goerrors.New("operation panicked")
+)
+
+type Options struct {
+	// Retention is how long completed operations are kept, giving clients
+	// time to poll for the result, before they are deleted.
+	Retention time.Duration
+
+	// ReapInterval is how often completed operations are checked for
+	// expiry.
+	ReapInterval time.Duration
+}
+
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	f.DurationVar(&o.Retention, "operation-retention", 24*time.Hour, "How long completed operations are kept before being deleted")
+	f.DurationVar(&o.ReapInterval, "operation-reap-interval", 10*time.Minute, "How often completed operations are checked for expiry")
+}
+
+// Client creates and updates operations that track asynchronous API requests.
+type Client struct {
+	// client is a Kubernetes client.
+	client client.Client
+	// namespace is where operations are stored.
+	namespace string
+}
+
+// New creates a new operation client.
+func New(client client.Client, namespace string) *Client {
+	return &Client{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Create creates a pending operation for a resource, where resource is the API
+// path of the resource being acted upon.  Labels should include the organization
+// and project the resource is scoped to, so reads can be scoped in the same way.
+func (c *Client) Create(ctx context.Context, resource string, labels map[string]string) (*unikornv1.Operation, error) {
+	operation := &unikornv1.Operation{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.namespace,
+			Name:      coreutil.GenerateResourceID(),
+			Labels:    labels,
+		},
+		Spec: unikornv1.OperationSpec{
+			Resource: resource,
+		},
+		Status: unikornv1.OperationStatus{
+			Phase: unikornv1.OperationPhasePending,
+		},
+	}
+
+	if p, err := principal.FromContext(ctx); err == nil {
+		operation.Annotations = map[string]string{
+			constants.CreatorAnnotation: p.Subject,
+		}
+	}
+
+	if err := c.client.Create(ctx, operation); err != nil {
+		return nil, errors.OAuth2ServerError("unable to create operation").WithError(err)
+	}
+
+	return operation, nil
+}
+
+// Get reads an operation.  The operation must have all the provided labels,
+// typically the organization and project from the request path, otherwise it
+// will be reported as not found.
+func (c *Client) Get(ctx context.Context, id string, labels map[string]string) (*unikornv1.Operation, error) {
+	operation := &unikornv1.Operation{}
+
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: c.namespace, Name: id}, operation); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, errors.HTTPNotFound().WithError(err)
+		}
+
+		return nil, errors.OAuth2ServerError("unable to read operation").WithError(err)
+	}
+
+	for key, value := range labels {
+		if operation.Labels[key] != value {
+			return nil, errors.HTTPNotFound()
+		}
+	}
+
+	return operation, nil
+}
+
+// update applies a mutation to an operation, retrying on conflict.
+func (c *Client) update(ctx context.Context, id string, mutate func(*unikornv1.Operation)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		operation := &unikornv1.Operation{}
+
+		if err := c.client.Get(ctx, client.ObjectKey{Namespace: c.namespace, Name: id}, operation); err != nil {
+			return err
+		}
+
+		if operation.Completed() {
+			return ErrCompleted
+		}
+
+		mutate(operation)
+
+		return c.client.Update(ctx, operation)
+	})
+}
+
+// Start marks the operation as running.
+func (c *Client) Start(ctx context.Context, id string) error {
+	return c.update(ctx, id, func(operation *unikornv1.Operation) {
+		operation.Status.Phase = unikornv1.OperationPhaseRunning
+	})
+}
+
+// Progress records a percentage estimate of how complete the operation is.
+func (c *Client) Progress(ctx context.Context, id string, percent int) error {
+	percent = max(0, min(percent, 100))
+
+	return c.update(ctx, id, func(operation *unikornv1.Operation) {
+		operation.Status.Phase = unikornv1.OperationPhaseRunning
+		operation.Status.Progress = &percent
+	})
+}
+
+// Succeed marks the operation as successfully completed.
+func (c *Client) Succeed(ctx context.Context, id string) error {
+	return c.update(ctx, id, func(operation *unikornv1.Operation) {
+		percent := 100
+		now := metav1.Now()
+
+		operation.Status.Phase = unikornv1.OperationPhaseSucceeded
+		operation.Status.Progress = &percent
+		operation.Status.CompletionTime = &now
+	})
+}
+
+// Fail marks the operation as failed.  As with HandleError, only the code and
+// description of API errors are reported to the client, anything else is
+// reported as a server error and the detail is logged.
+func (c *Client) Fail(ctx context.Context, id string, err error) error {
+	var httpError *errors.Error
+
+	if !goerrors.As(err, &httpError) {
+		log.FromContext(ctx).Error(err, "operation failed", "id", id)
+
+		httpError = errors.OAuth2ServerError("an unexpected error occurred")
+	}
+
+	return c.update(ctx, id, func(operation *unikornv1.Operation) {
+		now := metav1.Now()
+
+		operation.Status.Phase = unikornv1.OperationPhaseFailed
+		operation.Status.Error = &unikornv1.OperationError{
+			Code:        string(httpError.Type()),
+			Description: httpError.Error(),
+		}
+		operation.Status.CompletionTime = &now
+	})
+}
+
+// Work is an asynchronous unit of work that may optionally report progress.
+type Work func(ctx context.Context, progress func(percent int)) error
+
+// Run performs the work in the background, updating the operation as it starts,
+// progresses and completes.  The work is detached from the request context's
+// cancellation as this will be cancelled once the response has been sent.  Panics
+// in the work are recovered, and fail the operation.
+func (c *Client) Run(ctx context.Context, id string, work Work) {
+	ctx = context.WithoutCancel(ctx)
+
+	log := log.FromContext(ctx).WithValues("operation", id)
+
+	go func() {
+		// A panic here would take down the whole server, so treat it as a
+		// failure, otherwise the operation will never complete.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err := fmt.Errorf("%w: %v", ErrPanic, recovered)
+
+				if err := c.Fail(ctx, id, err); err != nil {
+					log.Error(err, "failed to fail operation")
+				}
+			}
+		}()
+
+		if err := c.Start(ctx, id); err != nil {
+			log.Error(err, "failed to start operation")
+
+			return
+		}
+
+		progress := func(percent int) {
+			if err := c.Progress(ctx, id, percent); err != nil {
+				log.Error(err, "failed to update operation progress")
+			}
+		}
+
+		if err := work(ctx, progress); err != nil {
+			if err := c.Fail(ctx, id, err); err != nil {
+				log.Error(err, "failed to fail operation")
+			}
+
+			return
+		}
+
+		if err := c.Succeed(ctx, id); err != nil {
+			log.Error(err, "failed to complete operation")
+		}
+	}()
+}
+
+// Reap deletes operations that completed longer ago than the retention period.
+func (c *Client) Reap(ctx context.Context, retention time.Duration) error {
+	operations := &unikornv1.OperationList{}
+
+	if err := c.client.List(ctx, operations, client.InNamespace(c.namespace)); err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-retention)
+
+	for i := range operations.Items {
+		operation := &operations.Items[i]
+
+		if !operation.Completed() || operation.Status.CompletionTime == nil || operation.Status.CompletionTime.After(cutoff) {
+			continue
+		}
+
+		// Another replica may have beaten us to it.
+		if err := c.client.Delete(ctx, operation); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunReaper periodically deletes expired operations until the context is cancelled.
+// It is safe to run this on every server replica.
+func (c *Client) RunReaper(ctx context.Context, options *Options) {
+	log := log.FromContext(ctx)
+
+	ticker := time.NewTicker(options.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Reap(ctx, options.Retention); err != nil {
+			log.Error(err, "failed to reap operations")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Convert converts an operation to its API representation.
+func Convert(in *unikornv1.Operation) *openapi.Operation {
+	out := &openapi.Operation{
+		Id:           in.Name,
+		Status:       openapi.OperationStatus(in.Status.Phase),
+		Progress:     in.Status.Progress,
+		CreationTime: in.CreationTimestamp.Time,
+	}
+
+	if out.Status == "" {
+		out.Status = openapi.Pending
+	}
+
+	if in.Spec.Resource != "" {
+		out.Result = &in.Spec.Resource
+	}
+
+	if in.Status.Error != nil {
+		out.Error = &openapi.Error{
+			Error:            openapi.ErrorError(in.Status.Error.Code),
+			ErrorDescription: in.Status.Error.Description,
+		}
+	}
+
+	if in.Status.CompletionTime != nil {
+		out.CompletionTime = &in.Status.CompletionTime.Time
+	}
+
+	return out
+}
+
+// WriteAccepted responds to a request that will be fulfilled asynchronously, with
+// a link to where the operation can be polled, and the operation itself.
+func WriteAccepted(w http.ResponseWriter, r *http.Request, location string, in *unikornv1.Operation) {
+	w.Header().Set("Location", location)
+
+	util.WriteJSONResponse(w, r, http.StatusAccepted, Convert(in))
+}
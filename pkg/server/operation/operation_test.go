@@ -0,0 +1,381 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operation_test
+
+import (
+	"context"
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/operation"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "test"
+
+var errTest = goerrors.New("test error")
+
+func testClient(t *testing.T, objects ...client.Object) (client.Client, *operation.Client) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, unikornv1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+
+	return c, operation.New(c, testNamespace)
+}
+
+// TestLifecycle checks operations progress through their phases, and cannot be
+// modified once completed.
+func TestLifecycle(t *testing.T) {
+	t.Parallel()
+
+	_, c := testClient(t)
+
+	labels := map[string]string{"organization": "a"}
+
+	created, err := c.Create(t.Context(), "/widgets/foo", labels)
+	require.NoError(t, err)
+	require.Equal(t, unikornv1.OperationPhasePending, created.Status.Phase)
+
+	id := created.Name
+
+	require.NoError(t, c.Start(t.Context(), id))
+	require.NoError(t, c.Progress(t.Context(), id, 150))
+
+	o, err := c.Get(t.Context(), id, labels)
+	require.NoError(t, err)
+	require.Equal(t, unikornv1.OperationPhaseRunning, o.Status.Phase)
+	require.Equal(t, ptr.To(100), o.Status.Progress)
+
+	require.NoError(t, c.Succeed(t.Context(), id))
+
+	o, err = c.Get(t.Context(), id, labels)
+	require.NoError(t, err)
+	require.Equal(t, unikornv1.OperationPhaseSucceeded, o.Status.Phase)
+	require.NotNil(t, o.Status.CompletionTime)
+
+	require.ErrorIs(t, c.Start(t.Context(), id), operation.ErrCompleted)
+	require.ErrorIs(t, c.Progress(t.Context(), id, 50), operation.ErrCompleted)
+	require.ErrorIs(t, c.Succeed(t.Context(), id), operation.ErrCompleted)
+	require.ErrorIs(t, c.Fail(t.Context(), id, errTest), operation.ErrCompleted)
+
+	// Reads are scoped to the labels.
+	_, err = c.Get(t.Context(), id, map[string]string{"organization": "b"})
+	require.True(t, errors.IsHTTPNotFound(err))
+
+	_, err = c.Get(t.Context(), "missing", nil)
+	require.True(t, errors.IsHTTPNotFound(err))
+}
+
+// TestFail checks only API errors are reported to the client verbatim.
+func TestFail(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		err      error
+		expected *unikornv1.OperationError
+	}{
+		{
+			name: "APIError",
+			err:  errors.HTTPConflict(),
+			expected: &unikornv1.OperationError{
+				Code:        "conflict",
+				Description: "the requested resource already exists",
+			},
+		},
+		{
+			name: "InternalError",
+			err:  errTest,
+			expected: &unikornv1.OperationError{
+				Code:        "server_error",
+				Description: "an unexpected error occurred",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, c := testClient(t)
+
+			created, err := c.Create(t.Context(), "", nil)
+			require.NoError(t, err)
+
+			require.NoError(t, c.Fail(t.Context(), created.Name, test.err))
+
+			o, err := c.Get(t.Context(), created.Name, nil)
+			require.NoError(t, err)
+			require.Equal(t, unikornv1.OperationPhaseFailed, o.Status.Phase)
+			require.Equal(t, test.expected, o.Status.Error)
+			require.NotNil(t, o.Status.CompletionTime)
+		})
+	}
+}
+
+// TestRun checks background work completes the operation, including when the work
+// panics.
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		work  operation.Work
+		phase unikornv1.OperationPhase
+	}{
+		{
+			name: "Success",
+			work: func(ctx context.Context, progress func(int)) error {
+				progress(50)
+				return nil
+			},
+			phase: unikornv1.OperationPhaseSucceeded,
+		},
+		{
+			name: "Error",
+			work: func(ctx context.Context, progress func(int)) error {
+				return errTest
+			},
+			phase: unikornv1.OperationPhaseFailed,
+		},
+		{
+			name: "Panic",
+			work: func(ctx context.Context, progress func(int)) error {
+				panic("boom")
+			},
+			phase: unikornv1.OperationPhaseFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, c := testClient(t)
+
+			created, err := c.Create(t.Context(), "", nil)
+			require.NoError(t, err)
+
+			// The request context is cancelled once the response is sent.
+			ctx, cancel := context.WithCancel(t.Context())
+
+			c.Run(ctx, created.Name, test.work)
+
+			cancel()
+
+			require.Eventually(t, func() bool {
+				o, err := c.Get(t.Context(), created.Name, nil)
+
+				return err == nil && o.Status.Phase == test.phase
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+// TestRunStartFailure checks work isn't done if the operation cannot be started.
+func TestRunStartFailure(t *testing.T) {
+	t.Parallel()
+
+	_, c := testClient(t)
+
+	created, err := c.Create(t.Context(), "", nil)
+	require.NoError(t, err)
+	require.NoError(t, c.Succeed(t.Context(), created.Name))
+
+	called := make(chan struct{})
+
+	c.Run(t.Context(), created.Name, func(ctx context.Context, progress func(int)) error {
+		close(called)
+		return errTest
+	})
+
+	select {
+	case <-called:
+		t.Fatal("work run for a completed operation")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	o, err := c.Get(t.Context(), created.Name, nil)
+	require.NoError(t, err)
+	require.Equal(t, unikornv1.OperationPhaseSucceeded, o.Status.Phase)
+}
+
+// TestReap checks only operations that completed before the retention period are
+// deleted.
+func TestReap(t *testing.T) {
+	t.Parallel()
+
+	old := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	recent := metav1.NewTime(time.Now().Add(-time.Minute))
+
+	newOperation := func(name string, phase unikornv1.OperationPhase, completionTime *metav1.Time) *unikornv1.Operation {
+		return &unikornv1.Operation{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      name,
+			},
+			Status: unikornv1.OperationStatus{
+				Phase:          phase,
+				CompletionTime: completionTime,
+			},
+		}
+	}
+
+	objects := []client.Object{
+		newOperation("expired-succeeded", unikornv1.OperationPhaseSucceeded, &old),
+		newOperation("expired-failed", unikornv1.OperationPhaseFailed, &old),
+		newOperation("recent", unikornv1.OperationPhaseSucceeded, &recent),
+		newOperation("running", unikornv1.OperationPhaseRunning, nil),
+	}
+
+	k, c := testClient(t, objects...)
+
+	require.NoError(t, c.Reap(t.Context(), time.Hour))
+
+	operations := &unikornv1.OperationList{}
+	require.NoError(t, k.List(t.Context(), operations))
+
+	names := make([]string, len(operations.Items))
+
+	for i := range operations.Items {
+		names[i] = operations.Items[i].Name
+	}
+
+	require.ElementsMatch(t, []string{"recent", "running"}, names)
+}
+
+// TestConvert checks operations are converted to their API representation.
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	creationTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	completionTime := creationTime.Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		in       *unikornv1.Operation
+		expected *openapi.Operation
+	}{
+		{
+			name: "Pending",
+			in: &unikornv1.Operation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "foo",
+					CreationTimestamp: metav1.NewTime(creationTime),
+				},
+			},
+			expected: &openapi.Operation{
+				Id:           "foo",
+				Status:       openapi.Pending,
+				CreationTime: creationTime,
+			},
+		},
+		{
+			name: "Succeeded",
+			in: &unikornv1.Operation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "foo",
+					CreationTimestamp: metav1.NewTime(creationTime),
+				},
+				Spec: unikornv1.OperationSpec{
+					Resource: "/widgets/foo",
+				},
+				Status: unikornv1.OperationStatus{
+					Phase:          unikornv1.OperationPhaseSucceeded,
+					Progress:       ptr.To(100),
+					CompletionTime: ptr.To(metav1.NewTime(completionTime)),
+				},
+			},
+			expected: &openapi.Operation{
+				Id:             "foo",
+				Status:         openapi.Succeeded,
+				Progress:       ptr.To(100),
+				Result:         ptr.To("/widgets/foo"),
+				CreationTime:   creationTime,
+				CompletionTime: &completionTime,
+			},
+		},
+		{
+			name: "Failed",
+			in: &unikornv1.Operation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "foo",
+					CreationTimestamp: metav1.NewTime(creationTime),
+				},
+				Status: unikornv1.OperationStatus{
+					Phase: unikornv1.OperationPhaseFailed,
+					Error: &unikornv1.OperationError{
+						Code:        "conflict",
+						Description: "resource exists",
+					},
+					CompletionTime: ptr.To(metav1.NewTime(completionTime)),
+				},
+			},
+			expected: &openapi.Operation{
+				Id:     "foo",
+				Status: openapi.Failed,
+				Error: &openapi.Error{
+					Error:            openapi.Conflict,
+					ErrorDescription: "resource exists",
+				},
+				CreationTime:   creationTime,
+				CompletionTime: &completionTime,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.expected, operation.Convert(test.in))
+		})
+	}
+}
+
+// TestWriteAccepted checks accepted responses link to the operation.
+func TestWriteAccepted(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	operation.WriteAccepted(w, httptest.NewRequest(http.MethodPost, "/", nil), "/operations/foo", &unikornv1.Operation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+		},
+	})
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "/operations/foo", w.Header().Get("Location"))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options defines common server options.
+type Options struct {
+	// ListenAddress is where the API is served.
+	ListenAddress string
+
+	// HealthAddress is where health and readiness probes are served.
+	HealthAddress string
+
+	// MetricsAddress is where Prometheus metrics are served, if empty
+	// metrics are not served.
+	MetricsAddress string
+
+	// ReadTimeout is the maximum time to read a request, including the body.
+	ReadTimeout time.Duration
+
+	// ReadHeaderTimeout is the maximum time to read request headers.
+	ReadHeaderTimeout time.Duration
+
+	// WriteTimeout is the maximum time to write a response.
+	WriteTimeout time.Duration
+
+	// IdleTimeout is how long keep-alive connections are held open.
+	IdleTimeout time.Duration
+
+	// MaxRequestSize is the maximum size of a request body in bytes.
+	MaxRequestSize int64
+
+	// RequestTimeout is the maximum time a request handler may run for.
+	RequestTimeout time.Duration
+
+	// ShutdownDelay is how long readiness reports failure for before we
+	// stop accepting connections, allowing load balancers to catch up.
+	ShutdownDelay time.Duration
+
+	// ShutdownTimeout is how long in-flight requests are given to complete
+	// on shutdown.
+	ShutdownTimeout time.Duration
+
+	// TLSSecretNamespace is the namespace of the TLS secret.
+	TLSSecretNamespace string
+
+	// TLSSecretName is a secret, typically provisioned by cert-manager,
+	// containing the TLS certificate and private key.  If empty the API
+	// is served over plain HTTP.
+	TLSSecretName string
+
+	// TLSReloadInterval is how often the TLS secret is checked for renewal.
+	TLSReloadInterval time.Duration
+}
+
+func (o *Options) AddFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.ListenAddress, "server-listen-address", ":6080", "API server listener address")
+	f.StringVar(&o.HealthAddress, "server-health-address", ":8081", "Health and readiness probe listener address")
+	f.StringVar(&o.MetricsAddress, "server-metrics-address", ":8080", "Prometheus metrics listener address, empty to disable")
+	f.DurationVar(&o.ReadTimeout, "server-read-timeout", 10*time.Second, "How long to wait for the client to send the request body")
+	f.DurationVar(&o.ReadHeaderTimeout, "server-read-header-timeout", 2*time.Second, "How long to wait for the client to send headers")
+	f.DurationVar(&o.WriteTimeout, "server-write-timeout", 30*time.Second, "How long to wait for the API to respond, this should be longer than the request timeout")
+	f.DurationVar(&o.IdleTimeout, "server-idle-timeout", 2*time.Minute, "How long to keep idle connections open")
+	f.Int64Var(&o.MaxRequestSize, "server-max-request-size", 16<<20, "Maximum size in bytes of a request body")
+	f.DurationVar(&o.RequestTimeout, "server-request-timeout", 25*time.Second, "How long a request can take to be handled")
+	f.DurationVar(&o.ShutdownDelay, "server-shutdown-delay", 5*time.Second, "How long to report not ready before draining connections on shutdown")
+	f.DurationVar(&o.ShutdownTimeout, "server-shutdown-timeout", 30*time.Second, "How long to allow in-flight requests to complete on shutdown")
+	f.StringVar(&o.TLSSecretNamespace, "server-tls-secret-namespace", "", "Namespace of the TLS secret")
+	f.StringVar(&o.TLSSecretName, "server-tls-secret-name", "", "Secret containing the TLS certificate and key, typically provisioned by cert-manager")
+	f.DurationVar(&o.TLSReloadInterval, "server-tls-reload-interval", time.Minute, "How often to check the TLS secret for renewal")
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package principal
+
+import (
+	"context"
+	"slices"
+
+	"github.com/unikorn-cloud/core/pkg/errors"
+)
+
+// Principal is the authenticated actor performing an API request.
+type Principal struct {
+	// Issuer is the identity provider that issued the credentials.
+	Issuer string
+
+	// Subject uniquely identifies the actor within the issuer.
+	Subject string
+
+	// Scopes are the scopes granted to the actor's credentials.
+	Scopes []string
+
+	// Token is the raw bearer token, and may be used to make onward
+	// requests to other services on the actor's behalf.
+	Token string
+}
+
+// HasScope returns true if the principal has been granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Recorder captures the principal when it's added to a descendant context, making
+// it visible to outer middleware e.g. for access logging.
+type Recorder struct {
+	// Principal is set once authenticated.
+	Principal *Principal
+}
+
+type contextkeyType int
+
+const (
+	// principalKey is used to propagate the principal.
+	principalKey contextkeyType = iota
+
+	// recorderKey is used to propagate a recorder.
+	recorderKey
+)
+
+// NewRecorderContext adds a recorder to the context.
+func NewRecorderContext(ctx context.Context) (context.Context, *Recorder) {
+	recorder := &Recorder{}
+
+	return context.WithValue(ctx, recorderKey, recorder), recorder
+}
+
+// NewContext adds the principal to the context.
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	if recorder, ok := ctx.Value(recorderKey).(*Recorder); ok {
+		recorder.Principal = principal
+	}
+
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// FromContext returns the principal from the context.
+func FromContext(ctx context.Context) (*Principal, error) {
+	if value := ctx.Value(principalKey); value != nil {
+		if principal, ok := value.(*Principal); ok {
+			return principal, nil
+		}
+	}
+
+	return nil, errors.ErrInvalidContext
+}
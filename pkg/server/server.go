@@ -0,0 +1,519 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/pflag"
+	"golang.org/x/sync/errgroup"
+
+	coreclient "github.com/unikorn-cloud/core/pkg/client"
+	"github.com/unikorn-cloud/core/pkg/manager/otel"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/accesslog"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/audit"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authentication"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/bodylimit"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/compression"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/cors"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/idempotency"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/opentelemetry"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/ratelimit"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/recovery"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/timeout"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/validation"
+
+	klog "k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// ErrTLSSecret is raised when the TLS secret is misconfigured.
+	ErrTLSSecret = errors.New("tls secret invalid")
+)
+
+// HandlerOptions abstracts service specific flags.
+type HandlerOptions interface {
+	// AddFlags adds a set of flags to the flagset.
+	AddFlags(f *pflag.FlagSet)
+}
+
+// HandlerFactory allows creation of a Unikorn HTTP service with minimal code.
+type HandlerFactory interface {
+	// Metadata returns the application, version and revision.
+	Metadata() (string, string, string)
+
+	// Options may be nil, otherwise it's a service specific set of
+	// options that are added to the flagset on start up and passed to the
+	// handler.
+	Options() HandlerOptions
+
+	// Handler returns the API handler, typically a router generated from
+	// the schema.  This is wrapped by the common middleware.
+	Handler(options HandlerOptions, client client.Client, schema *openapi.Schema) (http.Handler, error)
+
+	// Schemes allows services to add types to the client beyond
+	// the defaults defined in this repository.
+	Schemes() []coreclient.SchemeAdder
+}
+
+// AuthorizedHandlerFactory is optionally implemented by a HandlerFactory to
+// enforce the permissions declared by the schema.
+type AuthorizedHandlerFactory interface {
+	// Authorizer returns an authorizer to make access decisions.
+	Authorizer(client client.Client) authorization.Authorizer
+}
+
+// AuditedHandlerFactory is optionally implemented by a HandlerFactory to write
+// audit records to custom sinks, in addition to those configured by CLI flags.
+type AuditedHandlerFactory interface {
+	// AuditSinks returns additional audit sinks.
+	AuditSinks(client client.Client) []audit.Sink
+}
+
+// IdempotentHandlerFactory is optionally implemented by a HandlerFactory to
+// share idempotency keys between replicas.
+type IdempotentHandlerFactory interface {
+	// IdempotencyStore returns a store for idempotency keys.
+	IdempotencyStore(client client.Client) idempotency.Store
+}
+
+// CachedHandlerFactory is optionally implemented by a HandlerFactory to
+// restrict what is cached by informers.  These are used as defaults and
+// may be overridden by CLI flags.
+type CachedHandlerFactory interface {
+	// CacheOptions returns cache restrictions.
+	CacheOptions() *coreclient.CacheOptions
+}
+
+// middlewareOptions collects all the common middleware options.
+type middlewareOptions struct {
+	cors           cors.Options
+	accesslog      accesslog.Options
+	audit          audit.Options
+	compression    compression.Options
+	idempotency    idempotency.Options
+	opentelemetry  opentelemetry.Options
+	authentication authentication.Options
+	ratelimit      ratelimit.Options
+	validation     validation.Options
+}
+
+func (o *middlewareOptions) AddFlags(f *pflag.FlagSet) {
+	o.cors.AddFlags(f)
+	o.accesslog.AddFlags(f)
+	o.audit.AddFlags(f)
+	o.compression.AddFlags(f)
+	o.idempotency.AddFlags(f)
+	o.opentelemetry.AddFlags(f)
+	o.authentication.AddFlags(f)
+	o.ratelimit.AddFlags(f)
+	o.validation.AddFlags(f)
+}
+
+// getHandler wraps the service handler in the common middleware.  Ordering is
+// important here, the first middleware is the outermost.  Tracing needs to come
+// first so all other middleware are able to log with trace context, then access
+// logging so all responses are logged, then compression so both see the size of
+// what was actually sent, then panic recovery, then CORS so that errors are
+// readable by browsers, then request body limits so oversized requests are
+// rejected before any work is done.  Authentication must come before anything
+// that depends on the principal.  Auditing comes before authorization so that
+// denied requests are recorded.  Idempotent requests are replayed after
+// authorization so that access is always checked, and before validation, as the
+// original request was already validated.
+func getHandler(f HandlerFactory, o *Options, m *middlewareOptions, client client.Client, schema *openapi.Schema, auditDispatcher *audit.Dispatcher, handler http.Handler) (http.Handler, error) {
+	application, version, _ := f.Metadata()
+
+	chain := []func(http.Handler) http.Handler{
+		opentelemetry.MiddlewareWithOptions(application, version, schema, &m.opentelemetry),
+		accesslog.Middleware(schema, &m.accesslog),
+		compression.Middleware(&m.compression),
+		recovery.Middleware(),
+		cors.Middleware(schema, &m.cors),
+		bodylimit.Middleware(o.MaxRequestSize),
+		timeout.MiddlewareWithSchema(o.RequestTimeout, schema),
+	}
+
+	// Authentication is optional, services may use their own.
+	if m.authentication.Issuer != "" {
+		authenticator, err := authentication.New(&m.authentication)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, authentication.Middleware(schema, authenticator))
+	}
+
+	chain = append(chain, ratelimit.Middleware(schema, &m.ratelimit))
+	chain = append(chain, audit.Middleware(schema, auditDispatcher, &m.audit))
+
+	if af, ok := f.(AuthorizedHandlerFactory); ok {
+		chain = append(chain, authorization.Middleware(schema, af.Authorizer(client)))
+	}
+
+	var idempotencyStore idempotency.Store = idempotency.NewMemoryStore(m.idempotency.MaxKeys)
+
+	if idf, ok := f.(IdempotentHandlerFactory); ok {
+		idempotencyStore = idf.IdempotencyStore(client)
+	}
+
+	chain = append(chain, idempotency.Middleware(idempotencyStore, &m.idempotency))
+	chain = append(chain, validation.Middleware(schema, &m.validation))
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+
+	return handler, nil
+}
+
+// getAuditDispatcher creates the audit sinks and a dispatcher to write to them.
+// Built in sinks use an uncached client, as audit records are numerous and rarely
+// read, and any audit record resources are reaped until the context is cancelled.
+func getAuditDispatcher(ctx context.Context, f HandlerFactory, m *middlewareOptions, c client.Client) (*audit.Dispatcher, error) {
+	application, _, _ := f.Metadata()
+
+	config, err := clientconfig.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	uncached, err := client.New(config, client.Options{Scheme: c.Scheme(), Mapper: c.RESTMapper()})
+	if err != nil {
+		return nil, err
+	}
+
+	sinks, err := audit.NewSinks(uncached, application, &m.audit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sink := range sinks {
+		if resourceSink, ok := sink.(*audit.ResourceSink); ok {
+			go resourceSink.RunReaper(ctx, &m.audit)
+		}
+	}
+
+	if af, ok := f.(AuditedHandlerFactory); ok {
+		sinks = append(sinks, af.AuditSinks(c)...)
+	}
+
+	return audit.NewDispatcher(sinks, &m.audit), nil
+}
+
+// getTLSConfig configures TLS on the server if requested.
+func getTLSConfig(ctx context.Context, o *Options, server *http.Server) error {
+	if o.TLSSecretName == "" {
+		return nil
+	}
+
+	if o.TLSSecretNamespace == "" {
+		return fmt.Errorf("%w: namespace must be specified", ErrTLSSecret)
+	}
+
+	config, err := clientconfig.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	reader, err := client.New(config, client.Options{})
+	if err != nil {
+		return err
+	}
+
+	key := client.ObjectKey{
+		Namespace: o.TLSSecretNamespace,
+		Name:      o.TLSSecretName,
+	}
+
+	loader, err := newCertificateLoader(ctx, reader, key, o.TLSReloadInterval)
+	if err != nil {
+		return err
+	}
+
+	go loader.run(ctx)
+
+	server.TLSConfig = loader.TLSConfig()
+
+	return nil
+}
+
+// getProbeHandler serves liveness and readiness probes.  Readiness fails once
+// shutdown has started so load balancers stop routing new requests to us.
+func getProbeHandler(ready *atomic.Bool) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return mux
+}
+
+// serve runs a server on a bound listener until it is shutdown.
+func serve(server *http.Server, listener net.Listener) error {
+	var err error
+
+	if server.TLSConfig != nil {
+		err = server.ServeTLS(listener, "", "")
+	} else {
+		err = server.Serve(listener)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// listen binds all server addresses, so failures are reported before we
+// report ready.  On error any bound listeners are closed.
+func listen(servers []*http.Server) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(servers))
+
+	for _, server := range servers {
+		listener, err := net.Listen("tcp", server.Addr)
+		if err != nil {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+
+			return nil, err
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// run serves the API, probes and metrics until the context is cancelled, then
+// shuts down gracefully, allowing in-flight requests to complete.
+func run(ctx context.Context, o *Options, api *http.Server) error {
+	ready := &atomic.Bool{}
+
+	servers := []*http.Server{
+		api,
+		{
+			Addr:              o.HealthAddress,
+			ReadHeaderTimeout: o.ReadHeaderTimeout,
+			Handler:           getProbeHandler(ready),
+		},
+	}
+
+	if o.MetricsAddress != "" {
+		servers = append(servers, &http.Server{
+			Addr:              o.MetricsAddress,
+			ReadHeaderTimeout: o.ReadHeaderTimeout,
+			Handler:           promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}),
+		})
+	}
+
+	listeners, err := listen(servers)
+	if err != nil {
+		return err
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	for i, server := range servers {
+		group.Go(func() error {
+			return serve(server, listeners[i])
+		})
+	}
+
+	group.Go(func() error {
+		<-groupCtx.Done()
+
+		ready.Store(false)
+
+		// Only delay on a signal, if a server failed to start there's
+		// no point waiting around.
+		if ctx.Err() != nil {
+			log.FromContext(ctx).Info("draining connections")
+
+			time.Sleep(o.ShutdownDelay)
+		}
+
+		// The main context is cancelled by now, so give ourselves some
+		// time to tidy up.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), o.ShutdownTimeout)
+		defer cancel()
+
+		var errs []error
+
+		for _, server := range servers {
+			errs = append(errs, server.Shutdown(shutdownCtx))
+		}
+
+		return errors.Join(errs...)
+	})
+
+	// Only report ready once everything is listening, otherwise we may
+	// be routed traffic that will be refused.
+	ready.Store(true)
+
+	return group.Wait()
+}
+
+// Run provides common HTTP service initialization and execution.
+//
+//nolint:cyclop
+func Run(f HandlerFactory, schema *openapi.Schema) {
+	zapOptions := &zap.Options{}
+	zapOptions.BindFlags(flag.CommandLine)
+
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+
+	o := &Options{}
+	o.AddFlags(pflag.CommandLine)
+
+	application, version, revision := f.Metadata()
+
+	otelOptions := &otel.Options{
+		ServiceName:    application,
+		ServiceVersion: version,
+	}
+	otelOptions.AddFlags(pflag.CommandLine)
+
+	m := &middlewareOptions{}
+	m.AddFlags(pflag.CommandLine)
+
+	cacheOptions := &coreclient.CacheOptions{}
+
+	if cf, ok := f.(CachedHandlerFactory); ok {
+		if o := cf.CacheOptions(); o != nil {
+			cacheOptions = o
+		}
+	}
+
+	cacheOptions.AddFlags(pflag.CommandLine)
+
+	handlerOptions := f.Options()
+	if handlerOptions != nil {
+		handlerOptions.AddFlags(pflag.CommandLine)
+	}
+
+	pflag.Parse()
+
+	logr := otelOptions.Logger(zap.New(zap.UseFlagOptions(zapOptions)))
+
+	log.SetLogger(logr)
+	klog.SetLogger(logr)
+
+	logger := log.Log.WithName("init")
+	logger.Info("service starting", "application", application, "version", version, "revision", revision)
+
+	ctx := signals.SetupSignalHandler()
+
+	if err := otelOptions.Setup(ctx); err != nil {
+		logger.Error(err, "open telemetry setup failed")
+		os.Exit(1)
+	}
+
+	client, err := coreclient.NewWithCacheOptions(ctx, cacheOptions, f.Schemes()...)
+	if err != nil {
+		logger.Error(err, "client creation error")
+		os.Exit(1)
+	}
+
+	handler, err := f.Handler(handlerOptions, client, schema)
+	if err != nil {
+		logger.Error(err, "handler creation error")
+		os.Exit(1)
+	}
+
+	auditDispatcher, err := getAuditDispatcher(ctx, f, m, client)
+	if err != nil {
+		logger.Error(err, "audit setup failed")
+		os.Exit(1)
+	}
+
+	handler, err = getHandler(f, o, m, client, schema, auditDispatcher, handler)
+	if err != nil {
+		logger.Error(err, "middleware creation error")
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Addr:              o.ListenAddress,
+		ReadTimeout:       o.ReadTimeout,
+		ReadHeaderTimeout: o.ReadHeaderTimeout,
+		WriteTimeout:      o.WriteTimeout,
+		IdleTimeout:       o.IdleTimeout,
+		Handler:           handler,
+	}
+
+	if err := getTLSConfig(ctx, o, server); err != nil {
+		logger.Error(err, "tls setup failed")
+		os.Exit(1)
+	}
+
+	// Audit records are written until the server has shut down, so records
+	// of in-flight requests aren't lost.
+	auditCtx, auditCancel := context.WithCancel(context.WithoutCancel(ctx))
+	auditDone := make(chan struct{})
+
+	go func() {
+		defer close(auditDone)
+
+		auditDispatcher.Run(auditCtx)
+	}()
+
+	err = run(ctx, o, server)
+
+	auditCancel()
+	<-auditDone
+
+	// Flush any buffered spans, the signal context is cancelled by now.
+	if err := otelOptions.Shutdown(context.Background()); err != nil {
+		logger.Error(err, "open telemetry shutdown failed")
+	}
+
+	if err != nil {
+		logger.Error(err, "server terminated")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,680 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+
+	unikornv1 "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	coreclient "github.com/unikorn-cloud/core/pkg/client"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/audit"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/authorization"
+	"github.com/unikorn-cloud/core/pkg/server/middleware/idempotency"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var errTest = errors.New("test error")
+
+// freeAddress returns a loopback address that is not in use.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	address := listener.Addr().String()
+
+	require.NoError(t, listener.Close())
+
+	return address
+}
+
+// busyAddress returns a loopback address that is in use for the duration of the test.
+func busyAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	return listener.Addr().String()
+}
+
+// requireFree checks the address can be bound i.e. no listener was leaked.
+func requireFree(t *testing.T, address string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", address)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+}
+
+// TestListen checks all addresses are bound, and on error none are.
+func TestListen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		busy  int
+		valid bool
+	}{
+		{
+			name:  "All",
+			busy:  -1,
+			valid: true,
+		},
+		{
+			name: "FirstBusy",
+			busy: 0,
+		},
+		{
+			name: "LaterBusy",
+			busy: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			addresses := []string{
+				freeAddress(t),
+				freeAddress(t),
+				freeAddress(t),
+			}
+
+			if test.busy >= 0 {
+				addresses[test.busy] = busyAddress(t)
+			}
+
+			servers := make([]*http.Server, len(addresses))
+
+			for i := range addresses {
+				servers[i] = &http.Server{Addr: addresses[i], ReadHeaderTimeout: time.Second}
+			}
+
+			listeners, err := server.Listen(servers)
+			if !test.valid {
+				require.Error(t, err)
+				require.Nil(t, listeners)
+
+				for i := range addresses {
+					if i != test.busy {
+						requireFree(t, addresses[i])
+					}
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, listeners, len(addresses))
+
+			for i := range listeners {
+				require.Equal(t, addresses[i], listeners[i].Addr().String())
+				require.NoError(t, listeners[i].Close())
+			}
+		})
+	}
+}
+
+// TestListenInvalid checks invalid addresses are reported.
+func TestListenInvalid(t *testing.T) {
+	t.Parallel()
+
+	address := freeAddress(t)
+
+	servers := []*http.Server{
+		{Addr: address, ReadHeaderTimeout: time.Second},
+		{Addr: "invalid", ReadHeaderTimeout: time.Second},
+	}
+
+	_, err := server.Listen(servers)
+	require.Error(t, err)
+
+	requireFree(t, address)
+}
+
+// TestProbes checks liveness always succeeds, and readiness follows state.
+func TestProbes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		path   string
+		ready  bool
+		status int
+	}{
+		{
+			name:   "Live",
+			path:   "/healthz",
+			ready:  true,
+			status: http.StatusOK,
+		},
+		{
+			name:   "LiveNotReady",
+			path:   "/healthz",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Ready",
+			path:   "/readyz",
+			ready:  true,
+			status: http.StatusOK,
+		},
+		{
+			name:   "NotReady",
+			path:   "/readyz",
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:   "NotFound",
+			path:   "/metrics",
+			ready:  true,
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ready := &atomic.Bool{}
+			ready.Store(test.ready)
+
+			w := httptest.NewRecorder()
+
+			server.GetProbeHandler(ready).ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			require.Equal(t, test.status, w.Code)
+		})
+	}
+}
+
+// get returns the status code of a request, or zero if it cannot be made.
+func get(ctx context.Context, url string) int {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0
+	}
+
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+// runOptions returns server options listening on free loopback addresses.
+func runOptions(t *testing.T) *server.Options {
+	t.Helper()
+
+	return &server.Options{
+		ListenAddress:     freeAddress(t),
+		HealthAddress:     freeAddress(t),
+		MetricsAddress:    freeAddress(t),
+		ReadHeaderTimeout: time.Second,
+		ShutdownDelay:     500 * time.Millisecond,
+		ShutdownTimeout:   time.Second,
+	}
+}
+
+// TestRun checks all servers are started, and on shutdown readiness fails
+// before the servers are stopped.
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	o := runOptions(t)
+
+	api := &http.Server{
+		Addr:              o.ListenAddress,
+		ReadHeaderTimeout: o.ReadHeaderTimeout,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- server.RunServers(ctx, o, api)
+	}()
+
+	require.Eventually(t, func() bool {
+		return get(t.Context(), "http://"+o.HealthAddress+"/readyz") == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, http.StatusOK, get(t.Context(), "http://"+o.HealthAddress+"/healthz"))
+	require.Equal(t, http.StatusNoContent, get(t.Context(), "http://"+o.ListenAddress+"/"))
+	require.Equal(t, http.StatusOK, get(t.Context(), "http://"+o.MetricsAddress+"/metrics"))
+
+	cancel()
+
+	// During the shutdown delay we report not ready, but are still serving.
+	require.Eventually(t, func() bool {
+		return get(t.Context(), "http://"+o.HealthAddress+"/readyz") == http.StatusServiceUnavailable
+	}, o.ShutdownDelay, 10*time.Millisecond)
+
+	require.Equal(t, http.StatusOK, get(t.Context(), "http://"+o.HealthAddress+"/healthz"))
+	require.Equal(t, http.StatusNoContent, get(t.Context(), "http://"+o.ListenAddress+"/"))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server failed to shut down")
+	}
+
+	require.Zero(t, get(t.Context(), "http://"+o.HealthAddress+"/healthz"))
+	require.Zero(t, get(t.Context(), "http://"+o.ListenAddress+"/"))
+}
+
+// TestRunListenError checks failure to listen is reported, without waiting for
+// the shutdown delay, and no listeners are leaked.
+func TestRunListenError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		busy func(o *server.Options) *string
+	}{
+		{
+			name: "API",
+			busy: func(o *server.Options) *string { return &o.ListenAddress },
+		},
+		{
+			name: "Health",
+			busy: func(o *server.Options) *string { return &o.HealthAddress },
+		},
+		{
+			name: "Metrics",
+			busy: func(o *server.Options) *string { return &o.MetricsAddress },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := runOptions(t)
+			o.ShutdownDelay = time.Minute
+
+			*test.busy(o) = busyAddress(t)
+
+			api := &http.Server{
+				Addr:              o.ListenAddress,
+				ReadHeaderTimeout: o.ReadHeaderTimeout,
+			}
+
+			start := time.Now()
+
+			require.Error(t, server.RunServers(t.Context(), o, api))
+			require.Less(t, time.Since(start), o.ShutdownDelay)
+
+			for _, address := range []string{o.ListenAddress, o.HealthAddress, o.MetricsAddress} {
+				if address != *test.busy(o) {
+					requireFree(t, address)
+				}
+			}
+		})
+	}
+}
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /widgets:
+    get:
+      operationId: listWidgets
+      responses:
+        '200':
+          description: ok
+    post:
+      operationId: createWidget
+      x-required-permission:
+        resource: widgets
+        action: create
+        scope: global
+      x-rate-limit:
+        rate: 0.001
+        burst: 2
+      security:
+      - oauth2: []
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required:
+              - name
+              properties:
+                name:
+                  type: string
+      responses:
+        '201':
+          description: ok
+components:
+  securitySchemes:
+    oauth2:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://example.com/token
+          scopes: {}
+`
+
+func testSchema(t *testing.T) *openapi.Schema {
+	t.Helper()
+
+	schema, err := openapi.NewSchema(func() (*openapi3.T, error) {
+		return openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	})
+	require.NoError(t, err)
+
+	return schema
+}
+
+// handlerFactory is a minimal service with authorization.
+type handlerFactory struct {
+	authorizer authorization.Authorizer
+}
+
+func (f *handlerFactory) Metadata() (string, string, string) {
+	return "test", "0.0.0", "0000000"
+}
+
+func (f *handlerFactory) Options() server.HandlerOptions {
+	return nil
+}
+
+func (f *handlerFactory) Handler(options server.HandlerOptions, client client.Client, schema *openapi.Schema) (http.Handler, error) {
+	return nil, errTest
+}
+
+func (f *handlerFactory) Schemes() []coreclient.SchemeAdder {
+	return nil
+}
+
+func (f *handlerFactory) Authorizer(client client.Client) authorization.Authorizer {
+	return f.authorizer
+}
+
+// authorizer allows or denies everything.
+type authorizer bool
+
+func (a authorizer) Authorize(ctx context.Context, request *authorization.Request) (bool, error) {
+	return bool(a), nil
+}
+
+// recordingSink remembers audit records.
+type recordingSink struct {
+	records []*unikornv1.AuditRecord
+}
+
+func (s *recordingSink) Write(ctx context.Context, record *unikornv1.AuditRecord) error {
+	s.records = append(s.records, record)
+
+	return nil
+}
+
+// TestHandler checks the common middleware chain is ordered correctly.
+//
+//nolint:maintidx
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		requests int
+		body     string
+		header   map[string]string
+		denied   bool
+		panic    bool
+		status   int
+		calls    int
+		outcomes []unikornv1.AuditOutcome
+	}{
+		{
+			name:     "Success",
+			requests: 1,
+			body:     `{"name":"foo"}`,
+			status:   http.StatusCreated,
+			calls:    1,
+			outcomes: []unikornv1.AuditOutcome{unikornv1.AuditOutcomeSuccess},
+		},
+		{
+			// Recovery handles the panic, and CORS headers are still set.
+			// The request is audited even though the handler never returned.
+			name:     "Panic",
+			requests: 1,
+			body:     `{"name":"foo"}`,
+			panic:    true,
+			status:   http.StatusInternalServerError,
+			calls:    1,
+			outcomes: []unikornv1.AuditOutcome{unikornv1.AuditOutcomeFailure},
+		},
+		{
+			// Oversized requests are rejected before any other work is done.
+			name:     "TooLarge",
+			requests: 1,
+			body:     `{"name":"` + strings.Repeat("a", 1024) + `"}`,
+			status:   http.StatusRequestEntityTooLarge,
+		},
+		{
+			// Unauthenticated requests are rejected before auditing, as
+			// there is no principal to record.
+			name:     "Unauthenticated",
+			args:     []string{"--jwt-issuer=https://issuer.example.com"},
+			requests: 1,
+			body:     `{"name":"foo"}`,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			// Rate limited requests are not audited.
+			name:     "RateLimited",
+			requests: 3,
+			body:     `{"name":"foo"}`,
+			status:   http.StatusTooManyRequests,
+			calls:    2,
+			outcomes: []unikornv1.AuditOutcome{unikornv1.AuditOutcomeSuccess, unikornv1.AuditOutcomeSuccess},
+		},
+		{
+			// Denied requests are audited.
+			name:     "Denied",
+			requests: 1,
+			body:     `{"name":"foo"}`,
+			denied:   true,
+			status:   http.StatusForbidden,
+			outcomes: []unikornv1.AuditOutcome{unikornv1.AuditOutcomeDenied},
+		},
+		{
+			// Denied requests are never replayed.
+			name:     "DeniedIdempotent",
+			requests: 1,
+			body:     `{"name":"foo"}`,
+			header:   map[string]string{idempotency.KeyHeader: "foo"},
+			denied:   true,
+			status:   http.StatusForbidden,
+			outcomes: []unikornv1.AuditOutcome{unikornv1.AuditOutcomeDenied},
+		},
+		{
+			// Invalid requests are audited, but never reach the handler.
+			name:     "Invalid",
+			requests: 1,
+			body:     `{}`,
+			status:   http.StatusBadRequest,
+			outcomes: []unikornv1.AuditOutcome{unikornv1.AuditOutcomeFailure},
+		},
+		{
+			// Replayed requests are audited, but never reach the handler.
+			name:     "Idempotent",
+			requests: 2,
+			body:     `{"name":"foo"}`,
+			header:   map[string]string{idempotency.KeyHeader: "foo"},
+			status:   http.StatusCreated,
+			calls:    1,
+			outcomes: []unikornv1.AuditOutcome{unikornv1.AuditOutcomeSuccess, unikornv1.AuditOutcomeSuccess},
+		},
+	}
+
+	schema := testSchema(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+			o := &server.Options{}
+			o.AddFlags(flags)
+
+			m := &server.MiddlewareOptions{}
+			m.AddFlags(flags)
+
+			args := []string{
+				"--server-max-request-size=256",
+				"--cors-allow-origin=https://example.com",
+			}
+
+			require.NoError(t, flags.Parse(append(args, test.args...)))
+
+			sink := &recordingSink{}
+
+			dispatcher := audit.NewDispatcher([]audit.Sink{sink}, &audit.Options{QueueSize: 16})
+
+			var calls int
+
+			var deadline bool
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				_, deadline = r.Context().Deadline()
+
+				if test.panic {
+					panic("oops")
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{}`))
+			})
+
+			f := &handlerFactory{
+				authorizer: authorizer(!test.denied),
+			}
+
+			handler, err := server.GetHandler(f, o, m, schema, dispatcher, next)
+			require.NoError(t, err)
+
+			var w *httptest.ResponseRecorder
+
+			for range test.requests {
+				r := httptest.NewRequest(http.MethodPost, "/widgets", strings.NewReader(test.body))
+				r.Header.Set("Content-Type", "application/json")
+				r.Header.Set("Origin", "https://example.com")
+
+				for k, v := range test.header {
+					r.Header.Set(k, v)
+				}
+
+				w = httptest.NewRecorder()
+
+				handler.ServeHTTP(w, r)
+			}
+
+			require.Equal(t, test.status, w.Code, w.Body.String())
+			require.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+			require.Equal(t, test.calls, calls)
+
+			if calls > 0 {
+				require.True(t, deadline)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			cancel()
+
+			dispatcher.Run(ctx)
+
+			outcomes := make([]unikornv1.AuditOutcome, len(sink.records))
+
+			for i := range sink.records {
+				outcomes[i] = sink.records[i].Spec.Outcome
+			}
+
+			require.ElementsMatch(t, test.outcomes, outcomes)
+		})
+	}
+}
+
+// TestHandlerUnknownRoute checks requests that don't match the schema are passed
+// through to the router.
+func TestHandlerUnknownRoute(t *testing.T) {
+	t.Parallel()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	o := &server.Options{}
+	o.AddFlags(flags)
+
+	m := &server.MiddlewareOptions{}
+	m.AddFlags(flags)
+
+	require.NoError(t, flags.Parse(nil))
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	handler, err := server.GetHandler(&handlerFactory{authorizer: authorizer(false)}, o, m, testSchema(t), nil, next)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gadgets", nil))
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// certificateLoader serves a TLS certificate from a Kubernetes secret, as
+// provisioned by cert-manager, and reloads it when it's renewed.  The secret is
+// polled with an uncached client, as we don't want to cache secrets cluster wide.
+type certificateLoader struct {
+	// reader is used to read the secret.
+	reader client.Reader
+
+	// key identifies the secret.
+	key client.ObjectKey
+
+	// interval is how often to check for renewal.
+	interval time.Duration
+
+	// lock protects the certificate.
+	lock sync.RWMutex
+
+	// certificate is the current certificate.
+	certificate *tls.Certificate
+
+	// resourceVersion allows us to skip parsing if nothing has changed.
+	resourceVersion string
+}
+
+// newCertificateLoader creates a certificate loader, and does an initial load
+// so we fail fast on misconfiguration.
+func newCertificateLoader(ctx context.Context, reader client.Reader, key client.ObjectKey, interval time.Duration) (*certificateLoader, error) {
+	l := &certificateLoader{
+		reader:   reader,
+		key:      key,
+		interval: interval,
+	}
+
+	if err := l.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// load reads the secret and updates the certificate if it has changed.
+func (l *certificateLoader) load(ctx context.Context) error {
+	secret := &corev1.Secret{}
+
+	if err := l.reader.Get(ctx, l.key, secret); err != nil {
+		return err
+	}
+
+	if secret.ResourceVersion == l.resourceVersion {
+		return nil
+	}
+
+	certificate, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.certificate = &certificate
+	l.resourceVersion = secret.ResourceVersion
+
+	log.FromContext(ctx).Info("tls certificate loaded", "secret", l.key.String(), "resourceVersion", secret.ResourceVersion)
+
+	return nil
+}
+
+// run periodically reloads the certificate until the context is cancelled.
+func (l *certificateLoader) run(ctx context.Context) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// On error keep serving the old certificate, it may still be valid.
+		if err := l.load(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "failed to reload tls certificate", "secret", l.key.String())
+		}
+	}
+}
+
+// GetCertificate implements the tls.Config GetCertificate callback.
+func (l *certificateLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.certificate, nil
+}
+
+// TLSConfig returns a TLS configuration that uses the current certificate.
+func (l *certificateLoader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: l.GetCertificate,
+	}
+}
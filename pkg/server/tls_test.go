@@ -0,0 +1,194 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/server"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+//nolint:gochecknoglobals
+var secretKey = client.ObjectKey{
+	Namespace: "default",
+	Name:      "tls",
+}
+
+// certificate generates a self signed certificate and key in PEM format.
+func certificate(t *testing.T, commonName string) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName: commonName,
+		},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// tlsSecret returns a TLS secret as provisioned by cert-manager.
+func tlsSecret(certificate, key []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: secretKey.Namespace,
+			Name:      secretKey.Name,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certificate,
+			corev1.TLSPrivateKeyKey: key,
+		},
+	}
+}
+
+// commonName returns the common name of the certificate currently served.
+func commonName(t *testing.T, loader *server.CertificateLoader) string {
+	t.Helper()
+
+	certificate, err := loader.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.NotNil(t, certificate)
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+// TestCertificateLoader checks the initial load fails fast on misconfiguration.
+func TestCertificateLoader(t *testing.T) {
+	t.Parallel()
+
+	certificatePEM, keyPEM := certificate(t, "foo")
+	_, otherKeyPEM := certificate(t, "bar")
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		valid   bool
+	}{
+		{
+			name:  "Missing",
+			valid: false,
+		},
+		{
+			name: "Invalid",
+			objects: []client.Object{
+				tlsSecret([]byte("certificate"), []byte("key")),
+			},
+			valid: false,
+		},
+		{
+			name: "Mismatched",
+			objects: []client.Object{
+				tlsSecret(certificatePEM, otherKeyPEM),
+			},
+			valid: false,
+		},
+		{
+			name: "Valid",
+			objects: []client.Object{
+				tlsSecret(certificatePEM, keyPEM),
+			},
+			valid: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := fake.NewClientBuilder().WithObjects(test.objects...).Build()
+
+			loader, err := server.NewCertificateLoader(t.Context(), reader, secretKey, time.Minute)
+			if !test.valid {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "foo", commonName(t, loader))
+
+			config := loader.TLSConfig()
+			require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+			require.NotNil(t, config.GetCertificate)
+		})
+	}
+}
+
+// TestCertificateReload checks renewed certificates are served, and that the old
+// certificate is kept if the secret becomes invalid or is deleted.
+func TestCertificateReload(t *testing.T) {
+	t.Parallel()
+
+	certificatePEM, keyPEM := certificate(t, "foo")
+	renewedCertificatePEM, renewedKeyPEM := certificate(t, "bar")
+
+	cli := fake.NewClientBuilder().WithObjects(tlsSecret(certificatePEM, keyPEM)).Build()
+
+	loader, err := server.NewCertificateLoader(t.Context(), cli, secretKey, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, "foo", commonName(t, loader))
+
+	go loader.Run(t.Context())
+
+	require.NoError(t, cli.Update(t.Context(), tlsSecret(renewedCertificatePEM, renewedKeyPEM)))
+
+	require.Eventually(t, func() bool {
+		return commonName(t, loader) == "bar"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, cli.Update(t.Context(), tlsSecret([]byte("certificate"), []byte("key"))))
+
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, "bar", commonName(t, loader))
+
+	require.NoError(t, cli.Delete(t.Context(), tlsSecret(nil, nil)))
+
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, "bar", commonName(t, loader))
+}
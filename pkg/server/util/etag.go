@@ -0,0 +1,105 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/unikorn-cloud/core/pkg/server/conversion"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WriteETag adds an entity tag for the resource to the response.  This must be
+// called before the response body is written.
+func WriteETag(w http.ResponseWriter, in metav1.Object) {
+	w.Header().Set("ETag", conversion.ETag(in))
+}
+
+// ifMatch returns true if the request has an If-Match precondition.
+func ifMatch(r *http.Request) bool {
+	return len(r.Header.Values("If-Match")) != 0
+}
+
+// CheckIfMatch checks any If-Match precondition against the current resource,
+// returning an HTTP 412 error if it has been modified since it was read by the
+// client.  Requests without a precondition are always permitted.
+func CheckIfMatch(r *http.Request, current metav1.Object) error {
+	if !ifMatch(r) {
+		return nil
+	}
+
+	etag := conversion.ETag(current)
+
+	for _, value := range r.Header.Values("If-Match") {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+
+			// Weak tags never match as per RFC 9110.
+			if tag == "*" || tag == etag {
+				return nil
+			}
+		}
+	}
+
+	return errors.HTTPPreconditionFailed()
+}
+
+// IfMatchUpdate checks any If-Match precondition against the current resource,
+// then pins the required resource's version to the current one.  This way the
+// API server will reject the update with a conflict if the resource is modified
+// between the check and the update.  Such conflicts should be reported with
+// errors.HTTPPreconditionFailed.
+func IfMatchUpdate(r *http.Request, current, required metav1.Object) error {
+	if err := CheckIfMatch(r, current); err != nil {
+		return err
+	}
+
+	if ifMatch(r) {
+		required.SetResourceVersion(current.GetResourceVersion())
+	}
+
+	return nil
+}
+
+// IfMatchDelete checks any If-Match precondition against the current resource,
+// and returns delete options that have the API server enforce the precondition,
+// in case the resource is modified between the check and the delete.  Conflicts
+// should be reported with errors.HTTPPreconditionFailed.
+func IfMatchDelete(r *http.Request, current metav1.Object) ([]client.DeleteOption, error) {
+	if err := CheckIfMatch(r, current); err != nil {
+		return nil, err
+	}
+
+	if !ifMatch(r) {
+		return nil, nil
+	}
+
+	resourceVersion := current.GetResourceVersion()
+
+	options := []client.DeleteOption{
+		client.Preconditions{
+			ResourceVersion: &resourceVersion,
+		},
+	}
+
+	return options, nil
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func testResource(resourceVersion string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "test",
+			ResourceVersion: resourceVersion,
+		},
+	}
+}
+
+// ifMatchRequest returns a request with the provided If-Match headers.
+func ifMatchRequest(values ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+
+	for _, value := range values {
+		r.Header.Add("If-Match", value)
+	}
+
+	return r
+}
+
+// TestWriteETag checks entity tags are strong and derived from the resource version.
+func TestWriteETag(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	util.WriteETag(w, testResource("42"))
+
+	require.Equal(t, `"42"`, w.Header().Get("ETag"))
+}
+
+// TestCheckIfMatch checks If-Match preconditions are evaluated as per RFC 9110.
+func TestCheckIfMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		values  []string
+		matches bool
+	}{
+		{
+			name:    "NoPrecondition",
+			matches: true,
+		},
+		{
+			name:    "Match",
+			values:  []string{`"42"`},
+			matches: true,
+		},
+		{
+			name:   "Mismatch",
+			values: []string{`"41"`},
+		},
+		{
+			name:    "Wildcard",
+			values:  []string{"*"},
+			matches: true,
+		},
+		{
+			name:   "Weak",
+			values: []string{`W/"42"`},
+		},
+		{
+			name:   "Unquoted",
+			values: []string{"42"},
+		},
+		{
+			name:    "List",
+			values:  []string{`"40", "42"`},
+			matches: true,
+		},
+		{
+			name:    "MultipleHeaders",
+			values:  []string{`"40"`, `"42"`},
+			matches: true,
+		},
+		{
+			name:   "ListMismatch",
+			values: []string{`"40", "41"`, `W/"42"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := util.CheckIfMatch(ifMatchRequest(test.values...), testResource("42"))
+
+			if test.matches {
+				require.NoError(t, err)
+				return
+			}
+
+			require.True(t, errors.IsHTTPPreconditionFailed(err))
+		})
+	}
+}
+
+// TestIfMatchUpdate checks the resource version is only pinned when the client
+// sends a precondition, otherwise updates are unconditional.
+func TestIfMatchUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		values          []string
+		resourceVersion string
+		precondition    bool
+	}{
+		{
+			name: "NoPrecondition",
+		},
+		{
+			name:            "Match",
+			values:          []string{`"42"`},
+			resourceVersion: "42",
+		},
+		{
+			name:         "Mismatch",
+			values:       []string{`"41"`},
+			precondition: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			required := testResource("")
+
+			err := util.IfMatchUpdate(ifMatchRequest(test.values...), testResource("42"), required)
+
+			if test.precondition {
+				require.True(t, errors.IsHTTPPreconditionFailed(err))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.resourceVersion, required.ResourceVersion)
+		})
+	}
+}
+
+// TestIfMatchDelete checks deletes are only made conditional when the client sends
+// a precondition.
+func TestIfMatchDelete(t *testing.T) {
+	t.Parallel()
+
+	resourceVersion := "42"
+
+	tests := []struct {
+		name         string
+		values       []string
+		options      []client.DeleteOption
+		precondition bool
+	}{
+		{
+			name: "NoPrecondition",
+		},
+		{
+			name:   "Match",
+			values: []string{`"42"`},
+			options: []client.DeleteOption{
+				client.Preconditions{ResourceVersion: &resourceVersion},
+			},
+		},
+		{
+			name:         "Mismatch",
+			values:       []string{`"41"`},
+			precondition: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			options, err := util.IfMatchDelete(ifMatchRequest(test.values...), testResource("42"))
+
+			if test.precondition {
+				require.True(t, errors.IsHTTPPreconditionFailed(err))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.options, options)
+		})
+	}
+}
@@ -18,8 +18,12 @@ package util
 
 import (
 	"encoding/json"
+	goerrors "errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/unikorn-cloud/core/pkg/server/errors"
 
@@ -46,15 +50,140 @@ func WriteJSONResponse(w http.ResponseWriter, r *http.Request, code int, respons
 	}
 }
 
-// ReadJSONBody is a generic request reader to unmarshal JSON bodies.
-func ReadJSONBody(r *http.Request, v any) error {
-	body, err := io.ReadAll(r.Body)
+const (
+	// DefaultMaxJSONBodySize is the default maximum size of a JSON request body.
+	DefaultMaxJSONBodySize = 1 << 20
+)
+
+// jsonBodyOptions controls how JSON request bodies are decoded.
+type jsonBodyOptions struct {
+	// maxBodySize is the maximum number of bytes read from the body.
+	maxBodySize int64
+	// allowUnknownFields allows fields not defined by the type.
+	allowUnknownFields bool
+}
+
+// JSONBodyOption allows ReadJSONBody to be customized.
+type JSONBodyOption func(o *jsonBodyOptions)
+
+// WithMaxBodySize overrides the default maximum body size e.g. for resources that
+// embed large documents.
+func WithMaxBodySize(size int64) JSONBodyOption {
+	return func(o *jsonBodyOptions) {
+		o.maxBodySize = size
+	}
+}
+
+// AllowUnknownFields disables strict decoding, this should only be used where
+// forward compatibility with newer clients is required.
+func AllowUnknownFields(o *jsonBodyOptions) {
+	o.allowUnknownFields = true
+}
+
+// isJSONContentType checks whether the request body is JSON, this includes
+// structured syntax suffixes e.g. application/merge-patch+json.
+func isJSONContentType(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
-		return errors.OAuth2ServerError("unable to read request body").WithError(err)
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// jsonFieldPointer converts a decoder field path e.g. "metadata.name" into a
+// JSON pointer e.g. "/metadata/name".
+func jsonFieldPointer(field string) string {
+	if field == "" {
+		return ""
+	}
+
+	replacer := strings.NewReplacer("~", "~0", "/", "~1")
+
+	segments := strings.Split(field, ".")
+
+	for i := range segments {
+		segments[i] = replacer.Replace(segments[i])
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// jsonDecodeError maps decoder errors to errors the client can act on.
+func jsonDecodeError(err error) error {
+	var httpError *errors.Error
+
+	var maxBytesError *http.MaxBytesError
+
+	var syntaxError *json.SyntaxError
+
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case goerrors.As(err, &httpError):
+		// Readers may report their own client errors e.g. corrupt compressed bodies.
+		return httpError
+	case goerrors.As(err, &maxBytesError):
+		return errors.HTTPRequestEntityTooLarge(fmt.Sprintf("request body exceeds the maximum size of %d bytes", maxBytesError.Limit))
+	case goerrors.Is(err, io.EOF):
+		return errors.OAuth2InvalidRequest("request body is empty")
+	case goerrors.Is(err, io.ErrUnexpectedEOF):
+		return errors.OAuth2InvalidRequest("request body is truncated").WithError(err)
+	case goerrors.As(err, &syntaxError):
+		message := fmt.Sprintf("%s at offset %d", syntaxError.Error(), syntaxError.Offset)
+
+		return errors.OAuth2InvalidRequest("request body is not valid JSON: "+message).WithDetail("", "syntax", message)
+	case goerrors.As(err, &typeError):
+		message := fmt.Sprintf("expected %s but got %s at offset %d", typeError.Type, typeError.Value, typeError.Offset)
+
+		return errors.OAuth2InvalidRequest("request body field invalid: "+message).WithDetail(jsonFieldPointer(typeError.Field), "type", message)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// Sadly the decoder doesn't expose a typed error for this.
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+
+		message := "unknown field " + field
+
+		return errors.OAuth2InvalidRequest("request body field invalid: "+message).WithDetail("", "additionalProperties", message)
+	}
+
+	return errors.OAuth2ServerError("unable to read request body").WithError(err)
+}
+
+// ReadJSONBody is a generic request reader to unmarshal JSON bodies.  The request
+// must have a JSON content type, the body size is limited, and by default unknown
+// fields are rejected.  Errors are mapped to client errors that describe where the
+// body is invalid.
+func ReadJSONBody(r *http.Request, v any, options ...JSONBodyOption) error {
+	o := &jsonBodyOptions{
+		maxBodySize: DefaultMaxJSONBodySize,
+	}
+
+	for _, option := range options {
+		option(o)
 	}
 
-	if err := json.Unmarshal(body, v); err != nil {
-		return errors.OAuth2ServerError("unable to unmarshal request body").WithError(err)
+	if !isJSONContentType(r) {
+		return errors.HTTPUnsupportedMediaType("request content type must be application/json")
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, o.maxBodySize))
+
+	if !o.allowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		return jsonDecodeError(err)
+	}
+
+	// Reject anything after the value, it's likely a malformed request that
+	// we'd otherwise silently ignore.
+	if err := decoder.Decode(&json.RawMessage{}); !goerrors.Is(err, io.EOF) {
+		if err != nil {
+			return jsonDecodeError(err)
+		}
+
+		return errors.OAuth2InvalidRequest("request body must contain a single JSON value")
 	}
 
 	return nil
@@ -0,0 +1,213 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+)
+
+type testMetadata struct {
+	Name string `json:"name"`
+}
+
+type testBody struct {
+	Metadata testMetadata `json:"metadata"`
+	Count    int          `json:"count"`
+}
+
+// TestReadJSONBody checks request bodies are decoded strictly, and failures are
+// reported as client errors that describe the problem.
+func TestReadJSONBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		options     []util.JSONBodyOption
+		expected    *testBody
+		status      int
+		pointer     string
+		reason      string
+	}{
+		{
+			name:        "Valid",
+			contentType: "application/json",
+			body:        `{"metadata":{"name":"foo"},"count":1}`,
+			expected:    &testBody{Metadata: testMetadata{Name: "foo"}, Count: 1},
+		},
+		{
+			name:        "ContentTypeParameters",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"count":1}`,
+			expected:    &testBody{Count: 1},
+		},
+		{
+			name:        "ContentTypeSuffix",
+			contentType: "application/merge-patch+json",
+			body:        `{"count":1}`,
+			expected:    &testBody{Count: 1},
+		},
+		{
+			name:        "TrailingWhitespace",
+			contentType: "application/json",
+			body:        "{\"count\":1}\n",
+			expected:    &testBody{Count: 1},
+		},
+		{
+			name:   "ContentTypeMissing",
+			body:   `{"count":1}`,
+			status: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "ContentTypeInvalid",
+			contentType: "text/plain",
+			body:        `{"count":1}`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Empty",
+			contentType: "application/json",
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "Truncated",
+			contentType: "application/json",
+			body:        `{"count":`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "Syntax",
+			contentType: "application/json",
+			body:        `{"count":1,}`,
+			status:      http.StatusBadRequest,
+			reason:      "syntax",
+		},
+		{
+			name:        "Type",
+			contentType: "application/json",
+			body:        `{"metadata":{"name":1}}`,
+			status:      http.StatusBadRequest,
+			pointer:     "/metadata/name",
+			reason:      "type",
+		},
+		{
+			name:        "UnknownField",
+			contentType: "application/json",
+			body:        `{"count":1,"colour":"red"}`,
+			status:      http.StatusBadRequest,
+			reason:      "additionalProperties",
+		},
+		{
+			name:        "UnknownFieldAllowed",
+			contentType: "application/json",
+			body:        `{"count":1,"colour":"red"}`,
+			options:     []util.JSONBodyOption{util.AllowUnknownFields},
+			expected:    &testBody{Count: 1},
+		},
+		{
+			name:        "TrailingValue",
+			contentType: "application/json",
+			body:        `{"count":1}{"count":2}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "TrailingGarbage",
+			contentType: "application/json",
+			body:        `{"count":1} x`,
+			status:      http.StatusBadRequest,
+			reason:      "syntax",
+		},
+		{
+			name:        "TooLarge",
+			contentType: "application/json",
+			body:        `{"metadata":{"name":"` + strings.Repeat("a", 64) + `"}}`,
+			options:     []util.JSONBodyOption{util.WithMaxBodySize(32)},
+			status:      http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "TooLargeDefault",
+			contentType: "application/json",
+			body:        `{"metadata":{"name":"` + strings.Repeat("a", util.DefaultMaxJSONBodySize) + `"}}`,
+			status:      http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+
+			var body testBody
+
+			err := util.ReadJSONBody(r, &body, test.options...)
+
+			if test.status == 0 {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, &body)
+
+				return
+			}
+
+			var e *errors.Error
+
+			require.ErrorAs(t, err, &e)
+			require.Equal(t, test.status, e.StatusCode())
+
+			if test.reason == "" {
+				require.Empty(t, e.Details())
+				return
+			}
+
+			require.Len(t, e.Details(), 1)
+			require.Equal(t, test.pointer, e.Details()[0].Pointer)
+			require.Equal(t, test.reason, e.Details()[0].Reason)
+		})
+	}
+}
+
+// TestWriteJSONResponse checks responses are encoded with the correct status and
+// content type.
+func TestWriteJSONResponse(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	util.WriteJSONResponse(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusCreated, &testBody{Count: 1})
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body testBody
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, testBody{Count: 1}, body)
+}
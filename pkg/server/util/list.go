@@ -0,0 +1,356 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	unikornv1core "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/conversion"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrPageToken is raised when a page token is malformed.
+	ErrPageToken = goerrors.New("page token invalid")
+)
+
+// SortField is a resource metadata field that lists can be sorted by.
+type SortField string
+
+const (
+	SortFieldName               SortField = "name"
+	SortFieldCreationTime       SortField = "creationTime"
+	SortFieldProvisioningStatus SortField = "provisioningStatus"
+)
+
+// pageToken is the decoded form of the opaque page token handed to clients.
+type pageToken struct {
+	// Continue is set when pagination is performed by Kubernetes.
+	Continue string `json:"c,omitempty"`
+
+	// Offset is the index of the next item when pagination is performed
+	// in memory.
+	Offset int `json:"o,omitempty"`
+
+	// Sort is the sort order the offset refers to.
+	Sort string `json:"s,omitempty"`
+}
+
+// encode renders the page token in an opaque form.
+func (t *pageToken) encode() (string, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageToken parses an opaque page token.
+func decodePageToken(in string) (*pageToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(in)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &pageToken{}
+
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+
+	if token.Offset < 0 {
+		return nil, fmt.Errorf("%w: negative offset", ErrPageToken)
+	}
+
+	return token, nil
+}
+
+// ListOptions defines how a list is paginated, sorted and filtered.
+type ListOptions struct {
+	// Limit is the maximum number of items to return, zero means all of them.
+	Limit int
+
+	// SortField is the field to sort by, if empty lists are sorted by ID.
+	SortField SortField
+
+	// SortDescending reverses the sort order.
+	SortDescending bool
+
+	// Name, if set, only returns resources with the name.
+	Name string
+
+	// ProvisioningStatus, if set, only returns resources in one of the states.
+	ProvisioningStatus []openapi.ResourceProvisioningStatus
+
+	// Tags, if set, only returns resources that match the selector.
+	Tags TagSelector
+
+	// sort is the raw sort parameter.
+	sort string
+
+	// token is the decoded page token.
+	token *pageToken
+
+	// paginated is set when pagination has been pushed down to the API server.
+	paginated bool
+}
+
+// DecodeListParams decodes pagination and sorting parameters.  Filters can be
+// added with the builder methods.
+func DecodeListParams(limit *openapi.LimitParameter, pageToken *openapi.PageTokenParameter, sort *openapi.SortParameter) (*ListOptions, error) {
+	o := &ListOptions{}
+
+	if limit != nil {
+		if *limit < 1 {
+			return nil, errors.OAuth2InvalidRequest("list limit must be positive")
+		}
+
+		o.Limit = *limit
+	}
+
+	if sort != nil {
+		field := SortField(strings.TrimPrefix(*sort, "-"))
+
+		if !slices.Contains([]SortField{SortFieldName, SortFieldCreationTime, SortFieldProvisioningStatus}, field) {
+			return nil, errors.OAuth2InvalidRequest("list sort field invalid")
+		}
+
+		o.SortField = field
+		o.SortDescending = strings.HasPrefix(*sort, "-")
+		o.sort = *sort
+	}
+
+	if pageToken != nil {
+		token, err := decodePageToken(*pageToken)
+		if err != nil {
+			return nil, errors.OAuth2InvalidRequest("page token invalid").WithError(err)
+		}
+
+		o.token = token
+	}
+
+	return o, nil
+}
+
+// WithNameFilter only returns resources with the name.
+func (o *ListOptions) WithNameFilter(name *openapi.NameFilterParameter) *ListOptions {
+	if name != nil {
+		o.Name = *name
+	}
+
+	return o
+}
+
+// WithProvisioningStatusFilter only returns resources in one of the states.
+func (o *ListOptions) WithProvisioningStatusFilter(status *openapi.ProvisioningStatusFilterParameter) *ListOptions {
+	if status != nil {
+		o.ProvisioningStatus = *status
+	}
+
+	return o
+}
+
+// WithTagFilter only returns resources that match the selector, typically as
+// returned by DecodeTagSelector.
+func (o *ListOptions) WithTagFilter(tags TagSelector) *ListOptions {
+	o.Tags = tags
+
+	return o
+}
+
+// kubernetesPagination returns true if pagination can be performed by Kubernetes
+// i.e. the list is in its natural order and filtering can be performed by labels.
+func (o *ListOptions) kubernetesPagination() bool {
+	return o.SortField == "" && len(o.ProvisioningStatus) == 0 && len(o.Tags) == 0
+}
+
+// KubernetesListOptions returns options for a Kubernetes list that push filtering
+// down to the API server where possible.  These are safe to use with a cached
+// client, pagination is performed in memory by List.
+func (o *ListOptions) KubernetesListOptions() []client.ListOption {
+	var options []client.ListOption
+
+	if o.Name != "" {
+		options = append(options, client.MatchingLabels{
+			constants.NameLabel: o.Name,
+		})
+	}
+
+	return options
+}
+
+// PaginatedKubernetesListOptions returns options for a Kubernetes list that push
+// pagination and filtering down to the API server where possible.  The list must
+// be read with an uncached reader e.g. the manager's API reader, as caches silently
+// ignore continue tokens and truncate the list.
+func (o *ListOptions) PaginatedKubernetesListOptions() []client.ListOption {
+	options := o.KubernetesListOptions()
+
+	if !o.kubernetesPagination() {
+		return options
+	}
+
+	o.paginated = true
+
+	if o.Limit != 0 {
+		options = append(options, client.Limit(int64(o.Limit)))
+	}
+
+	if o.token != nil && o.token.Continue != "" {
+		options = append(options, client.Continue(o.token.Continue))
+	}
+
+	return options
+}
+
+// listItem caches the metadata of an item so it's only generated once.
+type listItem[T metav1.Object] struct {
+	item     T
+	metadata openapi.ResourceReadMetadata
+}
+
+// filter returns true if the item should be included in the list.
+func (o *ListOptions) filter(metadata *openapi.ResourceReadMetadata, tags unikornv1core.TagList) bool {
+	if o.Name != "" && metadata.Name != o.Name {
+		return false
+	}
+
+	if len(o.ProvisioningStatus) != 0 && !slices.Contains(o.ProvisioningStatus, metadata.ProvisioningStatus) {
+		return false
+	}
+
+	return o.Tags.Matches(tags)
+}
+
+// compare orders items by the sort field, falling back to the ID so the order
+// is stable across pages.
+func (o *ListOptions) compare(a, b *openapi.ResourceReadMetadata) int {
+	var result int
+
+	switch o.SortField {
+	case SortFieldName:
+		result = cmp.Compare(a.Name, b.Name)
+	case SortFieldCreationTime:
+		result = a.CreationTime.Compare(b.CreationTime)
+	case SortFieldProvisioningStatus:
+		result = cmp.Compare(a.ProvisioningStatus, b.ProvisioningStatus)
+	}
+
+	if o.SortDescending {
+		result = -result
+	}
+
+	if result != 0 {
+		return result
+	}
+
+	return cmp.Compare(a.Id, b.Id)
+}
+
+// List applies pagination, sorting and filtering to a list of resources.  If the
+// list was read with PaginatedKubernetesListOptions, then it must be passed in so
+// that the API server's continue token can be returned, otherwise it may be nil.
+// The tags function returns the tags for a resource and may be nil.
+func List[T metav1.Object](o *ListOptions, list metav1.ListInterface, items []T, tags func(T) unikornv1core.TagList) ([]T, *openapi.ListMetadata, error) {
+	metadata := &openapi.ListMetadata{}
+
+	if o.paginated {
+		if o.token != nil && o.token.Continue == "" {
+			return nil, nil, errors.OAuth2InvalidRequest("page token does not match list parameters")
+		}
+
+		if list != nil && list.GetContinue() != "" {
+			token, err := (&pageToken{Continue: list.GetContinue()}).encode()
+			if err != nil {
+				return nil, nil, errors.OAuth2ServerError("unable to encode page token").WithError(err)
+			}
+
+			metadata.NextPageToken = ptr.To(token)
+		}
+
+		return items, metadata, nil
+	}
+
+	offset := 0
+
+	if o.token != nil {
+		if o.token.Continue != "" || o.token.Sort != o.sort {
+			return nil, nil, errors.OAuth2InvalidRequest("page token does not match list parameters")
+		}
+
+		offset = o.token.Offset
+	}
+
+	filtered := make([]listItem[T], 0, len(items))
+
+	for _, item := range items {
+		var t unikornv1core.TagList
+
+		if tags != nil {
+			t = tags(item)
+		}
+
+		m := conversion.ResourceReadMetadata(item, t)
+
+		if !o.filter(&m, t) {
+			continue
+		}
+
+		filtered = append(filtered, listItem[T]{item: item, metadata: m})
+	}
+
+	slices.SortStableFunc(filtered, func(a, b listItem[T]) int {
+		return o.compare(&a.metadata, &b.metadata)
+	})
+
+	start := min(offset, len(filtered))
+	end := len(filtered)
+
+	if o.Limit != 0 && start+o.Limit < end {
+		end = start + o.Limit
+
+		token, err := (&pageToken{Offset: end, Sort: o.sort}).encode()
+		if err != nil {
+			return nil, nil, errors.OAuth2ServerError("unable to encode page token").WithError(err)
+		}
+
+		metadata.NextPageToken = ptr.To(token)
+	}
+
+	out := make([]T, 0, end-start)
+
+	for i := start; i < end; i++ {
+		out = append(out, filtered[i].item)
+	}
+
+	return out, metadata, nil
+}
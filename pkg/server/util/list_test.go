@@ -0,0 +1,384 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	unikornv1core "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/constants"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testListItems = 10
+
+// testItems returns resources whose IDs are ordered, but names and creation times
+// are ordered in reverse, so sorting can be checked.
+func testItems() []*corev1.ConfigMap {
+	items := make([]*corev1.ConfigMap, testListItems)
+
+	epoch := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range items {
+		items[i] = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "id-" + strconv.Itoa(i),
+				Labels: map[string]string{
+					constants.NameLabel: "name-" + strconv.Itoa(testListItems-i-1),
+				},
+				CreationTimestamp: metav1.NewTime(epoch.Add(-time.Duration(i) * time.Hour)),
+			},
+		}
+	}
+
+	return items
+}
+
+// parityTags returns tags for a resource, even items are tagged "parity=even".
+func parityTags(in *corev1.ConfigMap) unikornv1core.TagList {
+	parity := "odd"
+
+	if in.Name[len(in.Name)-1]%2 == 0 {
+		parity = "even"
+	}
+
+	return unikornv1core.TagList{
+		{Name: "parity", Value: parity},
+	}
+}
+
+func ids(items []*corev1.ConfigMap) []string {
+	out := make([]string, len(items))
+
+	for i := range items {
+		out[i] = items[i].Name
+	}
+
+	return out
+}
+
+func listOptions(t *testing.T, limit *int, pageToken, sort *string) *util.ListOptions {
+	t.Helper()
+
+	options, err := util.DecodeListParams(limit, pageToken, sort)
+	require.NoError(t, err)
+
+	return options
+}
+
+// encodeToken returns a raw page token, as if crafted by a client.
+func encodeToken(raw string) *string {
+	return ptr.To(base64.RawURLEncoding.EncodeToString([]byte(raw)))
+}
+
+// TestDecodeListParams checks malformed parameters are rejected as client errors.
+func TestDecodeListParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		limit     *int
+		pageToken *string
+		sort      *string
+		valid     bool
+	}{
+		{
+			name:  "Empty",
+			valid: true,
+		},
+		{
+			name:      "Valid",
+			limit:     ptr.To(10),
+			pageToken: encodeToken(`{"o":10,"s":"-name"}`),
+			sort:      ptr.To("-name"),
+			valid:     true,
+		},
+		{
+			name:  "ZeroLimit",
+			limit: ptr.To(0),
+		},
+		{
+			name:  "NegativeLimit",
+			limit: ptr.To(-1),
+		},
+		{
+			name: "InvalidSortField",
+			sort: ptr.To("color"),
+		},
+		{
+			name:      "TokenNotBase64",
+			pageToken: ptr.To("!!!"),
+		},
+		{
+			name:      "TokenNotJSON",
+			pageToken: encodeToken("offset"),
+		},
+		{
+			name:      "TokenNegativeOffset",
+			pageToken: encodeToken(`{"o":-3}`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := util.DecodeListParams(test.limit, test.pageToken, test.sort)
+
+			if test.valid {
+				require.NoError(t, err)
+				return
+			}
+
+			var e *errors.Error
+
+			require.ErrorAs(t, err, &e)
+			require.Equal(t, http.StatusBadRequest, e.StatusCode())
+		})
+	}
+}
+
+// TestListPagination checks paging through a list in memory returns every item
+// exactly once, in the requested order.
+func TestListPagination(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sort     *string
+		expected []string
+	}{
+		{
+			name:     "ID",
+			expected: []string{"id-0", "id-1", "id-2", "id-3", "id-4", "id-5", "id-6", "id-7", "id-8", "id-9"},
+		},
+		{
+			name:     "Name",
+			sort:     ptr.To("name"),
+			expected: []string{"id-9", "id-8", "id-7", "id-6", "id-5", "id-4", "id-3", "id-2", "id-1", "id-0"},
+		},
+		{
+			name:     "NameDescending",
+			sort:     ptr.To("-name"),
+			expected: []string{"id-0", "id-1", "id-2", "id-3", "id-4", "id-5", "id-6", "id-7", "id-8", "id-9"},
+		},
+		{
+			name:     "CreationTime",
+			sort:     ptr.To("creationTime"),
+			expected: []string{"id-9", "id-8", "id-7", "id-6", "id-5", "id-4", "id-3", "id-2", "id-1", "id-0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var pageToken *string
+
+			var actual []string
+
+			for range testListItems {
+				options := listOptions(t, ptr.To(3), pageToken, test.sort)
+
+				// Unsorted lists would be paginated by Kubernetes if requested,
+				// but filter only options are safe to use with a cache.
+				require.NotContains(t, options.KubernetesListOptions(), client.Limit(3))
+
+				items, metadata, err := util.List(options, nil, testItems(), nil)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(items), 3)
+
+				actual = append(actual, ids(items)...)
+
+				pageToken = metadata.NextPageToken
+				if pageToken == nil {
+					break
+				}
+			}
+
+			require.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+// TestListPageTokens checks page tokens that don't make sense for the list are
+// handled safely.
+func TestListPageTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		pageToken *string
+		sort      *string
+		expected  []string
+		status    int
+	}{
+		{
+			name:      "OffsetPastEnd",
+			pageToken: encodeToken(`{"o":100}`),
+			expected:  []string{},
+		},
+		{
+			name:      "SortMismatch",
+			pageToken: encodeToken(`{"o":3,"s":"name"}`),
+			sort:      ptr.To("-name"),
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "SortRemoved",
+			pageToken: encodeToken(`{"o":3,"s":"name"}`),
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "ContinueWithoutPagination",
+			pageToken: encodeToken(`{"c":"abc"}`),
+			status:    http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			items, metadata, err := util.List(listOptions(t, ptr.To(3), test.pageToken, test.sort), nil, testItems(), nil)
+
+			if test.status != 0 {
+				var e *errors.Error
+
+				require.ErrorAs(t, err, &e)
+				require.Equal(t, test.status, e.StatusCode())
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, ids(items))
+			require.Nil(t, metadata.NextPageToken)
+		})
+	}
+}
+
+// TestListFilter checks filters are applied before pagination.
+func TestListFilter(t *testing.T) {
+	t.Parallel()
+
+	even, err := util.ParseTagSelector([]string{"parity=even"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		options  func(*util.ListOptions) *util.ListOptions
+		expected []string
+	}{
+		{
+			name: "Name",
+			options: func(o *util.ListOptions) *util.ListOptions {
+				return o.WithNameFilter(ptr.To("name-0"))
+			},
+			expected: []string{"id-9"},
+		},
+		{
+			// Resources without status conditions are considered provisioned.
+			name: "ProvisioningStatus",
+			options: func(o *util.ListOptions) *util.ListOptions {
+				return o.WithProvisioningStatusFilter(&openapi.ProvisioningStatusFilterParameter{openapi.ResourceProvisioningStatusProvisioned})
+			},
+			expected: []string{"id-0", "id-1", "id-2", "id-3", "id-4", "id-5", "id-6", "id-7", "id-8", "id-9"},
+		},
+		{
+			name: "ProvisioningStatusNoMatch",
+			options: func(o *util.ListOptions) *util.ListOptions {
+				return o.WithProvisioningStatusFilter(&openapi.ProvisioningStatusFilterParameter{openapi.ResourceProvisioningStatusError})
+			},
+			expected: []string{},
+		},
+		{
+			name: "Tags",
+			options: func(o *util.ListOptions) *util.ListOptions {
+				return o.WithTagFilter(even)
+			},
+			expected: []string{"id-0", "id-2", "id-4", "id-6", "id-8"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			items, _, err := util.List(test.options(listOptions(t, nil, nil, nil)), nil, testItems(), parityTags)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, ids(items))
+		})
+	}
+}
+
+// TestListKubernetesPagination checks pagination is only pushed down to the API
+// server when explicitly requested, and only where the list is in its natural order.
+func TestListKubernetesPagination(t *testing.T) {
+	t.Parallel()
+
+	options := listOptions(t, ptr.To(3), encodeToken(`{"c":"abc"}`), nil)
+	require.Contains(t, options.PaginatedKubernetesListOptions(), client.Limit(3))
+	require.Contains(t, options.PaginatedKubernetesListOptions(), client.Continue("abc"))
+
+	items := testItems()[:3]
+
+	list := &corev1.ConfigMapList{
+		ListMeta: metav1.ListMeta{
+			Continue: "def",
+		},
+	}
+
+	out, metadata, err := util.List(options, list, items, nil)
+	require.NoError(t, err)
+	require.Equal(t, ids(items), ids(out))
+	require.NotNil(t, metadata.NextPageToken)
+
+	// The next page continues from where the API server left off.
+	options = listOptions(t, ptr.To(3), metadata.NextPageToken, nil)
+	require.Contains(t, options.PaginatedKubernetesListOptions(), client.Continue("def"))
+
+	// In memory page tokens cannot be used with Kubernetes pagination.
+	options = listOptions(t, ptr.To(3), encodeToken(`{"o":3}`), nil)
+	options.PaginatedKubernetesListOptions()
+
+	_, _, err = util.List(options, list, items, nil)
+	require.Error(t, err)
+
+	// Sorted lists are paginated in memory.
+	options = listOptions(t, ptr.To(3), nil, ptr.To("name"))
+	require.NotContains(t, options.PaginatedKubernetesListOptions(), client.Limit(3))
+
+	out, metadata, err = util.List(options, nil, testItems(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"id-9", "id-8", "id-7"}, ids(out))
+	require.NotNil(t, metadata.NextPageToken)
+}
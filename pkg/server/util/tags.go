@@ -24,6 +24,11 @@ import (
 	"github.com/unikorn-cloud/core/pkg/server/errors"
 )
 
+// DecodeTagSelectorParam decodes tags in the form "name=value", which must all match.
+// Tags are split on the first "=", everything after it is the value, verbatim.
+//
+// Deprecated: use DecodeTagSelector, which supports the full expression syntax
+// documented by the tagSelectorParameter.
 func DecodeTagSelectorParam(tags *openapi.TagSelectorParameter) (unikornv1core.TagList, error) {
 	if tags == nil {
 		return nil, nil
@@ -32,16 +37,30 @@ func DecodeTagSelectorParam(tags *openapi.TagSelectorParameter) (unikornv1core.T
 	out := make(unikornv1core.TagList, len(*tags))
 
 	for i, tag := range *tags {
-		parts := strings.Split(tag, "=")
-		if len(parts) != 2 {
+		name, value, ok := strings.Cut(tag, "=")
+		if !ok {
 			return nil, errors.OAuth2InvalidRequest("tag decode failed")
 		}
 
 		out[i] = unikornv1core.Tag{
-			Name:  parts[0],
-			Value: parts[1],
+			Name:  name,
+			Value: value,
 		}
 	}
 
 	return out, nil
 }
+
+// DecodeTagSelector decodes tag selector expressions, all of which must match.
+func DecodeTagSelector(tags *openapi.TagSelectorParameter) (TagSelector, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	out, err := ParseTagSelector(*tags)
+	if err != nil {
+		return nil, errors.OAuth2InvalidRequest(err.Error()).WithError(err)
+	}
+
+	return out, nil
+}
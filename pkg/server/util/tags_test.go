@@ -0,0 +1,130 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	unikornv1core "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	"github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+)
+
+// TestDecodeTagSelectorParam checks the legacy decoder splits on the first "=" and
+// takes everything else verbatim, as it always has, rather than using the selector
+// grammar.
+func TestDecodeTagSelectorParam(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		tags     *openapi.TagSelectorParameter
+		expected unikornv1core.TagList
+		invalid  bool
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name:     "Empty",
+			tags:     &openapi.TagSelectorParameter{},
+			expected: unikornv1core.TagList{},
+		},
+		{
+			name: "Equals",
+			tags: &openapi.TagSelectorParameter{"env=prod", "team=a"},
+			expected: unikornv1core.TagList{
+				{Name: "env", Value: "prod"},
+				{Name: "team", Value: "a"},
+			},
+		},
+		{
+			name: "Spaces",
+			tags: &openapi.TagSelectorParameter{"owner=John Smith"},
+			expected: unikornv1core.TagList{
+				{Name: "owner", Value: "John Smith"},
+			},
+		},
+		{
+			name: "Comma",
+			tags: &openapi.TagSelectorParameter{"name=a,b"},
+			expected: unikornv1core.TagList{
+				{Name: "name", Value: "a,b"},
+			},
+		},
+		{
+			name: "Operators",
+			tags: &openapi.TagSelectorParameter{"expr=(a|b)", "negated=!a", "pipe=a|b"},
+			expected: unikornv1core.TagList{
+				{Name: "expr", Value: "(a|b)"},
+				{Name: "negated", Value: "!a"},
+				{Name: "pipe", Value: "a|b"},
+			},
+		},
+		{
+			name: "QuotesPreserved",
+			tags: &openapi.TagSelectorParameter{`name="quoted"`},
+			expected: unikornv1core.TagList{
+				{Name: "name", Value: `"quoted"`},
+			},
+		},
+		{
+			name: "EmptyValue",
+			tags: &openapi.TagSelectorParameter{"env="},
+			expected: unikornv1core.TagList{
+				{Name: "env", Value: ""},
+			},
+		},
+		{
+			name: "SplitOnFirstEquals",
+			tags: &openapi.TagSelectorParameter{"query=a=b"},
+			expected: unikornv1core.TagList{
+				{Name: "query", Value: "a=b"},
+			},
+		},
+		{
+			name:    "NoEquals",
+			tags:    &openapi.TagSelectorParameter{"env"},
+			invalid: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			//nolint:staticcheck
+			tags, err := util.DecodeTagSelectorParam(test.tags)
+
+			if test.invalid {
+				var e *errors.Error
+
+				require.ErrorAs(t, err, &e)
+				require.Equal(t, http.StatusBadRequest, e.StatusCode())
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, tags)
+		})
+	}
+}
@@ -0,0 +1,540 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	goerrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	unikornv1core "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+)
+
+var (
+	// ErrTagSelector is raised when a tag selector cannot be parsed.
+	ErrTagSelector = goerrors.New("tag selector invalid")
+)
+
+// TagOperator defines how a tag requirement is evaluated.
+type TagOperator string
+
+const (
+	// TagOperatorEquals matches when the tag exists and has the value.
+	TagOperatorEquals TagOperator = "="
+
+	// TagOperatorNotEquals matches when the tag doesn't exist or doesn't
+	// have the value.
+	TagOperatorNotEquals TagOperator = "!="
+
+	// TagOperatorIn matches when the tag exists and has one of the values.
+	TagOperatorIn TagOperator = "in"
+
+	// TagOperatorNotIn matches when the tag doesn't exist or doesn't have
+	// any of the values.
+	TagOperatorNotIn TagOperator = "notin"
+
+	// TagOperatorExists matches when the tag exists.
+	TagOperatorExists TagOperator = "exists"
+
+	// TagOperatorDoesNotExist matches when the tag doesn't exist.
+	TagOperatorDoesNotExist TagOperator = "!"
+)
+
+// TagRequirement is a single constraint on a tag.
+type TagRequirement struct {
+	// Name is the tag name.
+	Name string
+
+	// Operator defines how the tag is evaluated.
+	Operator TagOperator
+
+	// Values are the values the operator uses, if any.
+	Values []string
+}
+
+// Matches returns true if the tags satisfy the requirement.
+func (r *TagRequirement) Matches(tags unikornv1core.TagList) bool {
+	i := slices.IndexFunc(tags, func(tag unikornv1core.Tag) bool {
+		return tag.Name == r.Name
+	})
+
+	exists := i >= 0
+
+	switch r.Operator {
+	case TagOperatorEquals, TagOperatorIn:
+		return exists && slices.Contains(r.Values, tags[i].Value)
+	case TagOperatorNotEquals, TagOperatorNotIn:
+		return !exists || !slices.Contains(r.Values, tags[i].Value)
+	case TagOperatorExists:
+		return exists
+	case TagOperatorDoesNotExist:
+		return !exists
+	}
+
+	return false
+}
+
+// TagRequirements matches when all the requirements match.
+type TagRequirements []TagRequirement
+
+// Matches returns true if the tags satisfy all the requirements.
+func (r TagRequirements) Matches(tags unikornv1core.TagList) bool {
+	for i := range r {
+		if !r[i].Matches(tags) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TagExpression matches when any of the groups of requirements match.
+type TagExpression []TagRequirements
+
+// Matches returns true if the tags satisfy any of the groups of requirements.
+func (e TagExpression) Matches(tags unikornv1core.TagList) bool {
+	for _, requirements := range e {
+		if requirements.Matches(tags) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TagSelector matches when all the expressions match.  An empty selector
+// matches everything.
+type TagSelector []TagExpression
+
+// Matches returns true if the tags satisfy all the expressions.
+func (s TagSelector) Matches(tags unikornv1core.TagList) bool {
+	for _, expression := range s {
+		if !expression.Matches(tags) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseTagSelector parses a set of tag expressions, all of which must match.
+func ParseTagSelector(in []string) (TagSelector, error) {
+	out := make(TagSelector, len(in))
+
+	for i := range in {
+		expression, err := ParseTagExpression(in[i])
+		if err != nil {
+			return nil, err
+		}
+
+		out[i] = expression
+	}
+
+	return out, nil
+}
+
+// tagTokenType is the type of lexical token.
+type tagTokenType int
+
+const (
+	tagTokenEnd tagTokenType = iota
+	tagTokenString
+	tagTokenEquals
+	tagTokenNotEquals
+	tagTokenNot
+	tagTokenOpen
+	tagTokenClose
+	tagTokenComma
+	tagTokenOr
+)
+
+// tagToken is a lexical token.
+type tagToken struct {
+	// t is the token type.
+	t tagTokenType
+
+	// value is the string value, with any quoting removed.
+	value string
+
+	// quoted is true if the string was quoted, and thus is not a keyword.
+	quoted bool
+
+	// position is the 1 based character offset of the token for error
+	// reporting.
+	position int
+}
+
+// String returns a human readable description of the token.
+func (t *tagToken) String() string {
+	switch t.t {
+	case tagTokenEnd:
+		return "end of expression"
+	case tagTokenString:
+		return fmt.Sprintf("%q", t.value)
+	case tagTokenEquals:
+		return `"="`
+	case tagTokenNotEquals:
+		return `"!="`
+	case tagTokenNot:
+		return `"!"`
+	case tagTokenOpen:
+		return `"("`
+	case tagTokenClose:
+		return `")"`
+	case tagTokenComma:
+		return `","`
+	case tagTokenOr:
+		return `"|"`
+	}
+
+	return "unknown token"
+}
+
+// isTagDelimiter returns true if the character ends a bare string.
+func isTagDelimiter(r rune) bool {
+	return strings.ContainsRune(" \t=!(),|\"", r)
+}
+
+// tagLexer breaks an expression into tokens.
+type tagLexer struct {
+	in       []rune
+	position int
+}
+
+// tagSelectorErrorf reports an error at a given position.
+func tagSelectorErrorf(position int, format string, a ...any) error {
+	return fmt.Errorf("%w: %s at position %d", ErrTagSelector, fmt.Sprintf(format, a...), position)
+}
+
+// quoted lexes a double quoted string, supporting backslash escapes.
+func (l *tagLexer) quoted() (*tagToken, error) {
+	start := l.position + 1
+
+	var value strings.Builder
+
+	for l.position++; l.position < len(l.in); l.position++ {
+		r := l.in[l.position]
+
+		switch r {
+		case '\\':
+			l.position++
+
+			if l.position == len(l.in) {
+				return nil, tagSelectorErrorf(l.position+1, "unterminated escape sequence")
+			}
+
+			value.WriteRune(l.in[l.position])
+		case '"':
+			l.position++
+
+			return &tagToken{t: tagTokenString, value: value.String(), quoted: true, position: start}, nil
+		default:
+			value.WriteRune(r)
+		}
+	}
+
+	return nil, tagSelectorErrorf(start, "unterminated quoted string")
+}
+
+// next returns the next token.
+func (l *tagLexer) next() (*tagToken, error) {
+	for l.position < len(l.in) && (l.in[l.position] == ' ' || l.in[l.position] == '\t') {
+		l.position++
+	}
+
+	if l.position == len(l.in) {
+		return &tagToken{t: tagTokenEnd, position: l.position + 1}, nil
+	}
+
+	start := l.position + 1
+
+	single := func(t tagTokenType) (*tagToken, error) {
+		l.position++
+
+		return &tagToken{t: t, position: start}, nil
+	}
+
+	switch l.in[l.position] {
+	case '=':
+		// Accept "==" as a synonym, as Kubernetes does.
+		if l.position+1 < len(l.in) && l.in[l.position+1] == '=' {
+			l.position++
+		}
+
+		return single(tagTokenEquals)
+	case '!':
+		if l.position+1 < len(l.in) && l.in[l.position+1] == '=' {
+			l.position++
+
+			return single(tagTokenNotEquals)
+		}
+
+		return single(tagTokenNot)
+	case '(':
+		return single(tagTokenOpen)
+	case ')':
+		return single(tagTokenClose)
+	case ',':
+		return single(tagTokenComma)
+	case '|':
+		return single(tagTokenOr)
+	case '"':
+		return l.quoted()
+	}
+
+	end := l.position
+
+	for end < len(l.in) && !isTagDelimiter(l.in[end]) {
+		end++
+	}
+
+	value := string(l.in[l.position:end])
+
+	l.position = end
+
+	return &tagToken{t: tagTokenString, value: value, position: start}, nil
+}
+
+// tagParser is a recursive descent parser for tag expressions with the grammar:
+//
+//	expression   := requirements { "|" requirements }
+//	requirements := requirement { "," requirement }
+//	requirement  := "!" name | name [ ( "=" | "==" | "!=" ) value | ( "in" | "notin" ) set ]
+//	set          := "(" [ value { "," value } ] ")"
+type tagParser struct {
+	lexer *tagLexer
+
+	// token is the current lookahead token.
+	token *tagToken
+}
+
+// advance reads the next lookahead token.
+func (p *tagParser) advance() error {
+	token, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+
+	p.token = token
+
+	return nil
+}
+
+// expect consumes a token of the given type.
+func (p *tagParser) expect(t tagTokenType, description string) (*tagToken, error) {
+	token := p.token
+
+	if token.t != t {
+		return nil, tagSelectorErrorf(token.position, "expected %s, got %s", description, token)
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// value parses a tag value, which may be empty.
+func (p *tagParser) value() (string, error) {
+	switch p.token.t {
+	case tagTokenString:
+		value := p.token.value
+
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+
+		return value, nil
+	case tagTokenEnd, tagTokenComma, tagTokenOr, tagTokenClose:
+		return "", nil
+	case tagTokenEquals, tagTokenNotEquals, tagTokenNot, tagTokenOpen:
+	}
+
+	return "", tagSelectorErrorf(p.token.position, "expected tag value, got %s", p.token)
+}
+
+// set parses a parenthesized list of values.
+func (p *tagParser) set() ([]string, error) {
+	if _, err := p.expect(tagTokenOpen, `"("`); err != nil {
+		return nil, err
+	}
+
+	var values []string
+
+	if p.token.t == tagTokenClose {
+		return nil, tagSelectorErrorf(p.token.position, "expected at least one tag value")
+	}
+
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+
+		if p.token.t != tagTokenComma {
+			break
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.expect(tagTokenClose, `"," or ")"`); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// requirement parses a single tag requirement.
+func (p *tagParser) requirement() (*TagRequirement, error) {
+	if p.token.t == tagTokenNot {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.expect(tagTokenString, "tag name")
+		if err != nil {
+			return nil, err
+		}
+
+		return &TagRequirement{Name: name.value, Operator: TagOperatorDoesNotExist}, nil
+	}
+
+	name, err := p.expect(tagTokenString, `tag name or "!"`)
+	if err != nil {
+		return nil, err
+	}
+
+	requirement := &TagRequirement{
+		Name: name.value,
+	}
+
+	//nolint:exhaustive
+	switch p.token.t {
+	case tagTokenEquals, tagTokenNotEquals:
+		requirement.Operator = TagOperatorEquals
+
+		if p.token.t == tagTokenNotEquals {
+			requirement.Operator = TagOperatorNotEquals
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		requirement.Values = []string{value}
+	case tagTokenString:
+		if p.token.quoted || (p.token.value != string(TagOperatorIn) && p.token.value != string(TagOperatorNotIn)) {
+			return nil, tagSelectorErrorf(p.token.position, `expected operator "=", "!=", "in" or "notin", got %s`, p.token)
+		}
+
+		requirement.Operator = TagOperator(p.token.value)
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		values, err := p.set()
+		if err != nil {
+			return nil, err
+		}
+
+		requirement.Values = values
+	default:
+		requirement.Operator = TagOperatorExists
+	}
+
+	return requirement, nil
+}
+
+// requirements parses a group of requirements that must all match.
+func (p *tagParser) requirements() (TagRequirements, error) {
+	var out TagRequirements
+
+	for {
+		requirement, err := p.requirement()
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, *requirement)
+
+		if p.token.t != tagTokenComma {
+			return out, nil
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ParseTagExpression parses a tag expression, for example "env in (prod,staging),team=a|owner".
+// Requirements separated by commas must all match, and groups of requirements separated by
+// vertical bars are alternatives.  Names and values containing special characters or spaces
+// may be double quoted.
+func ParseTagExpression(in string) (TagExpression, error) {
+	p := &tagParser{
+		lexer: &tagLexer{
+			in: []rune(in),
+		},
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.token.t == tagTokenEnd {
+		return nil, tagSelectorErrorf(p.token.position, "expression is empty")
+	}
+
+	var out TagExpression
+
+	for {
+		requirements, err := p.requirements()
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, requirements)
+
+		if p.token.t != tagTokenOr {
+			break
+		}
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.expect(tagTokenEnd, `",", "|" or end of expression`); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	unikornv1core "github.com/unikorn-cloud/core/pkg/apis/unikorn/v1alpha1"
+	"github.com/unikorn-cloud/core/pkg/server/util"
+)
+
+//nolint:gochecknoglobals
+var testTags = unikornv1core.TagList{
+	{Name: "env", Value: "prod"},
+	{Name: "team", Value: "a"},
+	{Name: "description", Value: "hello world"},
+}
+
+func TestTagExpressionMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expression string
+		matches    bool
+	}{
+		{`env=prod`, true},
+		{`env==prod`, true},
+		{`env=dev`, false},
+		{`env!=dev`, true},
+		{`owner!=bob`, true},
+		{`env`, true},
+		{`owner`, false},
+		{`!owner`, true},
+		{`!env`, false},
+		{`env in (dev, prod)`, true},
+		{`env in (dev,staging)`, false},
+		{`env notin (dev,staging)`, true},
+		{`owner notin (bob)`, true},
+		{`env=prod,team=b`, false},
+		{`env=prod,team=b|team=a`, true},
+		{`owner|!env|team in (a)`, true},
+		{`description="hello world"`, true},
+		{`"in" notin (x)`, true},
+	}
+
+	for _, test := range tests {
+		expression, err := util.ParseTagExpression(test.expression)
+		require.NoError(t, err, test.expression)
+		require.Equal(t, test.matches, expression.Matches(testTags), test.expression)
+	}
+}
+
+func TestTagExpressionInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expression string
+		message    string
+	}{
+		{``, "tag selector invalid: expression is empty at position 1"},
+		{`env=prod,`, `tag selector invalid: expected tag name or "!", got end of expression at position 10`},
+		{`env in dev`, `tag selector invalid: expected "(", got "dev" at position 8`},
+		{`env in (dev`, `tag selector invalid: expected "," or ")", got end of expression at position 12`},
+		{`env in ()`, `tag selector invalid: expected at least one tag value at position 9`},
+		{`env matches x`, `tag selector invalid: expected operator "=", "!=", "in" or "notin", got "matches" at position 5`},
+		{`env=hello world`, `tag selector invalid: expected ",", "|" or end of expression, got "world" at position 11`},
+		{`env="prod`, "tag selector invalid: unterminated quoted string at position 5"},
+		{`!=prod`, `tag selector invalid: expected tag name or "!", got "!=" at position 1`},
+	}
+
+	for _, test := range tests {
+		_, err := util.ParseTagExpression(test.expression)
+		require.ErrorIs(t, err, util.ErrTagSelector, test.expression)
+		require.EqualError(t, err, test.message, test.expression)
+	}
+}
+
+func TestTagSelectorMatches(t *testing.T) {
+	t.Parallel()
+
+	selector, err := util.ParseTagSelector([]string{"env=prod", "team in (a,b)"})
+	require.NoError(t, err)
+	require.True(t, selector.Matches(testTags))
+
+	selector, err = util.ParseTagSelector([]string{"env=prod", "!team"})
+	require.NoError(t, err)
+	require.False(t, selector.Matches(testTags))
+
+	selector, err = util.ParseTagSelector(nil)
+	require.NoError(t, err)
+	require.True(t, selector.Matches(testTags))
+}
@@ -22,6 +22,7 @@ import (
 	"reflect"
 
 	"github.com/unikorn-cloud/core/pkg/openapi"
+	servererrors "github.com/unikorn-cloud/core/pkg/server/errors"
 )
 
 var (
@@ -30,8 +31,56 @@ var (
 	ErrAPI = errors.New("api error")
 )
 
+// apiError is returned by ExtractError.  It is both an ErrAPI, and a server error
+// so that it can be handled directly by HandleError, and interrogated with
+// predicates such as IsHTTPNotFound.
+type apiError struct {
+	err *servererrors.Error
+}
+
+// Error implements the error interface.
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%v: %v - %v", ErrAPI, e.err.Type(), e.err.Error())
+}
+
+// Unwrap implements Go 1.20 errors.
+func (e *apiError) Unwrap() []error {
+	return []error{ErrAPI, e.err}
+}
+
+// ErrorDetails returns details of why parts of the request were invalid, if any,
+// from an error returned by ExtractError.
+func ErrorDetails(err error) []openapi.ErrorDetail {
+	var httpError *servererrors.Error
+
+	if !errors.As(err, &httpError) {
+		return nil
+	}
+
+	details := httpError.Details()
+	if details == nil {
+		return nil
+	}
+
+	out := make([]openapi.ErrorDetail, len(details))
+
+	for i := range details {
+		out[i] = openapi.ErrorDetail{
+			Message: details[i].Message,
+			Reason:  details[i].Reason,
+		}
+
+		if pointer := details[i].Pointer; pointer != "" {
+			out[i].Pointer = &pointer
+		}
+	}
+
+	return out
+}
+
 // ExtractError provides a response type agnostic way of extracting a human readable
-// error from an API.
+// error from an API.  The error can be handled by HandleError, which will return
+// the same error to the client, or be remapped using predicates such as IsHTTPNotFound.
 func ExtractError(statusCode int, response any) error {
 	if statusCode < 400 {
 		return fmt.Errorf("%w: status code %d not valid", ErrExtraction, statusCode)
@@ -53,7 +102,7 @@ func ExtractError(statusCode int, response any) error {
 
 	v = v.FieldByName(fieldName)
 
-	if v.IsZero() {
+	if !v.IsValid() || v.IsZero() {
 		return fmt.Errorf("%w: error field %s not defined", ErrExtraction, fieldName)
 	}
 
@@ -63,5 +112,27 @@ func ExtractError(statusCode int, response any) error {
 		return fmt.Errorf("%w: unable to assert error", ErrExtraction)
 	}
 
-	return fmt.Errorf("%w: %v - %v", ErrAPI, concreteError.Error, concreteError.ErrorDescription)
+	oauth2Error := &servererrors.OAuth2Error{
+		Error:       servererrors.OAuth2ErrorType(concreteError.Error),
+		Description: concreteError.ErrorDescription,
+	}
+
+	if concreteError.Details != nil {
+		for _, detail := range *concreteError.Details {
+			d := servererrors.Detail{
+				Reason:  detail.Reason,
+				Message: detail.Message,
+			}
+
+			if detail.Pointer != nil {
+				d.Pointer = *detail.Pointer
+			}
+
+			oauth2Error.Details = append(oauth2Error.Details, d)
+		}
+	}
+
+	return &apiError{
+		err: servererrors.FromOAuth2Error(statusCode, oauth2Error),
+	}
 }
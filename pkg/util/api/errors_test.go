@@ -0,0 +1,129 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	servererrors "github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/util/api"
+
+	"k8s.io/utils/ptr"
+)
+
+// testResponse emulates a generated client response.
+type testResponse struct {
+	JSON400 *openapi.Error
+	JSON404 *openapi.Error
+}
+
+// TestExtractError checks API errors are reconstructed as typed server errors,
+// preserving any details.
+func TestExtractError(t *testing.T) {
+	t.Parallel()
+
+	details := []openapi.ErrorDetail{
+		{
+			Pointer: ptr.To("/metadata/name"),
+			Reason:  "required",
+			Message: "name is required",
+		},
+		{
+			Reason:  "invalid",
+			Message: "request is invalid",
+		},
+	}
+
+	response := &testResponse{
+		JSON400: &openapi.Error{
+			Error:            openapi.InvalidRequest,
+			ErrorDescription: "request body invalid",
+			Details:          &details,
+		},
+	}
+
+	err := api.ExtractError(http.StatusBadRequest, response)
+	require.ErrorIs(t, err, api.ErrAPI)
+
+	var e *servererrors.Error
+
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.StatusCode())
+	require.Equal(t, details, api.ErrorDetails(err))
+}
+
+// TestExtractErrorNoDetails checks errors without details have no details.
+func TestExtractErrorNoDetails(t *testing.T) {
+	t.Parallel()
+
+	response := &testResponse{
+		JSON404: &openapi.Error{
+			Error:            openapi.NotFound,
+			ErrorDescription: "resource not found",
+		},
+	}
+
+	err := api.ExtractError(http.StatusNotFound, response)
+	require.True(t, servererrors.IsHTTPNotFound(err))
+	require.Nil(t, api.ErrorDetails(err))
+}
+
+// TestExtractErrorInvalid checks responses that cannot be decoded are reported.
+func TestExtractErrorInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		statusCode int
+		response   any
+	}{
+		{
+			name:       "Success",
+			statusCode: http.StatusOK,
+			response:   &testResponse{},
+		},
+		{
+			name:       "NotStruct",
+			statusCode: http.StatusBadRequest,
+			response:   "error",
+		},
+		{
+			name:       "MissingField",
+			statusCode: http.StatusConflict,
+			response:   &testResponse{},
+		},
+		{
+			name:       "NilField",
+			statusCode: http.StatusBadRequest,
+			response:   &testResponse{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := api.ExtractError(test.statusCode, test.response)
+			require.ErrorIs(t, err, api.ErrExtraction)
+			require.Nil(t, api.ErrorDetails(err))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	servererrors "github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/util/retry"
+)
+
+var (
+	// ErrOperationFailed is returned when an asynchronous operation fails.
+	ErrOperationFailed = errors.New("operation failed")
+
+	// errOperationIncomplete is used to keep polling.
+	errOperationIncomplete = errors.New("operation incomplete")
+)
+
+// OperationGetter reads an operation, typically by calling the generated client
+// with the operation ID from the Location header of an accepted response, and
+// using ExtractError to handle any errors.
+type OperationGetter func(ctx context.Context) (*openapi.Operation, error)
+
+// retryable returns true if polling should continue after an error.  Client errors
+// e.g. a missing operation, will never resolve themselves.
+func retryable(err error) bool {
+	var httpError *servererrors.Error
+
+	if !errors.As(err, &httpError) {
+		return true
+	}
+
+	status := httpError.StatusCode()
+
+	return status < http.StatusBadRequest || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
+
+// WaitForOperation polls an operation until it has completed, or the context is
+// cancelled.  If the operation failed, the operation is returned along with an
+// ErrOperationFailed error describing why.
+func WaitForOperation(ctx context.Context, get OperationGetter) (*openapi.Operation, error) {
+	var operation *openapi.Operation
+
+	var terminal error
+
+	callback := func() error {
+		result, err := get(ctx)
+		if err != nil {
+			if !retryable(err) {
+				operation = nil
+				terminal = err
+
+				return nil
+			}
+
+			return err
+		}
+
+		operation = result
+
+		switch operation.Status {
+		case openapi.Succeeded:
+			return nil
+		case openapi.Failed:
+			terminal = fmt.Errorf("%w: %s", ErrOperationFailed, operation.Id)
+
+			if operation.Error != nil {
+				terminal = fmt.Errorf("%w: %s - %s - %s", ErrOperationFailed, operation.Id, operation.Error.Error, operation.Error.ErrorDescription)
+			}
+
+			return nil
+		}
+
+		return fmt.Errorf("%w: %s is %s", errOperationIncomplete, operation.Id, operation.Status)
+	}
+
+	if err := retry.Forever().DoWithContext(ctx, callback); err != nil {
+		return nil, err
+	}
+
+	if terminal != nil {
+		return operation, terminal
+	}
+
+	return operation, nil
+}
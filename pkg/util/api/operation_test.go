@@ -0,0 +1,197 @@
+/*
+Copyright 2026 the Unikorn Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api_test
+
+import (
+	"context"
+	goerrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/unikorn-cloud/core/pkg/openapi"
+	servererrors "github.com/unikorn-cloud/core/pkg/server/errors"
+	"github.com/unikorn-cloud/core/pkg/util/api"
+)
+
+var errTransient = goerrors.New("connection reset")
+
+type result struct {
+	operation *openapi.Operation
+	err       error
+}
+
+// sequence returns a getter that returns each result in turn, repeating the last.
+func sequence(results ...result) (api.OperationGetter, *int) {
+	calls := 0
+
+	return func(ctx context.Context) (*openapi.Operation, error) {
+		r := results[min(calls, len(results)-1)]
+
+		calls++
+
+		return r.operation, r.err
+	}, &calls
+}
+
+func testOperation(status openapi.OperationStatus) *openapi.Operation {
+	return &openapi.Operation{
+		Id:     "foo",
+		Status: status,
+	}
+}
+
+// TestWaitForOperation checks polling continues through incomplete operations and
+// transient errors, but stops on completion or terminal errors.
+func TestWaitForOperation(t *testing.T) {
+	t.Parallel()
+
+	failed := testOperation(openapi.Failed)
+	failed.Error = &openapi.Error{
+		Error:            openapi.Conflict,
+		ErrorDescription: "resource exists",
+	}
+
+	tests := []struct {
+		name      string
+		results   []result
+		calls     int
+		status    openapi.OperationStatus
+		err       error
+		operation bool
+	}{
+		{
+			name:      "Succeeded",
+			results:   []result{{operation: testOperation(openapi.Succeeded)}},
+			calls:     1,
+			status:    openapi.Succeeded,
+			operation: true,
+		},
+		{
+			name:      "Failed",
+			results:   []result{{operation: failed}},
+			calls:     1,
+			status:    openapi.Failed,
+			err:       api.ErrOperationFailed,
+			operation: true,
+		},
+		{
+			name: "Running",
+			results: []result{
+				{operation: testOperation(openapi.Running)},
+				{operation: testOperation(openapi.Succeeded)},
+			},
+			calls:     2,
+			status:    openapi.Succeeded,
+			operation: true,
+		},
+		{
+			name: "TransientError",
+			results: []result{
+				{err: errTransient},
+				{operation: testOperation(openapi.Succeeded)},
+			},
+			calls:     2,
+			status:    openapi.Succeeded,
+			operation: true,
+		},
+		{
+			name: "ServerError",
+			results: []result{
+				{err: servererrors.OAuth2ServerError("oops")},
+				{operation: testOperation(openapi.Succeeded)},
+			},
+			calls:     2,
+			status:    openapi.Succeeded,
+			operation: true,
+		},
+		{
+			name: "TooManyRequests",
+			results: []result{
+				{err: servererrors.HTTPTooManyRequests()},
+				{operation: testOperation(openapi.Succeeded)},
+			},
+			calls:     2,
+			status:    openapi.Succeeded,
+			operation: true,
+		},
+		{
+			name: "NotFound",
+			results: []result{
+				{err: servererrors.HTTPNotFound()},
+			},
+			calls: 1,
+		},
+		{
+			name: "Forbidden",
+			results: []result{
+				{operation: testOperation(openapi.Running)},
+				{err: servererrors.HTTPForbidden("denied")},
+			},
+			calls: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			get, calls := sequence(test.results...)
+
+			operation, err := api.WaitForOperation(t.Context(), get)
+
+			require.Equal(t, test.calls, *calls)
+
+			if !test.operation {
+				require.Error(t, err)
+				require.Nil(t, operation)
+
+				// The client error is returned so it can be handled.
+				var e *servererrors.Error
+
+				require.ErrorAs(t, err, &e)
+
+				return
+			}
+
+			require.NotNil(t, operation)
+			require.Equal(t, test.status, operation.Status)
+
+			if test.err == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+// TestWaitForOperationCancel checks polling stops when the context is cancelled.
+func TestWaitForOperationCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	get, _ := sequence(result{operation: testOperation(openapi.Running)})
+
+	operation, err := api.WaitForOperation(ctx, get)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Nil(t, operation)
+}